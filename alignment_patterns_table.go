@@ -30,6 +30,18 @@ var (
 		{6, 30, 58, 86, 114},
 		{6, 34, 62, 90, 118},
 		{6, 26, 50, 74, 98, 122}, // version 28
+		{6, 30, 54, 78, 102, 126},
+		{6, 26, 52, 78, 104, 130},
+		{6, 30, 56, 82, 108, 134},
+		{6, 34, 60, 86, 112, 138},
+		{6, 30, 58, 86, 114, 142},
+		{6, 34, 62, 90, 118, 146},
+		{6, 30, 54, 78, 102, 126, 150}, // version 35
+		{6, 24, 50, 76, 102, 128, 154},
+		{6, 28, 54, 80, 106, 132, 158},
+		{6, 32, 58, 84, 110, 136, 162},
+		{6, 26, 54, 82, 110, 138, 166},
+		{6, 30, 58, 86, 114, 142, 170},
 	}
 )
 
@@ -49,3 +61,14 @@ func get_alignment_patterns_for_version(version int) [][]int {
 	}
 	return pos
 }
+
+// rMQR alignment pattern center columns, by symbol width. Each column has an
+// alignment pattern at the top and bottom edges joined by a vertical timing pattern.
+var rmqr_alignment_columns = map[int][]int{
+	27:  {},
+	43:  {21},
+	59:  {19, 39},
+	77:  {25, 51},
+	99:  {23, 49, 75},
+	139: {27, 55, 83, 111},
+}
@@ -178,3 +178,44 @@ func (bs BitSeq) String() string {
 
 	return sb.String()
 }
+
+// FromBytes creates a sequence holding every bit of data, most significant bit first.
+func FromBytes(data []byte) BitSeq {
+	res := make([]byte, len(data))
+	copy(res, data)
+	return BitSeq{data: res, nbits: len(data) * 8}
+}
+
+// Reader reads a sequence from its most significant bit onwards.
+type Reader struct {
+	bs  BitSeq
+	pos int
+}
+
+// NewReader returns a Reader positioned at the first bit of bs.
+func NewReader(bs BitSeq) *Reader {
+	return &Reader{bs: bs}
+}
+
+// Read returns the next n bits as an integer, the first bit being the most
+// significant. It returns false, without consuming anything, if fewer than n
+// bits remain.
+func (r *Reader) Read(n int) (uint64, bool) {
+	if n < 0 || n > 64 || r.Remaining() < n {
+		return 0, false
+	}
+	var val uint64
+	for range n {
+		val <<= 1
+		if r.bs.Bit(r.pos) {
+			val |= 1
+		}
+		r.pos++
+	}
+	return val, true
+}
+
+// Remaining returns the number of bits left to read.
+func (r *Reader) Remaining() int {
+	return r.bs.nbits - r.pos
+}
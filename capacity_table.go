@@ -84,14 +84,585 @@ var microCapacityData = map[int]struct {
 			ERR_CORR_Q: {
 				TotalECCodewords: 14,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 1, TotalCodewords: 14, DataCodewords: 10},
+					{NumBlocks: 1, TotalCodewords: 24, DataCodewords: 10},
+				},
+			},
+		},
+	},
+}
+
+// rMQR (ISO/IEC 23941) capacity, only error correction levels M and H are available
+var rmqrCapacityData = map[int]struct {
+	totalCodewords int
+	ecInfo         map[errcorr]ECInfo
+}{
+	1: { // R7x43
+		totalCodewords: 13,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 7,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 13, DataCodewords: 6},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 10,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 13, DataCodewords: 3},
+				},
+			},
+		},
+	},
+	2: { // R7x59
+		totalCodewords: 21,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 9,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 21, DataCodewords: 12},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 14,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 21, DataCodewords: 7},
+				},
+			},
+		},
+	},
+	3: { // R7x77
+		totalCodewords: 32,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 12,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 32, DataCodewords: 20},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 22,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 32, DataCodewords: 10},
+				},
+			},
+		},
+	},
+	4: { // R7x99
+		totalCodewords: 44,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 16,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 44, DataCodewords: 28},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 30,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 44, DataCodewords: 14},
+				},
+			},
+		},
+	},
+	5: { // R7x139
+		totalCodewords: 68,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 24,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 68, DataCodewords: 44},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 44,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 2, TotalCodewords: 34, DataCodewords: 12},
+				},
+			},
+		},
+	},
+	6: { // R9x43
+		totalCodewords: 21,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 9,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 21, DataCodewords: 12},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 14,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 21, DataCodewords: 7},
+				},
+			},
+		},
+	},
+	7: { // R9x59
+		totalCodewords: 33,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 12,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 33, DataCodewords: 21},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 22,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 33, DataCodewords: 11},
+				},
+			},
+		},
+	},
+	8: { // R9x77
+		totalCodewords: 49,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 18,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 49, DataCodewords: 31},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 32,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 24, DataCodewords: 8},
+					{NumBlocks: 1, TotalCodewords: 25, DataCodewords: 9},
+				},
+			},
+		},
+	},
+	9: { // R9x99
+		totalCodewords: 66,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 24,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 66, DataCodewords: 42},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 44,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 2, TotalCodewords: 33, DataCodewords: 11},
+				},
+			},
+		},
+	},
+	10: { // R9x139
+		totalCodewords: 99,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 36,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 49, DataCodewords: 31},
+					{NumBlocks: 1, TotalCodewords: 50, DataCodewords: 32},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 66,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 3, TotalCodewords: 33, DataCodewords: 11},
+				},
+			},
+		},
+	},
+	11: { // R11x27
+		totalCodewords: 15,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 8,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 15, DataCodewords: 7},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 10,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 15, DataCodewords: 5},
+				},
+			},
+		},
+	},
+	12: { // R11x43
+		totalCodewords: 31,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 12,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 31, DataCodewords: 19},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 20,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 31, DataCodewords: 11},
+				},
+			},
+		},
+	},
+	13: { // R11x59
+		totalCodewords: 47,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 16,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 47, DataCodewords: 31},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 32,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 23, DataCodewords: 7},
+					{NumBlocks: 1, TotalCodewords: 24, DataCodewords: 8},
+				},
+			},
+		},
+	},
+	14: { // R11x77
+		totalCodewords: 67,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 24,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 67, DataCodewords: 43},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 44,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 33, DataCodewords: 11},
+					{NumBlocks: 1, TotalCodewords: 34, DataCodewords: 12},
+				},
+			},
+		},
+	},
+	15: { // R11x99
+		totalCodewords: 89,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 32,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 44, DataCodewords: 28},
+					{NumBlocks: 1, TotalCodewords: 45, DataCodewords: 29},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 60,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 44, DataCodewords: 14},
+					{NumBlocks: 1, TotalCodewords: 45, DataCodewords: 15},
+				},
+			},
+		},
+	},
+	16: { // R11x139
+		totalCodewords: 132,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 48,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 2, TotalCodewords: 66, DataCodewords: 42},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 90,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 3, TotalCodewords: 44, DataCodewords: 14},
+				},
+			},
+		},
+	},
+	17: { // R13x27
+		totalCodewords: 21,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 9,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 21, DataCodewords: 12},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 14,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 21, DataCodewords: 7},
+				},
+			},
+		},
+	},
+	18: { // R13x43
+		totalCodewords: 41,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 14,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 41, DataCodewords: 27},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 28,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 41, DataCodewords: 13},
+				},
+			},
+		},
+	},
+	19: { // R13x59
+		totalCodewords: 60,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 22,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 60, DataCodewords: 38},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 40,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 2, TotalCodewords: 30, DataCodewords: 10},
+				},
+			},
+		},
+	},
+	20: { // R13x77
+		totalCodewords: 85,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 32,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 42, DataCodewords: 26},
+					{NumBlocks: 1, TotalCodewords: 43, DataCodewords: 27},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 56,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 42, DataCodewords: 14},
+					{NumBlocks: 1, TotalCodewords: 43, DataCodewords: 15},
+				},
+			},
+		},
+	},
+	21: { // R13x99
+		totalCodewords: 113,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 40,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 56, DataCodewords: 36},
+					{NumBlocks: 1, TotalCodewords: 57, DataCodewords: 37},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 78,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 37, DataCodewords: 11},
+					{NumBlocks: 2, TotalCodewords: 38, DataCodewords: 12},
+				},
+			},
+		},
+	},
+	22: { // R13x139
+		totalCodewords: 166,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 60,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 2, TotalCodewords: 55, DataCodewords: 35},
+					{NumBlocks: 1, TotalCodewords: 56, DataCodewords: 36},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 112,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 2, TotalCodewords: 41, DataCodewords: 13},
+					{NumBlocks: 2, TotalCodewords: 42, DataCodewords: 14},
+				},
+			},
+		},
+	},
+	23: { // R15x43
+		totalCodewords: 51,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 18,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 51, DataCodewords: 33},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 36,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 25, DataCodewords: 7},
+					{NumBlocks: 1, TotalCodewords: 26, DataCodewords: 8},
+				},
+			},
+		},
+	},
+	24: { // R15x59
+		totalCodewords: 74,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 26,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 74, DataCodewords: 48},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 48,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 2, TotalCodewords: 37, DataCodewords: 13},
+				},
+			},
+		},
+	},
+	25: { // R15x77
+		totalCodewords: 103,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 36,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 51, DataCodewords: 33},
+					{NumBlocks: 1, TotalCodewords: 52, DataCodewords: 34},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 72,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 2, TotalCodewords: 34, DataCodewords: 10},
+					{NumBlocks: 1, TotalCodewords: 35, DataCodewords: 11},
+				},
+			},
+		},
+	},
+	26: { // R15x99
+		totalCodewords: 136,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 48,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 2, TotalCodewords: 68, DataCodewords: 44},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 88,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 4, TotalCodewords: 34, DataCodewords: 12},
+				},
+			},
+		},
+	},
+	27: { // R15x139
+		totalCodewords: 199,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 72,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 2, TotalCodewords: 66, DataCodewords: 42},
+					{NumBlocks: 1, TotalCodewords: 67, DataCodewords: 43},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 130,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 39, DataCodewords: 13},
+					{NumBlocks: 4, TotalCodewords: 40, DataCodewords: 14},
+				},
+			},
+		},
+	},
+	28: { // R17x43
+		totalCodewords: 61,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 22,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 61, DataCodewords: 39},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 40,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 30, DataCodewords: 10},
+					{NumBlocks: 1, TotalCodewords: 31, DataCodewords: 11},
+				},
+			},
+		},
+	},
+	29: { // R17x59
+		totalCodewords: 88,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 32,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 2, TotalCodewords: 44, DataCodewords: 28},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 60,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 2, TotalCodewords: 44, DataCodewords: 14},
+				},
+			},
+		},
+	},
+	30: { // R17x77
+		totalCodewords: 122,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 44,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 2, TotalCodewords: 61, DataCodewords: 39},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 84,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 1, TotalCodewords: 40, DataCodewords: 12},
+					{NumBlocks: 2, TotalCodewords: 41, DataCodewords: 13},
+				},
+			},
+		},
+	},
+	31: { // R17x99
+		totalCodewords: 160,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 60,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 2, TotalCodewords: 53, DataCodewords: 33},
+					{NumBlocks: 1, TotalCodewords: 54, DataCodewords: 34},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 104,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 4, TotalCodewords: 40, DataCodewords: 14},
+				},
+			},
+		},
+	},
+	32: { // R17x139
+		totalCodewords: 232,
+		ecInfo: map[errcorr]ECInfo{
+			ERR_CORR_M: {
+				TotalECCodewords: 80,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 4, TotalCodewords: 58, DataCodewords: 38},
+				},
+			},
+			ERR_CORR_H: {
+				TotalECCodewords: 156,
+				BlockGroups: []BlockGroup{
+					{NumBlocks: 2, TotalCodewords: 38, DataCodewords: 12},
+					{NumBlocks: 4, TotalCodewords: 39, DataCodewords: 13},
 				},
 			},
 		},
 	},
 }
 
-// FIXME: Table values are wrong from level 16 and above
 var capacityData = map[int]struct {
 	totalCodewords int
 	ecInfo         map[errcorr]ECInfo
@@ -230,7 +801,7 @@ var capacityData = map[int]struct {
 			ERR_CORR_Q: {
 				TotalECCodewords: 72,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 2, TotalCodewords: 35, DataCodewords: 15},
+					{NumBlocks: 2, TotalCodewords: 33, DataCodewords: 15},
 					{NumBlocks: 2, TotalCodewords: 34, DataCodewords: 16},
 				},
 			},
@@ -348,6 +919,7 @@ var capacityData = map[int]struct {
 				TotalECCodewords: 110,
 				BlockGroups: []BlockGroup{
 					{NumBlocks: 3, TotalCodewords: 58, DataCodewords: 36},
+					{NumBlocks: 2, TotalCodewords: 59, DataCodewords: 37},
 				},
 			},
 			ERR_CORR_Q: {
@@ -599,31 +1171,31 @@ var capacityData = map[int]struct {
 		totalCodewords: 815,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 168,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 5, TotalCodewords: 136, DataCodewords: 108},
 					{NumBlocks: 1, TotalCodewords: 135, DataCodewords: 107},
+					{NumBlocks: 5, TotalCodewords: 136, DataCodewords: 108},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 308,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 5, TotalCodewords: 152, DataCodewords: 122},
-					{NumBlocks: 1, TotalCodewords: 55, DataCodewords: 25},
+					{NumBlocks: 10, TotalCodewords: 74, DataCodewords: 46},
+					{NumBlocks: 1, TotalCodewords: 75, DataCodewords: 47},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 448,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 5, TotalCodewords: 145, DataCodewords: 115},
-					{NumBlocks: 2, TotalCodewords: 45, DataCodewords: 15},
+					{NumBlocks: 1, TotalCodewords: 50, DataCodewords: 22},
+					{NumBlocks: 15, TotalCodewords: 51, DataCodewords: 23},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 532,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 1, TotalCodewords: 75, DataCodewords: 47},
-					{NumBlocks: 10, TotalCodewords: 74, DataCodewords: 46},
+					{NumBlocks: 2, TotalCodewords: 42, DataCodewords: 14},
+					{NumBlocks: 17, TotalCodewords: 43, DataCodewords: 15},
 				},
 			},
 		},
@@ -632,31 +1204,31 @@ var capacityData = map[int]struct {
 		totalCodewords: 901,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 180,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 1, TotalCodewords: 151, DataCodewords: 121},
 					{NumBlocks: 5, TotalCodewords: 150, DataCodewords: 120},
+					{NumBlocks: 1, TotalCodewords: 151, DataCodewords: 121},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 26,
+				TotalECCodewords: 338,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 4, TotalCodewords: 70, DataCodewords: 44},
 					{NumBlocks: 9, TotalCodewords: 69, DataCodewords: 43},
+					{NumBlocks: 4, TotalCodewords: 70, DataCodewords: 44},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 24,
+				TotalECCodewords: 504,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 4, TotalCodewords: 64, DataCodewords: 40},
-					{NumBlocks: 15, TotalCodewords: 43, DataCodewords: 19},
+					{NumBlocks: 17, TotalCodewords: 50, DataCodewords: 22},
+					{NumBlocks: 1, TotalCodewords: 51, DataCodewords: 23},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 588,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 1, TotalCodewords: 51, DataCodewords: 23},
-					{NumBlocks: 17, TotalCodewords: 50, DataCodewords: 22},
+					{NumBlocks: 2, TotalCodewords: 42, DataCodewords: 14},
+					{NumBlocks: 19, TotalCodewords: 43, DataCodewords: 15},
 				},
 			},
 		},
@@ -665,31 +1237,31 @@ var capacityData = map[int]struct {
 		totalCodewords: 991,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 196,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 4, TotalCodewords: 142, DataCodewords: 114},
 					{NumBlocks: 3, TotalCodewords: 141, DataCodewords: 113},
+					{NumBlocks: 4, TotalCodewords: 142, DataCodewords: 114},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 26,
+				TotalECCodewords: 364,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 11, TotalCodewords: 71, DataCodewords: 45},
 					{NumBlocks: 3, TotalCodewords: 70, DataCodewords: 44},
+					{NumBlocks: 11, TotalCodewords: 71, DataCodewords: 45},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 546,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 3, TotalCodewords: 147, DataCodewords: 117},
-					{NumBlocks: 10, TotalCodewords: 55, DataCodewords: 25},
+					{NumBlocks: 17, TotalCodewords: 47, DataCodewords: 21},
+					{NumBlocks: 4, TotalCodewords: 48, DataCodewords: 22},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 26,
+				TotalECCodewords: 650,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 4, TotalCodewords: 48, DataCodewords: 22},
-					{NumBlocks: 17, TotalCodewords: 47, DataCodewords: 21},
+					{NumBlocks: 9, TotalCodewords: 39, DataCodewords: 13},
+					{NumBlocks: 16, TotalCodewords: 40, DataCodewords: 14},
 				},
 			},
 		},
@@ -698,31 +1270,31 @@ var capacityData = map[int]struct {
 		totalCodewords: 1085,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 224,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 5, TotalCodewords: 136, DataCodewords: 108},
 					{NumBlocks: 3, TotalCodewords: 135, DataCodewords: 107},
+					{NumBlocks: 5, TotalCodewords: 136, DataCodewords: 108},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 26,
+				TotalECCodewords: 416,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 13, TotalCodewords: 68, DataCodewords: 42},
 					{NumBlocks: 3, TotalCodewords: 67, DataCodewords: 41},
+					{NumBlocks: 13, TotalCodewords: 68, DataCodewords: 42},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 600,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 3, TotalCodewords: 147, DataCodewords: 117},
-					{NumBlocks: 14, TotalCodewords: 46, DataCodewords: 16},
+					{NumBlocks: 15, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 5, TotalCodewords: 55, DataCodewords: 25},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 700,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 5, TotalCodewords: 55, DataCodewords: 25},
-					{NumBlocks: 15, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 15, TotalCodewords: 43, DataCodewords: 15},
+					{NumBlocks: 10, TotalCodewords: 44, DataCodewords: 16},
 				},
 			},
 		},
@@ -731,30 +1303,30 @@ var capacityData = map[int]struct {
 		totalCodewords: 1156,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 224,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 4, TotalCodewords: 145, DataCodewords: 117},
 					{NumBlocks: 4, TotalCodewords: 144, DataCodewords: 116},
+					{NumBlocks: 4, TotalCodewords: 145, DataCodewords: 117},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 26,
+				TotalECCodewords: 442,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 8, TotalCodewords: 132, DataCodewords: 106},
-					{NumBlocks: 2, TotalCodewords: 50, DataCodewords: 24},
+					{NumBlocks: 17, TotalCodewords: 68, DataCodewords: 42},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 26,
+				TotalECCodewords: 644,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 17, TotalCodewords: 68, DataCodewords: 42},
+					{NumBlocks: 17, TotalCodewords: 50, DataCodewords: 22},
+					{NumBlocks: 6, TotalCodewords: 51, DataCodewords: 23},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 750,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 7, TotalCodewords: 73, DataCodewords: 45},
-					{NumBlocks: 15, TotalCodewords: 43, DataCodewords: 15},
+					{NumBlocks: 19, TotalCodewords: 46, DataCodewords: 16},
+					{NumBlocks: 6, TotalCodewords: 47, DataCodewords: 17},
 				},
 			},
 		},
@@ -763,27 +1335,27 @@ var capacityData = map[int]struct {
 		totalCodewords: 1258,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 252,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 7, TotalCodewords: 140, DataCodewords: 112},
 					{NumBlocks: 2, TotalCodewords: 139, DataCodewords: 111},
+					{NumBlocks: 7, TotalCodewords: 140, DataCodewords: 112},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 476,
 				BlockGroups: []BlockGroup{
 					{NumBlocks: 17, TotalCodewords: 74, DataCodewords: 46},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 690,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 16, TotalCodewords: 55, DataCodewords: 25},
 					{NumBlocks: 7, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 16, TotalCodewords: 55, DataCodewords: 25},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 24,
+				TotalECCodewords: 816,
 				BlockGroups: []BlockGroup{
 					{NumBlocks: 34, TotalCodewords: 37, DataCodewords: 13},
 				},
@@ -794,31 +1366,31 @@ var capacityData = map[int]struct {
 		totalCodewords: 1364,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 270,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 5, TotalCodewords: 152, DataCodewords: 122},
 					{NumBlocks: 4, TotalCodewords: 151, DataCodewords: 121},
+					{NumBlocks: 5, TotalCodewords: 152, DataCodewords: 122},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 504,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 14, TotalCodewords: 76, DataCodewords: 48},
 					{NumBlocks: 4, TotalCodewords: 75, DataCodewords: 47},
+					{NumBlocks: 14, TotalCodewords: 76, DataCodewords: 48},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 750,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 14, TotalCodewords: 55, DataCodewords: 25},
 					{NumBlocks: 11, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 14, TotalCodewords: 55, DataCodewords: 25},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 900,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 14, TotalCodewords: 46, DataCodewords: 16},
 					{NumBlocks: 16, TotalCodewords: 45, DataCodewords: 15},
+					{NumBlocks: 14, TotalCodewords: 46, DataCodewords: 16},
 				},
 			},
 		},
@@ -827,31 +1399,31 @@ var capacityData = map[int]struct {
 		totalCodewords: 1474,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 300,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 4, TotalCodewords: 148, DataCodewords: 118},
 					{NumBlocks: 6, TotalCodewords: 147, DataCodewords: 117},
+					{NumBlocks: 4, TotalCodewords: 148, DataCodewords: 118},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 560,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 14, TotalCodewords: 74, DataCodewords: 46},
 					{NumBlocks: 6, TotalCodewords: 73, DataCodewords: 45},
+					{NumBlocks: 14, TotalCodewords: 74, DataCodewords: 46},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 810,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 6, TotalCodewords: 146, DataCodewords: 116},
-					{NumBlocks: 13, TotalCodewords: 46, DataCodewords: 16},
+					{NumBlocks: 11, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 16, TotalCodewords: 55, DataCodewords: 25},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 960,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 16, TotalCodewords: 55, DataCodewords: 25},
-					{NumBlocks: 11, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 30, TotalCodewords: 46, DataCodewords: 16},
+					{NumBlocks: 2, TotalCodewords: 47, DataCodewords: 17},
 				},
 			},
 		},
@@ -860,31 +1432,31 @@ var capacityData = map[int]struct {
 		totalCodewords: 1588,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 26,
+				TotalECCodewords: 312,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 4, TotalCodewords: 133, DataCodewords: 107},
 					{NumBlocks: 8, TotalCodewords: 132, DataCodewords: 106},
+					{NumBlocks: 4, TotalCodewords: 133, DataCodewords: 107},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 588,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 13, TotalCodewords: 76, DataCodewords: 48},
 					{NumBlocks: 8, TotalCodewords: 75, DataCodewords: 47},
+					{NumBlocks: 13, TotalCodewords: 76, DataCodewords: 48},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 870,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 3, TotalCodewords: 146, DataCodewords: 116},
-					{NumBlocks: 25, TotalCodewords: 46, DataCodewords: 16},
+					{NumBlocks: 7, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 22, TotalCodewords: 55, DataCodewords: 25},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 1050,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 22, TotalCodewords: 55, DataCodewords: 25},
-					{NumBlocks: 7, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 22, TotalCodewords: 45, DataCodewords: 15},
+					{NumBlocks: 13, TotalCodewords: 46, DataCodewords: 16},
 				},
 			},
 		},
@@ -893,31 +1465,31 @@ var capacityData = map[int]struct {
 		totalCodewords: 1706,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 336,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 2, TotalCodewords: 143, DataCodewords: 115},
 					{NumBlocks: 10, TotalCodewords: 142, DataCodewords: 114},
+					{NumBlocks: 2, TotalCodewords: 143, DataCodewords: 115},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 644,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 4, TotalCodewords: 75, DataCodewords: 47},
 					{NumBlocks: 19, TotalCodewords: 74, DataCodewords: 46},
+					{NumBlocks: 4, TotalCodewords: 75, DataCodewords: 47},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 952,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 6, TotalCodewords: 51, DataCodewords: 23},
 					{NumBlocks: 28, TotalCodewords: 50, DataCodewords: 22},
+					{NumBlocks: 6, TotalCodewords: 51, DataCodewords: 23},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 1110,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 4, TotalCodewords: 47, DataCodewords: 17},
 					{NumBlocks: 33, TotalCodewords: 46, DataCodewords: 16},
+					{NumBlocks: 4, TotalCodewords: 47, DataCodewords: 17},
 				},
 			},
 		},
@@ -926,30 +1498,30 @@ var capacityData = map[int]struct {
 		totalCodewords: 1828,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 360,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 4, TotalCodewords: 153, DataCodewords: 123},
 					{NumBlocks: 8, TotalCodewords: 152, DataCodewords: 122},
+					{NumBlocks: 4, TotalCodewords: 153, DataCodewords: 123},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 700,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 3, TotalCodewords: 74, DataCodewords: 46},
 					{NumBlocks: 22, TotalCodewords: 73, DataCodewords: 45},
+					{NumBlocks: 3, TotalCodewords: 74, DataCodewords: 46},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 1020,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 26, TotalCodewords: 54, DataCodewords: 24},
 					{NumBlocks: 8, TotalCodewords: 53, DataCodewords: 23},
+					{NumBlocks: 26, TotalCodewords: 54, DataCodewords: 24},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 1200,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 10, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 12, TotalCodewords: 45, DataCodewords: 15},
 					{NumBlocks: 28, TotalCodewords: 46, DataCodewords: 16},
 				},
 			},
@@ -959,31 +1531,31 @@ var capacityData = map[int]struct {
 		totalCodewords: 1921,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 390,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 10, TotalCodewords: 148, DataCodewords: 118},
 					{NumBlocks: 3, TotalCodewords: 147, DataCodewords: 117},
+					{NumBlocks: 10, TotalCodewords: 148, DataCodewords: 118},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 728,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 23, TotalCodewords: 74, DataCodewords: 46},
 					{NumBlocks: 3, TotalCodewords: 73, DataCodewords: 45},
+					{NumBlocks: 23, TotalCodewords: 74, DataCodewords: 46},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 1050,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 6, TotalCodewords: 146, DataCodewords: 116},
-					{NumBlocks: 19, TotalCodewords: 55, DataCodewords: 25},
+					{NumBlocks: 4, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 31, TotalCodewords: 55, DataCodewords: 25},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 1260,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 5, TotalCodewords: 145, DataCodewords: 115},
-					{NumBlocks: 26, TotalCodewords: 46, DataCodewords: 16},
+					{NumBlocks: 11, TotalCodewords: 45, DataCodewords: 15},
+					{NumBlocks: 31, TotalCodewords: 46, DataCodewords: 16},
 				},
 			},
 		},
@@ -992,31 +1564,31 @@ var capacityData = map[int]struct {
 		totalCodewords: 2051,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 420,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 7, TotalCodewords: 147, DataCodewords: 117},
 					{NumBlocks: 7, TotalCodewords: 146, DataCodewords: 116},
+					{NumBlocks: 7, TotalCodewords: 147, DataCodewords: 117},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 784,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 26, TotalCodewords: 76, DataCodewords: 48},
-					{NumBlocks: 1, TotalCodewords: 75, DataCodewords: 47},
+					{NumBlocks: 21, TotalCodewords: 73, DataCodewords: 45},
+					{NumBlocks: 7, TotalCodewords: 74, DataCodewords: 46},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 1140,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 7, TotalCodewords: 74, DataCodewords: 46},
-					{NumBlocks: 21, TotalCodewords: 73, DataCodewords: 45},
+					{NumBlocks: 1, TotalCodewords: 53, DataCodewords: 23},
+					{NumBlocks: 37, TotalCodewords: 54, DataCodewords: 24},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 1350,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 19, TotalCodewords: 74, DataCodewords: 46},
-					{NumBlocks: 15, TotalCodewords: 43, DataCodewords: 15},
+					{NumBlocks: 19, TotalCodewords: 45, DataCodewords: 15},
+					{NumBlocks: 26, TotalCodewords: 46, DataCodewords: 16},
 				},
 			},
 		},
@@ -1025,31 +1597,31 @@ var capacityData = map[int]struct {
 		totalCodewords: 2185,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 450,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 10, TotalCodewords: 146, DataCodewords: 116},
 					{NumBlocks: 5, TotalCodewords: 145, DataCodewords: 115},
+					{NumBlocks: 10, TotalCodewords: 146, DataCodewords: 116},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 812,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 10, TotalCodewords: 142, DataCodewords: 114},
-					{NumBlocks: 15, TotalCodewords: 51, DataCodewords: 23},
+					{NumBlocks: 19, TotalCodewords: 75, DataCodewords: 47},
+					{NumBlocks: 10, TotalCodewords: 76, DataCodewords: 48},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 1200,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 10, TotalCodewords: 76, DataCodewords: 48},
-					{NumBlocks: 19, TotalCodewords: 75, DataCodewords: 47},
+					{NumBlocks: 15, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 25, TotalCodewords: 55, DataCodewords: 25},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 1440,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 25, TotalCodewords: 55, DataCodewords: 25},
-					{NumBlocks: 15, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 23, TotalCodewords: 45, DataCodewords: 15},
+					{NumBlocks: 25, TotalCodewords: 46, DataCodewords: 16},
 				},
 			},
 		},
@@ -1058,31 +1630,31 @@ var capacityData = map[int]struct {
 		totalCodewords: 2323,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 480,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 3, TotalCodewords: 146, DataCodewords: 116},
 					{NumBlocks: 13, TotalCodewords: 145, DataCodewords: 115},
+					{NumBlocks: 3, TotalCodewords: 146, DataCodewords: 116},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 868,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 29, TotalCodewords: 75, DataCodewords: 47},
 					{NumBlocks: 2, TotalCodewords: 74, DataCodewords: 46},
+					{NumBlocks: 29, TotalCodewords: 75, DataCodewords: 47},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 1290,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 1, TotalCodewords: 55, DataCodewords: 25},
 					{NumBlocks: 42, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 1, TotalCodewords: 55, DataCodewords: 25},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 1530,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 28, TotalCodewords: 46, DataCodewords: 16},
 					{NumBlocks: 23, TotalCodewords: 45, DataCodewords: 15},
+					{NumBlocks: 28, TotalCodewords: 46, DataCodewords: 16},
 				},
 			},
 		},
@@ -1091,30 +1663,30 @@ var capacityData = map[int]struct {
 		totalCodewords: 2465,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 510,
 				BlockGroups: []BlockGroup{
 					{NumBlocks: 17, TotalCodewords: 145, DataCodewords: 115},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 924,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 23, TotalCodewords: 75, DataCodewords: 47},
 					{NumBlocks: 10, TotalCodewords: 74, DataCodewords: 46},
+					{NumBlocks: 23, TotalCodewords: 75, DataCodewords: 47},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 1350,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 5, TotalCodewords: 152, DataCodewords: 122},
-					{NumBlocks: 31, TotalCodewords: 55, DataCodewords: 25},
+					{NumBlocks: 10, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 35, TotalCodewords: 55, DataCodewords: 25},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 1620,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 35, TotalCodewords: 55, DataCodewords: 25},
-					{NumBlocks: 10, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 19, TotalCodewords: 45, DataCodewords: 15},
+					{NumBlocks: 35, TotalCodewords: 46, DataCodewords: 16},
 				},
 			},
 		},
@@ -1123,31 +1695,31 @@ var capacityData = map[int]struct {
 		totalCodewords: 2611,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 540,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 1, TotalCodewords: 146, DataCodewords: 116},
 					{NumBlocks: 17, TotalCodewords: 145, DataCodewords: 115},
+					{NumBlocks: 1, TotalCodewords: 146, DataCodewords: 116},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 980,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 21, TotalCodewords: 75, DataCodewords: 47},
 					{NumBlocks: 14, TotalCodewords: 74, DataCodewords: 46},
+					{NumBlocks: 21, TotalCodewords: 75, DataCodewords: 47},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 1440,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 6, TotalCodewords: 151, DataCodewords: 121},
-					{NumBlocks: 31, TotalCodewords: 55, DataCodewords: 25},
+					{NumBlocks: 29, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 19, TotalCodewords: 55, DataCodewords: 25},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 1710,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 5, TotalCodewords: 145, DataCodewords: 115},
-					{NumBlocks: 41, TotalCodewords: 46, DataCodewords: 16},
+					{NumBlocks: 11, TotalCodewords: 45, DataCodewords: 15},
+					{NumBlocks: 46, TotalCodewords: 46, DataCodewords: 16},
 				},
 			},
 		},
@@ -1156,31 +1728,31 @@ var capacityData = map[int]struct {
 		totalCodewords: 2761,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 570,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 6, TotalCodewords: 146, DataCodewords: 116},
 					{NumBlocks: 13, TotalCodewords: 145, DataCodewords: 115},
+					{NumBlocks: 6, TotalCodewords: 146, DataCodewords: 116},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 1036,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 3, TotalCodewords: 135, DataCodewords: 107},
-					{NumBlocks: 31, TotalCodewords: 76, DataCodewords: 48},
+					{NumBlocks: 14, TotalCodewords: 74, DataCodewords: 46},
+					{NumBlocks: 23, TotalCodewords: 75, DataCodewords: 47},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 1530,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 23, TotalCodewords: 75, DataCodewords: 47},
-					{NumBlocks: 14, TotalCodewords: 74, DataCodewords: 46},
+					{NumBlocks: 44, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 7, TotalCodewords: 55, DataCodewords: 25},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 1800,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 7, TotalCodewords: 55, DataCodewords: 25},
-					{NumBlocks: 44, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 59, TotalCodewords: 46, DataCodewords: 16},
+					{NumBlocks: 1, TotalCodewords: 47, DataCodewords: 17},
 				},
 			},
 		},
@@ -1189,31 +1761,31 @@ var capacityData = map[int]struct {
 		totalCodewords: 2876,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 570,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 17, TotalCodewords: 152, DataCodewords: 122},
-					{NumBlocks: 2, TotalCodewords: 146, DataCodewords: 116},
+					{NumBlocks: 12, TotalCodewords: 151, DataCodewords: 121},
+					{NumBlocks: 7, TotalCodewords: 152, DataCodewords: 122},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 1064,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 26, TotalCodewords: 76, DataCodewords: 48},
 					{NumBlocks: 12, TotalCodewords: 75, DataCodewords: 47},
+					{NumBlocks: 26, TotalCodewords: 76, DataCodewords: 48},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 1590,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 4, TotalCodewords: 152, DataCodewords: 122},
-					{NumBlocks: 42, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 39, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 14, TotalCodewords: 55, DataCodewords: 25},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 1890,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 5, TotalCodewords: 152, DataCodewords: 122},
-					{NumBlocks: 46, TotalCodewords: 46, DataCodewords: 16},
+					{NumBlocks: 22, TotalCodewords: 45, DataCodewords: 15},
+					{NumBlocks: 41, TotalCodewords: 46, DataCodewords: 16},
 				},
 			},
 		},
@@ -1222,31 +1794,31 @@ var capacityData = map[int]struct {
 		totalCodewords: 3034,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 600,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 14, TotalCodewords: 152, DataCodewords: 122},
 					{NumBlocks: 6, TotalCodewords: 151, DataCodewords: 121},
+					{NumBlocks: 14, TotalCodewords: 152, DataCodewords: 122},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 1120,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 20, TotalCodewords: 147, DataCodewords: 117},
-					{NumBlocks: 2, TotalCodewords: 47, DataCodewords: 17},
+					{NumBlocks: 6, TotalCodewords: 75, DataCodewords: 47},
+					{NumBlocks: 34, TotalCodewords: 76, DataCodewords: 48},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 1680,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 17, TotalCodewords: 152, DataCodewords: 122},
-					{NumBlocks: 10, TotalCodewords: 45, DataCodewords: 15},
+					{NumBlocks: 46, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 10, TotalCodewords: 55, DataCodewords: 25},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 1980,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 34, TotalCodewords: 76, DataCodewords: 48},
-					{NumBlocks: 6, TotalCodewords: 75, DataCodewords: 47},
+					{NumBlocks: 2, TotalCodewords: 45, DataCodewords: 15},
+					{NumBlocks: 64, TotalCodewords: 46, DataCodewords: 16},
 				},
 			},
 		},
@@ -1255,31 +1827,31 @@ var capacityData = map[int]struct {
 		totalCodewords: 3196,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 630,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 4, TotalCodewords: 153, DataCodewords: 123},
 					{NumBlocks: 17, TotalCodewords: 152, DataCodewords: 122},
+					{NumBlocks: 4, TotalCodewords: 153, DataCodewords: 123},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 1204,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 14, TotalCodewords: 75, DataCodewords: 47},
 					{NumBlocks: 29, TotalCodewords: 74, DataCodewords: 46},
+					{NumBlocks: 14, TotalCodewords: 75, DataCodewords: 47},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 1770,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 4, TotalCodewords: 151, DataCodewords: 121},
-					{NumBlocks: 48, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 49, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 10, TotalCodewords: 55, DataCodewords: 25},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 2100,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 10, TotalCodewords: 55, DataCodewords: 25},
-					{NumBlocks: 49, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 24, TotalCodewords: 45, DataCodewords: 15},
+					{NumBlocks: 46, TotalCodewords: 46, DataCodewords: 16},
 				},
 			},
 		},
@@ -1288,31 +1860,31 @@ var capacityData = map[int]struct {
 		totalCodewords: 3362,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 660,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 18, TotalCodewords: 153, DataCodewords: 123},
 					{NumBlocks: 4, TotalCodewords: 152, DataCodewords: 122},
+					{NumBlocks: 18, TotalCodewords: 153, DataCodewords: 123},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 1260,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 19, TotalCodewords: 148, DataCodewords: 118},
-					{NumBlocks: 10, TotalCodewords: 55, DataCodewords: 25},
+					{NumBlocks: 13, TotalCodewords: 74, DataCodewords: 46},
+					{NumBlocks: 32, TotalCodewords: 75, DataCodewords: 47},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 1860,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 32, TotalCodewords: 75, DataCodewords: 47},
-					{NumBlocks: 13, TotalCodewords: 74, DataCodewords: 46},
+					{NumBlocks: 48, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 14, TotalCodewords: 55, DataCodewords: 25},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 2220,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 14, TotalCodewords: 55, DataCodewords: 25},
-					{NumBlocks: 48, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 42, TotalCodewords: 45, DataCodewords: 15},
+					{NumBlocks: 32, TotalCodewords: 46, DataCodewords: 16},
 				},
 			},
 		},
@@ -1321,31 +1893,31 @@ var capacityData = map[int]struct {
 		totalCodewords: 3532,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 720,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 4, TotalCodewords: 148, DataCodewords: 118},
 					{NumBlocks: 20, TotalCodewords: 147, DataCodewords: 117},
+					{NumBlocks: 4, TotalCodewords: 148, DataCodewords: 118},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 1316,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 19, TotalCodewords: 148, DataCodewords: 118},
-					{NumBlocks: 16, TotalCodewords: 45, DataCodewords: 15},
+					{NumBlocks: 40, TotalCodewords: 75, DataCodewords: 47},
+					{NumBlocks: 7, TotalCodewords: 76, DataCodewords: 48},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 1950,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 14, TotalCodewords: 152, DataCodewords: 122},
-					{NumBlocks: 26, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 43, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 22, TotalCodewords: 55, DataCodewords: 25},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 2310,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 7, TotalCodewords: 76, DataCodewords: 48},
-					{NumBlocks: 40, TotalCodewords: 75, DataCodewords: 47},
+					{NumBlocks: 10, TotalCodewords: 45, DataCodewords: 15},
+					{NumBlocks: 67, TotalCodewords: 46, DataCodewords: 16},
 				},
 			},
 		},
@@ -1354,31 +1926,31 @@ var capacityData = map[int]struct {
 		totalCodewords: 3706,
 		ecInfo: map[errcorr]ECInfo{
 			ERR_CORR_L: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 750,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 6, TotalCodewords: 149, DataCodewords: 119},
 					{NumBlocks: 19, TotalCodewords: 148, DataCodewords: 118},
+					{NumBlocks: 6, TotalCodewords: 149, DataCodewords: 119},
 				},
 			},
 			ERR_CORR_M: {
-				TotalECCodewords: 28,
+				TotalECCodewords: 1372,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 31, TotalCodewords: 76, DataCodewords: 48},
 					{NumBlocks: 18, TotalCodewords: 75, DataCodewords: 47},
+					{NumBlocks: 31, TotalCodewords: 76, DataCodewords: 48},
 				},
 			},
 			ERR_CORR_Q: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 2040,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 34, TotalCodewords: 55, DataCodewords: 25},
 					{NumBlocks: 34, TotalCodewords: 54, DataCodewords: 24},
+					{NumBlocks: 34, TotalCodewords: 55, DataCodewords: 25},
 				},
 			},
 			ERR_CORR_H: {
-				TotalECCodewords: 30,
+				TotalECCodewords: 2430,
 				BlockGroups: []BlockGroup{
-					{NumBlocks: 61, TotalCodewords: 46, DataCodewords: 16},
 					{NumBlocks: 20, TotalCodewords: 45, DataCodewords: 15},
+					{NumBlocks: 61, TotalCodewords: 46, DataCodewords: 16},
 				},
 			},
 		},
@@ -1395,6 +1967,9 @@ func getTotalCodewords(v version.QRVersion) int {
 	case version.FORMAT_QR_MODEL_2:
 		data := capacityData[v.Number]
 		return data.totalCodewords
+	case version.FORMAT_RMQR:
+		data := rmqrCapacityData[v.Number]
+		return data.totalCodewords
 	}
 	return 0
 }
@@ -1410,6 +1985,9 @@ func getTotalECCodewords(v version.QRVersion, ecLevel errcorr) int {
 	case version.FORMAT_QR_MODEL_2:
 		capacityData := capacityData[v.Number]
 		ecInfo = capacityData.ecInfo[ecLevel]
+	case version.FORMAT_RMQR:
+		capacityData := rmqrCapacityData[v.Number]
+		ecInfo = capacityData.ecInfo[ecLevel]
 	}
 
 	return ecInfo.TotalECCodewords
@@ -1418,3 +1996,38 @@ func getTotalECCodewords(v version.QRVersion, ecLevel errcorr) int {
 func getTotalDataCodewords(v version.QRVersion, ecLevel errcorr) int {
 	return getTotalCodewords(v) - getTotalECCodewords(v, ecLevel)
 }
+
+// getECInfo returns the error correction blocks of version v at ecLevel, and
+// whether ecLevel is available for v (e.g. H is not available in Micro QR).
+func getECInfo(v version.QRVersion, ecLevel errcorr) (ECInfo, bool) {
+	var ecInfo ECInfo
+	var ok bool
+	switch v.Format {
+	case version.FORMAT_MICRO_QR:
+		ecInfo, ok = microCapacityData[v.Number].ecInfo[ecLevel]
+	case version.FORMAT_QR, version.FORMAT_QR_MODEL_2:
+		ecInfo, ok = capacityData[v.Number].ecInfo[ecLevel]
+	case version.FORMAT_RMQR:
+		ecInfo, ok = rmqrCapacityData[v.Number].ecInfo[ecLevel]
+	}
+	return ecInfo, ok
+}
+
+// getDataCapacityBits returns the number of data bits of version v at
+// ecLevel, or 0 if ecLevel is not available for v. In Micro QR M1 and M3 the
+// final data codeword is 4 bits long.
+func getDataCapacityBits(v version.QRVersion, ecLevel errcorr) int {
+	if _, ok := getECInfo(v, ecLevel); !ok {
+		return 0
+	}
+	bits := getTotalDataCodewords(v, ecLevel) * 8
+	if hasHalfDataCodeword(v) {
+		bits -= 4
+	}
+	return bits
+}
+
+// hasHalfDataCodeword reports whether the final data codeword of v is 4 bits long.
+func hasHalfDataCodeword(v version.QRVersion) bool {
+	return v.Format == version.FORMAT_MICRO_QR && (v.Number == 1 || v.Number == 3)
+}
@@ -1,7 +1,8 @@
 package main
 
 import (
-	"github.com/harogaston/qr-decoder/bitseq"
+	"slices"
+
 	"github.com/harogaston/qr-decoder/modes"
 	"github.com/harogaston/qr-decoder/version"
 )
@@ -30,6 +31,9 @@ type CharCountDataLength map[charCountVersionClass]int
 // | 1 to 9    | 10           | 9                 | 8         | 8          |
 // | 10 to 26  | 12           | 11                | 16        | 10         |
 // | 27 to 40  | 14           | 13                | 16        | 12         |
+//
+// Hanzi mode (GB/T 18284) has the Kanji mode lengths in QR Code versions 1
+// to 40 and is not available in Micro QR.
 var charCountData = map[modes.QRMode]CharCountDataLength{
 	modes.NumericMode: {
 		M1Version:      3,
@@ -67,36 +71,134 @@ var charCountData = map[modes.QRMode]CharCountDataLength{
 		V10To26Version: 10,
 		V27To40Version: 12,
 	},
+	modes.HanziMode: {
+		V1To9Version:   8,
+		V10To26Version: 10,
+		V27To40Version: 12,
+	},
+}
+
+// rMQR character count indicator lengths, indexed by version number - 1
+//
+// | Version | Numeric mode | Alphanumeric mode | Byte mode | Kanji mode |
+// | :------ | :----------- | :---------------- | :-------- | :--------- |
+// | R7x43   | 4            | 3                 | 3         | 2          |
+// | R7x59   | 5            | 5                 | 4         | 3          |
+// | R7x77   | 6            | 5                 | 5         | 4          |
+// | R7x99   | 7            | 6                 | 5         | 5          |
+// | R7x139  | 7            | 6                 | 6         | 5          |
+// | R9x43   | 5            | 5                 | 4         | 3          |
+// | R9x59   | 6            | 5                 | 5         | 4          |
+// | R9x77   | 7            | 6                 | 5         | 5          |
+// | R9x99   | 7            | 6                 | 6         | 5          |
+// | R9x139  | 8            | 7                 | 6         | 6          |
+// | R11x27  | 4            | 4                 | 3         | 2          |
+// | R11x43  | 6            | 5                 | 5         | 4          |
+// | R11x59  | 7            | 6                 | 5         | 5          |
+// | R11x77  | 7            | 6                 | 6         | 5          |
+// | R11x99  | 8            | 7                 | 6         | 6          |
+// | R11x139 | 8            | 7                 | 7         | 6          |
+// | R13x27  | 5            | 5                 | 4         | 3          |
+// | R13x43  | 6            | 6                 | 5         | 5          |
+// | R13x59  | 7            | 6                 | 6         | 5          |
+// | R13x77  | 7            | 7                 | 6         | 5          |
+// | R13x99  | 8            | 7                 | 7         | 6          |
+// | R13x139 | 8            | 8                 | 7         | 7          |
+// | R15x43  | 7            | 6                 | 6         | 5          |
+// | R15x59  | 7            | 7                 | 6         | 5          |
+// | R15x77  | 8            | 7                 | 7         | 6          |
+// | R15x99  | 8            | 7                 | 7         | 6          |
+// | R15x139 | 9            | 8                 | 7         | 7          |
+// | R17x43  | 7            | 6                 | 6         | 5          |
+// | R17x59  | 8            | 7                 | 6         | 6          |
+// | R17x77  | 8            | 7                 | 7         | 6          |
+// | R17x99  | 8            | 8                 | 7         | 6          |
+// | R17x139 | 9            | 8                 | 8         | 7          |
+var rmqrCharCountData = map[modes.QRMode][]int{
+	modes.NumericMode:      {4, 5, 6, 7, 7, 5, 6, 7, 7, 8, 4, 6, 7, 7, 8, 8, 5, 6, 7, 7, 8, 8, 7, 7, 8, 8, 9, 7, 8, 8, 8, 9},
+	modes.AlphanumericMode: {3, 5, 5, 6, 6, 5, 5, 6, 6, 7, 4, 5, 6, 6, 7, 7, 5, 6, 6, 7, 7, 8, 6, 7, 7, 7, 8, 6, 7, 7, 8, 8},
+	modes.ByteMode:         {3, 4, 5, 5, 6, 4, 5, 5, 6, 6, 3, 5, 5, 6, 6, 7, 4, 5, 6, 6, 7, 7, 6, 6, 7, 7, 7, 6, 6, 7, 7, 8},
+	modes.KanjiMode:        {2, 3, 4, 5, 5, 3, 4, 5, 5, 6, 2, 4, 5, 5, 6, 6, 3, 5, 5, 5, 6, 7, 5, 5, 6, 6, 7, 5, 6, 6, 6, 7},
 }
 
-func GetVersionNumber(mode modes.QRMode, format version.QRFormat, data bitseq.BitSeq, ecLevel errcorr) int {
+// GetVersionNumber returns the smallest version of format whose data capacity
+// fits the segments returned by segmentsFor, called once per version class
+// since character count widths differ between classes. segmentsFor returns
+// nil when the data cannot be encoded in a version. Returns 0 when the data
+// does not fit any version.
+func GetVersionNumber(format version.QRFormat, ecLevel errcorr, segmentsFor func(version.QRVersion) []modes.Segment) int {
 	switch format {
 	case version.FORMAT_QR, version.FORMAT_QR_MODEL_2:
+		var segments []modes.Segment
 		for num := 1; num <= 40; num++ {
 			v := version.QRVersion{Format: format, Number: num}
-			// 1. Mode indicator length
-			modeBits := 4 // For QR Code (except Micro)
-
-			// 2. Char count indicator length
-			charCountBits := GetCharCountLength(v, mode)
+			// 1. Segments for the version class
+			if num == 1 || num == 10 || num == 27 {
+				segments = segmentsFor(v)
+			}
 
-			// 3. Total bits
-			totalBits := modeBits + charCountBits + data.Len()
+			// 2. Total bits: mode indicator, char count indicator and data of each segment
+			totalBits := segmentsBitLength(segments, v)
 
-			// 4. Data capacity
-			dataCapacityBits := (getTotalDataCodewords(v, ecLevel)) * 8
+			// 3. Data capacity
+			dataCapacityBits := getDataCapacityBits(v, ecLevel)
 
-			if totalBits <= dataCapacityBits {
+			if totalBits >= 0 && totalBits <= dataCapacityBits {
 				return num
 			}
 		}
 	case version.FORMAT_MICRO_QR:
-		// TODO: Implement Micro QR capacity check
-		panic("Micro QR not implemented yet")
+		for num := 1; num <= 4; num++ {
+			v := version.QRVersion{Format: format, Number: num}
+			// Every Micro QR version has its own character count widths and
+			// available modes. Versions without ecLevel have no capacity.
+			dataCapacityBits := getDataCapacityBits(v, ecLevel)
+			if dataCapacityBits == 0 {
+				continue
+			}
+
+			segments := segmentsFor(v)
+			if segments == nil {
+				continue
+			}
+			totalBits := segmentsBitLength(segments, v)
+			if totalBits >= 0 && totalBits <= dataCapacityBits {
+				return num
+			}
+		}
+	case version.FORMAT_RMQR:
+		// Every rMQR version has its own character count widths, the
+		// smallest symbol (by area) that fits is selected
+		for _, num := range rmqrVersionsByArea() {
+			v := version.QRVersion{Format: format, Number: num}
+			segments := segmentsFor(v)
+			if segments == nil {
+				continue
+			}
+			totalBits := segmentsBitLength(segments, v)
+			if totalBits >= 0 && totalBits <= getDataCapacityBits(v, ecLevel) {
+				return num
+			}
+		}
 	}
 	return 0
 }
 
+// rmqrVersionsByArea returns the rMQR version numbers from the smallest to
+// the largest symbol area.
+func rmqrVersionsByArea() []int {
+	nums := make([]int, version.RMQRVersionCount)
+	for i := range nums {
+		nums[i] = i + 1
+	}
+	slices.SortStableFunc(nums, func(a, b int) int {
+		wa, ha := version.QRVersion{Format: version.FORMAT_RMQR, Number: a}.Size()
+		wb, hb := version.QRVersion{Format: version.FORMAT_RMQR, Number: b}.Size()
+		return wa*ha - wb*hb
+	})
+	return nums
+}
+
 // GetCharCountLength retrieves the character count for a given QR version and mode.
 // Returns 0 for N/A cases.
 func GetCharCountLength(qrversion version.QRVersion, mode modes.QRMode) int {
@@ -124,6 +226,11 @@ func GetCharCountLength(qrversion version.QRVersion, mode modes.QRMode) int {
 			return charCountData[mode][V27To40Version]
 		}
 	}
+	if qrversion.Format == version.FORMAT_RMQR {
+		if lengths, ok := rmqrCharCountData[mode]; ok && qrversion.Number >= 1 && qrversion.Number <= len(lengths) {
+			return lengths[qrversion.Number-1]
+		}
+	}
 
 	return 0
 }
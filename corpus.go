@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+
+	"github.com/harogaston/qr-decoder/images"
+)
+
+// Distortion is a kind of image degradation of the regression corpus
+type Distortion string
+
+const (
+	// The symbol as rendered
+	DistortionNone Distortion = "none"
+	// Rotated by an angle in degrees
+	DistortionRotation Distortion = "rotation"
+	// Seen at an angle: each corner moves by up to a share of the side
+	DistortionPerspective Distortion = "perspective"
+	// Box blurred over a radius in pixels
+	DistortionBlur Distortion = "blur"
+	// Gaussian noise with a standard deviation in grey levels
+	DistortionNoise Distortion = "noise"
+	// Light minus dark modules, as a share of the grey scale
+	DistortionLowContrast Distortion = "low-contrast"
+	// JPEG compressed with a quality from 1 to 100
+	DistortionJPEG Distortion = "jpeg"
+)
+
+// Distortions lists every distortion, in the order of the corpus files
+var Distortions = []Distortion{DistortionNone, DistortionRotation, DistortionPerspective,
+	DistortionBlur, DistortionNoise, DistortionLowContrast, DistortionJPEG}
+
+// Pixels per module of the corpus renders
+const corpusModuleSize = 6
+
+// CorpusEntry is the ground truth of an image of the corpus
+type CorpusEntry struct {
+	File       string     `json:"file"`
+	Data       string     `json:"data"`
+	Version    int        `json:"version"`
+	ECLevel    string     `json:"ec_level"`
+	Mask       int        `json:"mask"`
+	Distortion Distortion `json:"distortion"`
+	// Angle, share, radius, standard deviation, contrast or quality, see
+	// Distortion. 0 for DistortionNone.
+	Parameter float64 `json:"parameter"`
+	// Top left, top right, bottom right and bottom left corners of the
+	// symbol in the image, quiet zone excluded
+	Corners [4]images.Point `json:"corners"`
+}
+
+// CorpusManifest lists the images of a corpus
+type CorpusManifest struct {
+	Seed       uint64        `json:"seed"`
+	ModuleSize int           `json:"module_size"`
+	Entries    []CorpusEntry `json:"entries"`
+}
+
+// GenerateCorpus renders a symbol of each payload at error correction level
+// ecLevel and writes it to dir with every distortion, as PNG images named
+// after the payload number and the distortion, e.g. 001-blur.png, along with
+// their ground truth in manifest.json. The parameters of the distortions are
+// drawn from a random source seeded with seed and the number of the image,
+// so a corpus generated twice is the same, and adding payloads keeps the
+// images of the others.
+func GenerateCorpus(dir string, payloads []string, ecLevel errcorr, seed uint64) (*CorpusManifest, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	manifest := &CorpusManifest{Seed: seed, ModuleSize: corpusModuleSize}
+	for k, payload := range payloads {
+		req := QRRequest{input_data: payload, err_corr_level: string(ecLevel)}
+		if _, _, err := analyze(req); err != nil {
+			return nil, fmt.Errorf("corpus: payload %d: %w", k+1, err)
+		}
+		symbol := NewQRCode(req)
+		rendered := renderGray(symbol.matrix, corpusModuleSize)
+		m := float64(corpusModuleSize)
+		quiet, side := 4*m, float64(4+symbol.size)*m
+		corners := [4]images.Point{{X: quiet, Y: quiet}, {X: side, Y: quiet}, {X: side, Y: side}, {X: quiet, Y: side}}
+
+		for d, distortion := range Distortions {
+			rng := rand.New(rand.NewPCG(seed, uint64(k*len(Distortions)+d)))
+			img, parameter, transform, err := distort(rendered, distortion, rng)
+			if err != nil {
+				return nil, err
+			}
+			entry := CorpusEntry{
+				File:       fmt.Sprintf("%03d-%s.png", k+1, distortion),
+				Data:       payload,
+				Version:    symbol.version.Number,
+				ECLevel:    string(symbol.error_corr_level),
+				Mask:       symbol.mask,
+				Distortion: distortion,
+				Parameter:  parameter,
+			}
+			for i, c := range corners {
+				entry.Corners[i] = transform.Apply(c)
+			}
+			if err := writeGrayPNG(filepath.Join(dir, entry.File), img); err != nil {
+				return nil, err
+			}
+			manifest.Entries = append(manifest.Entries, entry)
+		}
+	}
+
+	encoded, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "manifest.json"), append(encoded, '\n'), 0o644); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// distort applies a distortion to img with parameters drawn from rng
+// returns the distorted image, the parameter of the distortion and the
+// transform of the points of img onto it
+func distort(img *image.Gray, distortion Distortion, rng *rand.Rand) (*image.Gray, float64, images.PerspectiveTransform, error) {
+	w, h := float64(img.Bounds().Dx()), float64(img.Bounds().Dy())
+	bounds := [4]images.Point{{X: 0, Y: 0}, {X: w, Y: 0}, {X: w, Y: h}, {X: 0, Y: h}}
+	identity := images.QuadrilateralToQuadrilateral(bounds, bounds)
+
+	switch distortion {
+	case DistortionRotation:
+		angle := math.Round(rng.Float64()*3600) / 10
+		sin, cos := math.Sincos(angle * math.Pi / 180)
+		side := math.Ceil(math.Hypot(w, h))
+		var dst [4]images.Point
+		for i, p := range bounds {
+			dx, dy := p.X-w/2, p.Y-h/2
+			dst[i] = images.Point{X: side/2 + cos*dx - sin*dy, Y: side/2 + sin*dx + cos*dy}
+		}
+		return warp(img, dst, int(side)), angle, images.QuadrilateralToQuadrilateral(bounds, dst), nil
+	case DistortionPerspective:
+		share := math.Round(5+rng.Float64()*20) / 100
+		// Corners move inwards, within a margin around the image
+		margin := share * w
+		var dst [4]images.Point
+		for i, p := range bounds {
+			sx, sy := 1.0, 1.0
+			if p.X > 0 {
+				sx = -1
+			}
+			if p.Y > 0 {
+				sy = -1
+			}
+			dst[i] = images.Point{X: margin + p.X + sx*rng.Float64()*share*w, Y: margin + p.Y + sy*rng.Float64()*share*h}
+		}
+		return warp(img, dst, int(math.Ceil(w+2*margin))), share, images.QuadrilateralToQuadrilateral(bounds, dst), nil
+	case DistortionBlur:
+		radius := 1 + rng.IntN(corpusModuleSize/2)
+		return boxBlur(img, radius), float64(radius), identity, nil
+	case DistortionNoise:
+		sigma := float64(8 + rng.IntN(25))
+		noisy := image.NewGray(img.Bounds())
+		copy(noisy.Pix, img.Pix)
+		addNoise(noisy, sigma, rng)
+		return noisy, sigma, identity, nil
+	case DistortionLowContrast:
+		contrast := math.Round(20+rng.Float64()*20) / 100
+		span := contrast * 255
+		dark := 30 + rng.Float64()*(225-span)
+		faded := image.NewGray(img.Bounds())
+		for i, v := range img.Pix {
+			faded.Pix[i] = uint8(math.Round(dark + float64(v)/255*span))
+		}
+		return faded, contrast, identity, nil
+	case DistortionJPEG:
+		quality := 10 + rng.IntN(51)
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, 0, identity, err
+		}
+		decoded, err := jpeg.Decode(&buf)
+		if err != nil {
+			return nil, 0, identity, err
+		}
+		compressed, err := images.Grayscale(decoded, images.ChannelLuminance)
+		return compressed, float64(quality), identity, err
+	}
+	return img, 0, identity, nil
+}
+
+// warp maps the corners of img onto dst in a white side x side image, with
+// bilinear interpolation
+func warp(img *image.Gray, dst [4]images.Point, side int) *image.Gray {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	bounds := [4]images.Point{{X: 0, Y: 0}, {X: float64(w), Y: 0}, {X: float64(w), Y: float64(h)}, {X: 0, Y: float64(h)}}
+	back := images.QuadrilateralToQuadrilateral(dst, bounds)
+	level := func(x, y int) float64 {
+		if x < 0 || y < 0 || x >= w || y >= h {
+			return 255
+		}
+		return float64(img.GrayAt(x, y).Y)
+	}
+
+	warped := image.NewGray(image.Rect(0, 0, side, side))
+	for y := range side {
+		for x := range side {
+			p := back.Apply(images.Point{X: float64(x) + 0.5, Y: float64(y) + 0.5})
+			// Between the centers of 4 pixels
+			fx, fy := p.X-0.5, p.Y-0.5
+			x0, y0 := int(math.Floor(fx)), int(math.Floor(fy))
+			tx, ty := fx-float64(x0), fy-float64(y0)
+			v := (level(x0, y0)*(1-tx)+level(x0+1, y0)*tx)*(1-ty) +
+				(level(x0, y0+1)*(1-tx)+level(x0+1, y0+1)*tx)*ty
+			warped.SetGray(x, y, color.Gray{Y: uint8(math.Round(v))})
+		}
+	}
+	return warped
+}
+
+// writeGrayPNG writes img to a PNG file
+func writeGrayPNG(path string, img *image.Gray) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return png.Encode(file, img)
+}
+
+// renderGray draws matrix with moduleSize pixels per module and a quiet zone
+// of 4 modules, black on white
+func renderGray(matrix [][]module, moduleSize int) *image.Gray {
+	side := (len(matrix) + 8) * moduleSize
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for y := range side {
+		for x := range side {
+			i, j := y/moduleSize-4, x/moduleSize-4
+			dark := i >= 0 && j >= 0 && i < len(matrix) && j < len(matrix) && matrix[i][j].bit == One
+			if !dark {
+				img.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+	return img
+}
@@ -0,0 +1,565 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/harogaston/qr-decoder/bitseq"
+	"github.com/harogaston/qr-decoder/images"
+	"github.com/harogaston/qr-decoder/modes"
+	"github.com/harogaston/qr-decoder/version"
+)
+
+// Modules sampled with a lower confidence make their codeword an erasure
+const minModuleConfidence = 0.75
+
+// DecodeImage binarizes img, then decodes it with DecodeBitImage. The zero
+// Binarizer suits most images.
+func DecodeImage(img image.Image, binarizer images.Binarizer) (*qr, error) {
+	b, err := binarizer.Binarize(img)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeBitImage(b)
+}
+
+// DecodeBitImage locates a QR Code symbol in b by its finder patterns, samples
+// its modules and decodes them. If that fails, b is read again inverted for
+// light modules on a dark background, which the qr reports in reversed.
+func DecodeBitImage(b *images.BitImage) (*qr, error) {
+	qr, err := locateAndDecode(b)
+	if err == nil {
+		return qr, nil
+	}
+	if qr, invertedErr := locateAndDecode(b.Inverted()); invertedErr == nil {
+		qr.reversed = true
+		return qr, nil
+	}
+	return nil, err
+}
+
+func locateAndDecode(b *images.BitImage) (*qr, error) {
+	symbol, err := images.Locate(b)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSymbol(b, symbol)
+}
+
+// ImageSymbol is a symbol found in an image by DecodeAllBitImage
+type ImageSymbol struct {
+	// Corners of the symbol in the image, clockwise from the upper left one
+	Corners [4]images.Point
+	QR      *qr
+	// Why the symbol could not be decoded, QR is nil then
+	Err error
+}
+
+// DecodeAllImage binarizes img, then decodes it with DecodeAllBitImage
+func DecodeAllImage(img image.Image, binarizer images.Binarizer) ([]ImageSymbol, error) {
+	b, err := binarizer.Binarize(img)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeAllBitImage(b), nil
+}
+
+// DecodeAllBitImage locates every QR Code symbol in b and decodes each of
+// them independently, in the order of images.LocateAll. Symbols that fail to
+// decode are returned with their error. The symbols with light modules on a
+// dark background, found in b inverted, follow.
+func DecodeAllBitImage(b *images.BitImage) []ImageSymbol {
+	var symbols []ImageSymbol
+	for _, inverted := range []bool{false, true} {
+		scanned := b
+		if inverted {
+			scanned = b.Inverted()
+		}
+		for _, symbol := range images.LocateAll(scanned) {
+			qr, err := decodeSymbol(scanned, symbol)
+			if err == nil {
+				qr.reversed = inverted
+			}
+			// The dimension may have been corrected by the version information
+			symbols = append(symbols, ImageSymbol{Corners: symbol.Corners(), QR: qr, Err: err})
+		}
+	}
+	return symbols
+}
+
+// decodeSymbol samples the modules of a symbol located in b and decodes them
+func decodeSymbol(b *images.BitImage, symbol *images.Symbol) (*qr, error) {
+	grid, confidence, err := sampleSymbol(b, symbol)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeSoftGrid(grid, confidence)
+}
+
+// sampleSymbol samples the modules of a symbol located in b, with their
+// confidence, through its alignment patterns
+func sampleSymbol(b *images.BitImage, symbol *images.Symbol) ([][]bool, [][]float64, error) {
+	// The size estimated from the finder patterns can be off by a version on
+	// large symbols, the version information tells. It is read next to the
+	// finder patterns, before looking for alignment patterns of the wrong
+	// version.
+	if symbol.Dimension >= 45 {
+		grid, err := symbol.Sample(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		versionInfo, err := DecodeVersionInformation(readVersionInformation(gridMatrix(grid)))
+		if err == nil {
+			symbol.Dimension = 17 + 4*versionInfo.Version
+		}
+	}
+
+	symbol.Alignment = alignment_patterns_table[(symbol.Dimension-17)/4-1]
+	return symbol.SampleConfidence(b)
+}
+
+// DecodeGrid decodes a QR Code symbol given as a grid of modules, true being dark.
+func DecodeGrid(grid [][]bool) (*qr, error) {
+	return DecodeMatrix(gridMatrix(grid))
+}
+
+// DecodeSoftGrid decodes like DecodeGrid, given also the confidence of each
+// module from 0 to 1 (see images.Symbol.SampleConfidence). Codewords with a
+// module below minModuleConfidence are Reed-Solomon erasures: the decoder
+// recomputes them instead of trusting their bits, and repairs up to twice as
+// many erasures as errors at unknown positions.
+func DecodeSoftGrid(grid [][]bool, confidence [][]float64) (*qr, error) {
+	matrix := gridMatrix(grid)
+	if len(confidence) != len(matrix) {
+		return nil, errors.New("decode: the confidence does not match the grid")
+	}
+	for i, row := range confidence {
+		if len(row) != len(matrix[i]) {
+			return nil, errors.New("decode: the confidence does not match the grid")
+		}
+		for j, c := range row {
+			matrix[i][j].doubt = 1 - c
+		}
+	}
+	return DecodeMatrix(matrix)
+}
+
+func gridMatrix(grid [][]bool) [][]module {
+	matrix := make([][]module, len(grid))
+	for i, row := range grid {
+		matrix[i] = make([]module, len(row))
+		for j, dark := range row {
+			if dark {
+				matrix[i][j] = module{bit: One}
+			} else {
+				matrix[i][j] = module{bit: Zero}
+			}
+		}
+	}
+	return matrix
+}
+
+// DecodeMatrix reverses generate() for a QR Code (Model 2) symbol: the
+// version follows from the size, the error correction level and mask from the
+// format information. Once the mask is undone the codewords are read back in
+// placement order, de-interleaved, error corrected and parsed into segments.
+// The returned qr holds the segments and the payload as UTF-8 text in data.
+//
+// A mirrored symbol, e.g. seen through the back of glass, is read transposed.
+// Only its format information passes the BCH check once transposed back, so
+// the orientation with fewer format information errors is tried first, then
+// the other one. The qr reports which in mirrored.
+func DecodeMatrix(matrix [][]module) (*qr, error) {
+	size := len(matrix)
+	for _, row := range matrix {
+		if len(row) != size {
+			return nil, errors.New("decode: the matrix is not square")
+		}
+	}
+	if size < 21 || size > 177 || (size-17)%4 != 0 {
+		return nil, fmt.Errorf("decode: no QR Code version measures %d modules", size)
+	}
+
+	transposed := transpose(matrix)
+	normalInfo, normalErr := DecodeFormatInformation(readFormatInformation(matrix))
+	mirroredInfo, mirroredErr := DecodeFormatInformation(readFormatInformation(transposed))
+	orientations := []bool{false, true}
+	if mirroredErr == nil && (normalErr != nil || mirroredInfo.Errors < normalInfo.Errors) {
+		orientations = []bool{true, false}
+	}
+
+	var first error
+	for _, mirrored := range orientations {
+		m := matrix
+		if mirrored {
+			m = transposed
+		}
+		qr, err := decodeMatrix(m)
+		if err == nil {
+			qr.mirrored = mirrored
+			return qr, nil
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	return nil, first
+}
+
+// doubtfulCodewords returns the positions of the codewords of a block with a
+// module below minModuleConfidence, the most doubtful first. Two error
+// correction codewords are left to check the correction, with none left any
+// erasures would do.
+func doubtfulCodewords(doubts []byte, numECCodewords int) []int {
+	var erasures []int
+	for i, doubt := range doubts {
+		if float64(doubt) > (1-minModuleConfidence)*255 {
+			erasures = append(erasures, i)
+		}
+	}
+	slices.SortStableFunc(erasures, func(a, b int) int {
+		return int(doubts[b]) - int(doubts[a])
+	})
+	return erasures[:max(0, min(len(erasures), numECCodewords-2))]
+}
+
+// transpose swaps the rows and columns of a square matrix
+func transpose(matrix [][]module) [][]module {
+	transposed := make([][]module, len(matrix))
+	for i := range transposed {
+		transposed[i] = make([]module, len(matrix))
+		for j := range transposed[i] {
+			transposed[i][j] = matrix[j][i]
+		}
+	}
+	return transposed
+}
+
+// decodeMatrix decodes a square matrix of the size of a version, in the
+// orientation given
+func decodeMatrix(matrix [][]module) (*qr, error) {
+	size := len(matrix)
+
+	// Step 1 - Function patterns of the version. From version 7 the version
+	// information must agree with the size.
+	v := version.QRVersion{Format: version.FORMAT_QR_MODEL_2, Number: (size - 17) / 4}
+	if v.Number >= 7 {
+		versionInfo, err := DecodeVersionInformation(readVersionInformation(matrix))
+		if err != nil {
+			return nil, fmt.Errorf("decode: %w", err)
+		}
+		if versionInfo.Version != v.Number {
+			return nil, fmt.Errorf("decode: version information says version %d, the size is that of version %d", versionInfo.Version, v.Number)
+		}
+	}
+	qr := newEmptyQRCode(v)
+	qr.finder_patterns()
+	qr.separators()
+	qr.timing_patterns()
+	qr.alignment_patterns()
+	qr.version_information()
+	qr.reserve_format_information_area()
+
+	// Step 2 - Format information
+	formatInfo, err := DecodeFormatInformation(readFormatInformation(matrix))
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	qr.error_corr_level = formatInfo.ECLevel
+	qr.mask = formatInfo.Mask
+
+	// Step 3 - Undo the mask (masking twice is a no-op)
+	qr.matrix = qr.apply_mask(qr.mask, matrix)
+
+	// Step 4 - Codewords, de-interleaved into data and EC blocks. A codeword
+	// is as doubtful as its most doubtful module, scaled to a byte to be
+	// de-interleaved alike. Remainder bits past the last codeword are ignored.
+	codewords := make([]byte, getTotalCodewords(v))
+	doubts := make([]byte, len(codewords))
+	for i, modules := range qr.codeword_module_positions()[:len(codewords)] {
+		for bit, pos := range modules {
+			m := qr.matrix[pos[0]][pos[1]]
+			if m.bit == One {
+				codewords[i] |= 0x80 >> bit
+			}
+			doubts[i] = max(doubts[i], byte(math.Round(m.doubt*255)))
+		}
+	}
+	dataBlocks, ecBlocks := qr.deinterleave(codewords)
+	dataDoubts, ecDoubts := qr.deinterleave(doubts)
+
+	// Step 5 - Error correction, block by block. Blocks with too many errors
+	// are corrected again with their doubtful codewords as erasures.
+	var data []byte
+	qr.unused_error_correction = 1
+	for i, block := range dataBlocks {
+		codewords := slices.Concat(block, ecBlocks[i])
+		corrected, errs, erased, err := reedSolomonDecode(codewords, len(ecBlocks[i]), nil)
+		if erasures := doubtfulCodewords(slices.Concat(dataDoubts[i], ecDoubts[i]), len(ecBlocks[i])); err != nil && len(erasures) > 0 {
+			if c, e, r, erasuresErr := reedSolomonDecode(codewords, len(ecBlocks[i]), erasures); erasuresErr == nil {
+				corrected, errs, erased, err = c, e, r, nil
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode: block %d: %w", i+1, err)
+		}
+		data = append(data, corrected[:len(block)]...)
+		qr.unused_error_correction = min(qr.unused_error_correction,
+			unusedErrorCorrection(errs, erased, len(ecBlocks[i]), misdecodeProtectionCodewords(v, qr.error_corr_level)))
+	}
+	qr.encoded_data = bitseq.FromBytes(data)
+
+	// Step 6 - Segments
+	qr.segments, err = decodeSegments(qr.encoded_data, v)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := segmentsPayload(qr.segments)
+	if err != nil {
+		return nil, err
+	}
+	qr.data = []byte(payload)
+	return qr, nil
+}
+
+// newEmptyQRCode allocates a symbol of version v with no module set
+func newEmptyQRCode(v version.QRVersion) *qr {
+	width, height := v.Size()
+	matrix := make([][]module, height)
+	isFunctionPattern := make([][]bool, height)
+	for i := range height {
+		matrix[i] = make([]module, width)
+		isFunctionPattern[i] = make([]bool, width)
+	}
+	qr := &qr{
+		matrix:              matrix,
+		is_function_pattern: isFunctionPattern,
+		version:             v,
+		width:               width,
+		height:              height,
+	}
+	if width == height {
+		qr.size = width
+	}
+	return qr
+}
+
+// readFormatInformation returns both copies of the format information, bit
+// 14 first, as placed by place_format_information
+func readFormatInformation(matrix [][]module) (uint16, uint16) {
+	size := len(matrix)
+	var copy1, copy2 uint16
+	read := func(formatInfo *uint16, row, col int) {
+		*formatInfo <<= 1
+		if matrix[row][col].bit == One {
+			*formatInfo |= 1
+		}
+	}
+
+	// Copy 1: bits 14-9 at (8, 0-5), bit 8 at (8, 7), bit 7 at (8, 8),
+	// bit 6 at (7, 8) and bits 5-0 at (5-0, 8)
+	for j := range 6 {
+		read(&copy1, 8, j)
+	}
+	read(&copy1, 8, 7)
+	read(&copy1, 8, 8)
+	read(&copy1, 7, 8)
+	for i := 5; i >= 0; i-- {
+		read(&copy1, i, 8)
+	}
+
+	// Copy 2: bits 14-8 at (size-1 to size-7, 8) and bits 7-0 at (8, size-8 to size-1)
+	for i := range 7 {
+		read(&copy2, size-1-i, 8)
+	}
+	for j := size - 8; j < size; j++ {
+		read(&copy2, 8, j)
+	}
+	return copy1, copy2
+}
+
+// readVersionInformation returns both copies of the version information,
+// bit 17 first, as placed by version_information
+func readVersionInformation(matrix [][]module) (uint32, uint32) {
+	size := len(matrix)
+	var copy1, copy2 uint32
+	for i := 5; i >= 0; i-- {
+		for j := size - 9; j >= size-11; j-- {
+			copy1 <<= 1
+			if matrix[i][j].bit == One {
+				copy1 |= 1
+			}
+		}
+	}
+	for j := 5; j >= 0; j-- {
+		for i := size - 9; i >= size-11; i-- {
+			copy2 <<= 1
+			if matrix[i][j].bit == One {
+				copy2 |= 1
+			}
+		}
+	}
+	return copy1, copy2
+}
+
+// deinterleave splits the codewords read from the symbol into the data and
+// error correction blocks of the version, reversing data_and_error_correction
+func (qr *qr) deinterleave(codewords []byte) (dataBlocks, ecBlocks [][]byte) {
+	ecInfo, _ := getECInfo(qr.version, qr.error_corr_level)
+	var dataLens []int
+	ecLen := 0
+	for _, group := range ecInfo.BlockGroups {
+		for range group.NumBlocks {
+			dataLens = append(dataLens, group.DataCodewords)
+		}
+		ecLen = group.TotalCodewords - group.DataCodewords // same in every group
+	}
+	dataBlocks = make([][]byte, len(dataLens))
+	ecBlocks = make([][]byte, len(dataLens))
+
+	// Codewords go round robin, the shorter data blocks run out first
+	next := 0
+	for i := range slices.Max(dataLens) {
+		for b, n := range dataLens {
+			if i < n {
+				dataBlocks[b] = append(dataBlocks[b], codewords[next])
+				next++
+			}
+		}
+	}
+	for range ecLen {
+		for b := range ecBlocks {
+			ecBlocks[b] = append(ecBlocks[b], codewords[next])
+			next++
+		}
+	}
+	return dataBlocks, ecBlocks
+}
+
+// decodeSegments parses the data bit stream of version v back into
+// segments, up to the terminator or the end of the data. Segments hold their
+// data as written by encodeSegment: byte mode segments keep the bytes of the
+// active charset, Kanji and Hanzi segments are converted to UTF-8.
+func decodeSegments(data bitseq.BitSeq, v version.QRVersion) ([]modes.Segment, error) {
+	r := bitseq.NewReader(data)
+	indicatorLen := modes.GetModeIndicatorBits(v, modes.NumericMode).Len()
+
+	var segments []modes.Segment
+	for {
+		indicator, ok := r.Read(indicatorLen)
+		if !ok || indicator == 0 {
+			// Terminator, possibly truncated at the end of the data
+			return segments, nil
+		}
+		mode, ok := modes.ModeForIndicator(v, indicator)
+		if !ok {
+			return nil, fmt.Errorf("decode: unknown mode indicator %0*b", indicatorLen, indicator)
+		}
+
+		s := modes.Segment{Mode: mode}
+		var err error
+		switch mode {
+		case modes.ECI:
+			s.ECI, err = modes.DecodeECIDesignator(r)
+		case modes.StructuredAppend:
+			s.Position, s.Total, s.Parity, err = modes.DecodeStructuredAppend(r)
+		case modes.FNC1FirstPosition:
+		case modes.FNC1SecondPosition:
+			s.Data, err = modes.DecodeApplicationIndicator(r)
+		default:
+			if mode == modes.HanziMode {
+				if err := modes.DecodeHanziSubset(r); err != nil {
+					return nil, err
+				}
+			}
+			count, ok := r.Read(GetCharCountLength(v, mode))
+			if !ok {
+				return nil, fmt.Errorf("decode: %s segment without character count", mode)
+			}
+			s.Data, err = decode(mode, r, int(count))
+		}
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, s)
+	}
+}
+
+// decode reads count characters of the given mode, reversing encode
+func decode(mode modes.QRMode, r *bitseq.Reader, count int) (string, error) {
+	switch mode {
+	case modes.NumericMode:
+		return modes.DecodeNumeric(r, count)
+	case modes.AlphanumericMode:
+		return modes.DecodeAlphanumeric(r, count)
+	case modes.ByteMode:
+		data := make([]byte, count)
+		for i := range data {
+			b, ok := r.Read(8)
+			if !ok {
+				return "", errors.New("byte segment: unexpected end of data")
+			}
+			data[i] = byte(b)
+		}
+		return string(data), nil
+	case modes.KanjiMode:
+		return modes.DecodeKanji(r, count)
+	case modes.HanziMode:
+		return modes.DecodeHanzi(r, count)
+	default:
+		return "", fmt.Errorf("decode: data mode %s not implemented", mode)
+	}
+}
+
+// segmentsPayload joins the data of the segments into UTF-8 text. Byte mode
+// data is read in the charset designated by the last ECI segment, ISO/IEC
+// 8859-1 by default. In the FNC1 modes alphanumeric group separators are
+// restored.
+func segmentsPayload(segments []modes.Segment) (string, error) {
+	charset, _ := modes.GetCharset(fmt.Sprint(modes.DefaultECI))
+	fnc1 := false
+	var b strings.Builder
+	for _, s := range segments {
+		switch s.Mode {
+		case modes.ECI:
+			c, ok := modes.GetCharset(fmt.Sprint(s.ECI))
+			if !ok {
+				return "", fmt.Errorf("decode: unsupported ECI %06d", s.ECI)
+			}
+			charset = c
+		case modes.FNC1FirstPosition, modes.FNC1SecondPosition:
+			fnc1 = true
+		case modes.StructuredAppend:
+		case modes.ByteMode:
+			text, err := charset.Decode([]byte(s.Data))
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(text)
+		case modes.AlphanumericMode:
+			if fnc1 {
+				b.WriteString(modes.UnescapeFNC1Alphanumeric(s.Data))
+			} else {
+				b.WriteString(s.Data)
+			}
+		default:
+			b.WriteString(s.Data)
+		}
+	}
+	return b.String(), nil
+}
+
+// payloadText returns the decoded data as passed to the payload package. The
+// data of a symbol with FNC1 in first position is GS1 element strings, which
+// a reader transmits after the ]Q3 symbology identifier: without it, element
+// strings of predefined length only look like digits.
+func (qr *qr) payloadText() string {
+	if slices.ContainsFunc(qr.segments, func(s modes.Segment) bool { return s.Mode == modes.FNC1FirstPosition }) {
+		return "]Q3" + string(qr.data)
+	}
+	return string(qr.data)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"errors"
+	"math/bits"
 )
 
 const (
@@ -10,8 +11,25 @@ const (
 	// BCH(15, 5) Generator Polynomial: x^10 + x^8 + x^5 + x^4 + x^2 + x + 1
 	// 10100110111 (0x537)
 	format_information_generator_poly = 0x537
+	// Micro QR Format Info Mask Pattern: 0b100010001000101 (0x4445)
+	micro_format_information_mask_pattern = 0x4445
 )
 
+// Micro QR symbol number, given by version and error correction level
+//
+// | Version | L | M | Q |
+// | :------ | - | - | - |
+// | M1      | 0 |   |   |
+// | M2      | 1 | 2 |   |
+// | M3      | 3 | 4 |   |
+// | M4      | 5 | 6 | 7 |
+var micro_symbol_numbers = map[int]map[errcorr]uint{
+	1: {ERR_CORR_L: 0},
+	2: {ERR_CORR_L: 1, ERR_CORR_M: 2},
+	3: {ERR_CORR_L: 3, ERR_CORR_M: 4},
+	4: {ERR_CORR_L: 5, ERR_CORR_M: 6, ERR_CORR_Q: 7},
+}
+
 // GenerateFormatInformation calculates the 15-bit format information sequence
 // containing 5 data bits (2 for error correction level, 3 for mask pattern)
 // and 10 error correction bits.
@@ -39,6 +57,123 @@ func GenerateFormatInformation(ecLevel errcorr, maskPattern int) (uint16, error)
 	return uint16(maskedSequence), nil
 }
 
+// GenerateMicroFormatInformation calculates the 15-bit Micro QR format
+// information sequence containing 5 data bits (3 for the symbol number, 2 for
+// the mask pattern) and 10 error correction bits.
+// The result is XORed with the Micro QR mask pattern.
+func GenerateMicroFormatInformation(versionNumber int, ecLevel errcorr, maskPattern int) (uint16, error) {
+	if maskPattern < 0 || maskPattern > 3 {
+		return 0, errors.New("invalid micro mask pattern reference")
+	}
+	symbolNumber, ok := micro_symbol_numbers[versionNumber][ecLevel]
+	if !ok {
+		return 0, errors.New("invalid micro version and error correction level")
+	}
+
+	// Format: [Symbol number (3 bits)] [Mask Pattern (2 bits)]
+	data := (symbolNumber << 2) | uint(maskPattern)
+	bchBits := encodeBCH15_5(data, format_information_generator_poly)
+	fullSequence := (data << 10) | bchBits
+
+	return uint16(fullSequence ^ micro_format_information_mask_pattern), nil
+}
+
+// rMQR error correction level bit, only M and H are available
+var rmqr_error_correction_codes = map[errcorr]uint{
+	ERR_CORR_M: 0,
+	ERR_CORR_H: 1,
+}
+
+// GenerateRMQRFormatInformation calculates the 18-bit rMQR format information
+// sequences containing 6 data bits (1 for the error correction level, 5 for
+// the version indicator) and 12 error correction bits calculated with the
+// same (18, 6) Golay code as the QR Code version information.
+// Two sequences are returned, XORed with the mask of the copy next to the
+// finder pattern and next to the finder sub-pattern respectively.
+func GenerateRMQRFormatInformation(versionNumber int, ecLevel errcorr) (finder, subFinder uint32, err error) {
+	ecBit, ok := rmqr_error_correction_codes[ecLevel]
+	if !ok {
+		return 0, 0, errors.New("invalid rMQR error correction level")
+	}
+	if versionNumber < 1 || versionNumber > 32 {
+		return 0, 0, errors.New("invalid rMQR version")
+	}
+
+	// Format: [EC Level (1 bit)] [Version indicator (5 bits), R7x43 being 0]
+	data := ecBit<<5 | uint(versionNumber-1)
+	fullSequence := uint32(data<<12 | encodeGolay18_6(data))
+
+	return fullSequence ^ rmqr_format_information_finder_mask, fullSequence ^ rmqr_format_information_sub_finder_mask, nil
+}
+
+// Up to 3 bit errors are corrected in the format information, the BCH(15, 5)
+// code has a minimum distance of 7, and in the version information, the
+// Golay (18, 6) code has a minimum distance of 8
+const (
+	maxFormatInformationErrors  = 3
+	maxVersionInformationErrors = 3
+)
+
+// FormatInformation is the content of the format information read from a
+// symbol, along with the copy it was taken from (1 around the upper left
+// finder pattern, 2 split between the other two) and the number of bits of
+// that copy that disagreed with it.
+type FormatInformation struct {
+	ECLevel errcorr
+	Mask    int
+	Copy    int
+	Errors  int
+}
+
+// DecodeFormatInformation returns the valid format information nearest to
+// either copy by Hamming distance. Ties go to copy 1, even when copy 2 is as
+// near to another codeword.
+func DecodeFormatInformation(copy1, copy2 uint16) (FormatInformation, error) {
+	best := FormatInformation{Errors: maxFormatInformationErrors + 1}
+	for _, level := range errCorrLevels {
+		for mask := range len(mask_patterns) {
+			formatInfo, _ := GenerateFormatInformation(level, mask)
+			for i, c := range []uint16{copy1, copy2} {
+				if d := bits.OnesCount16(formatInfo ^ c); d < best.Errors || d == best.Errors && i+1 < best.Copy {
+					best = FormatInformation{ECLevel: level, Mask: mask, Copy: i + 1, Errors: d}
+				}
+			}
+		}
+	}
+	if best.Errors > maxFormatInformationErrors {
+		return FormatInformation{}, errors.New("unreadable format information")
+	}
+	return best, nil
+}
+
+// VersionInformation is the version read from a symbol, along with the copy
+// it was taken from (1 upper right, 2 lower left) and the number of bits of
+// that copy that disagreed with it.
+type VersionInformation struct {
+	Version int
+	Copy    int
+	Errors  int
+}
+
+// DecodeVersionInformation returns the valid version information (versions 7
+// to 40) nearest to either 18-bit copy by Hamming distance. Ties go to copy 1,
+// even when copy 2 is as near to another version.
+func DecodeVersionInformation(copy1, copy2 uint32) (VersionInformation, error) {
+	best := VersionInformation{Errors: maxVersionInformationErrors + 1}
+	for v := 7; v <= 40; v++ {
+		versionInfo := uint32(uint(v)<<12 | encodeGolay18_6(uint(v)))
+		for i, c := range []uint32{copy1, copy2} {
+			if d := bits.OnesCount32(versionInfo ^ c); d < best.Errors || d == best.Errors && i+1 < best.Copy {
+				best = VersionInformation{Version: v, Copy: i + 1, Errors: d}
+			}
+		}
+	}
+	if best.Errors > maxVersionInformationErrors {
+		return VersionInformation{}, errors.New("unreadable version information")
+	}
+	return best, nil
+}
+
 // encodeBCH15_5 calculates the BCH error correction bits.
 // data: The data bits (5 bits for format info).
 // poly: The generator polynomial.
@@ -72,6 +72,43 @@ func TestGenerateFormatInformation(t *testing.T) {
 	}
 }
 
+func TestPlaceFormatInformation(t *testing.T) {
+	// Both copies read back most significant bit first, as in ISO/IEC
+	// 18004:2024 Figure 25
+	for _, level := range []string{"L", "M", "Q", "H"} {
+		for _, version := range []int{2, 7} {
+			qr := NewQRCode(QRRequest{input_data: "HELLO WORLD", err_corr_level: level, version: version})
+			want, err := GenerateFormatInformation(qr.error_corr_level, qr.mask)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// (row, column) of bits 14 to 0
+			topLeft := [][2]int{{8, 0}, {8, 1}, {8, 2}, {8, 3}, {8, 4}, {8, 5}, {8, 7}, {8, 8}, {7, 8}, {5, 8}, {4, 8}, {3, 8}, {2, 8}, {1, 8}, {0, 8}}
+			var split [][2]int
+			for i := range 7 {
+				split = append(split, [2]int{qr.size - 1 - i, 8})
+			}
+			for i := range 8 {
+				split = append(split, [2]int{8, qr.size - 8 + i})
+			}
+
+			for name, positions := range map[string][][2]int{"top-left": topLeft, "bottom-left and top-right": split} {
+				var got uint16
+				for _, pos := range positions {
+					got <<= 1
+					if qr.matrix[pos[0]][pos[1]].bit == One {
+						got |= 1
+					}
+				}
+				if got != want {
+					t.Errorf("version %d-%s %s copy = %015b, want %015b", version, level, name, got, want)
+				}
+			}
+		}
+	}
+}
+
 func TestCalculateBCH(t *testing.T) {
 	// Test calculateBCH with the example from the spec
 	// Data: 00101 (5)
@@ -87,3 +124,232 @@ func TestCalculateBCH(t *testing.T) {
 		t.Errorf("calculateBCH(0x%X, 0x%X) = 0x%X, want 0x%X", data, poly, got, expected)
 	}
 }
+
+func TestGenerateMicroFormatInformation(t *testing.T) {
+	tests := []struct {
+		name        string
+		version     int
+		ecLevel     errcorr
+		maskPattern int
+		expected    uint16
+		wantErr     bool
+	}{
+		{
+			// Symbol number 0, mask 00: all-zero data, only the mask remains
+			name:        "M1, Mask 0",
+			version:     1,
+			ecLevel:     ERR_CORR_L,
+			maskPattern: 0,
+			expected:    0x4445,
+		},
+		{
+			name:        "M2-L, Mask 0",
+			version:     2,
+			ecLevel:     ERR_CORR_L,
+			maskPattern: 0,
+			expected:    0x55AE,
+		},
+		{
+			name:        "M3-M, Mask 1",
+			version:     3,
+			ecLevel:     ERR_CORR_M,
+			maskPattern: 1,
+			expected:    0x03E9,
+		},
+		{
+			name:        "M4-Q, Mask 3",
+			version:     4,
+			ecLevel:     ERR_CORR_Q,
+			maskPattern: 3,
+			expected:    0x3BBA,
+		},
+		{
+			name:        "Invalid Mask Pattern",
+			version:     4,
+			ecLevel:     ERR_CORR_L,
+			maskPattern: 4,
+			wantErr:     true,
+		},
+		{
+			name:        "Level not available in M1",
+			version:     1,
+			ecLevel:     ERR_CORR_M,
+			maskPattern: 0,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateMicroFormatInformation(tt.version, tt.ecLevel, tt.maskPattern)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GenerateMicroFormatInformation() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got != tt.expected {
+				t.Errorf("GenerateMicroFormatInformation() = 0x%X, want 0x%X", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenerateRMQRFormatInformation(t *testing.T) {
+	tests := []struct {
+		name          string
+		version       int
+		ecLevel       errcorr
+		wantFinder    uint32
+		wantSubFinder uint32
+		wantErr       bool
+	}{
+		{
+			// All-zero data, only the masks remain
+			name:          "R7x43-M",
+			version:       1,
+			ecLevel:       ERR_CORR_M,
+			wantFinder:    0b011111101010110010,
+			wantSubFinder: 0b100000101001111011,
+		},
+		{
+			// Data 000111, same Golay code as QR Code version 7 (0x07C94)
+			name:          "R9x77-M",
+			version:       8,
+			ecLevel:       ERR_CORR_M,
+			wantFinder:    0x07C94 ^ 0b011111101010110010,
+			wantSubFinder: 0x07C94 ^ 0b100000101001111011,
+		},
+		{
+			name:    "R7x43-L",
+			version: 1,
+			ecLevel: ERR_CORR_L,
+			wantErr: true,
+		},
+		{
+			name:    "Version 33",
+			version: 33,
+			ecLevel: ERR_CORR_H,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			finder, subFinder, err := GenerateRMQRFormatInformation(tt.version, tt.ecLevel)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateRMQRFormatInformation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if finder != tt.wantFinder || subFinder != tt.wantSubFinder {
+				t.Errorf("GenerateRMQRFormatInformation() = %018b, %018b, want %018b, %018b", finder, subFinder, tt.wantFinder, tt.wantSubFinder)
+			}
+		})
+	}
+}
+
+func TestDecodeFormatInformation(t *testing.T) {
+	// Level M, mask 5 (see TestGenerateFormatInformation)
+	const valid = 0b100000011001110
+	tests := []struct {
+		name         string
+		copy1, copy2 uint16
+		want         FormatInformation
+		wantErr      bool
+	}{
+		{
+			name:  "Both copies intact",
+			copy1: valid,
+			copy2: valid,
+			want:  FormatInformation{ECLevel: ERR_CORR_M, Mask: 5, Copy: 1},
+		},
+		{
+			name:  "3 errors in copy 1",
+			copy1: valid ^ 0b100000100000001,
+			copy2: valid ^ 0b111111000000000,
+			want:  FormatInformation{ECLevel: ERR_CORR_M, Mask: 5, Copy: 1, Errors: 3},
+		},
+		{
+			name:  "Copy 2 closer",
+			copy1: valid ^ 0b000011100000000,
+			copy2: valid ^ 0b000000000000100,
+			want:  FormatInformation{ECLevel: ERR_CORR_M, Mask: 5, Copy: 2, Errors: 1},
+		},
+		{
+			// Level L, mask 0 comes first but is no nearer to copy 2
+			name:  "Tie with copy 2 on an earlier codeword",
+			copy1: valid ^ 0b000000000000010,
+			copy2: 0b111011111000100 ^ 0b010000000000000,
+			want:  FormatInformation{ECLevel: ERR_CORR_M, Mask: 5, Copy: 1, Errors: 1},
+		},
+		{
+			name:    "4 errors in both copies",
+			copy1:   valid ^ 0b000000000001111,
+			copy2:   valid ^ 0b111100000000000,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeFormatInformation(tt.copy1, tt.copy2)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeFormatInformation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DecodeFormatInformation() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeVersionInformation(t *testing.T) {
+	// Versions 7 and 40 from ISO/IEC 18004 Annex D
+	const version7, version40 = 0x07C94, 0x28C69
+	tests := []struct {
+		name         string
+		copy1, copy2 uint32
+		want         VersionInformation
+		wantErr      bool
+	}{
+		{
+			name:  "Version 7",
+			copy1: version7,
+			copy2: version7,
+			want:  VersionInformation{Version: 7, Copy: 1},
+		},
+		{
+			name:  "Version 40, 3 errors in copy 1",
+			copy1: version40 ^ 0b100000000100000001,
+			copy2: version40 ^ 0b111100000000000000,
+			want:  VersionInformation{Version: 40, Copy: 1, Errors: 3},
+		},
+		{
+			name:  "Version 40, copy 2 closer",
+			copy1: 0,
+			copy2: version40 ^ 0b000000000000000011,
+			want:  VersionInformation{Version: 40, Copy: 2, Errors: 2},
+		},
+		{
+			name:  "Tie with copy 2 on an earlier version",
+			copy1: version40 ^ 0b000000000000000100,
+			copy2: version7 ^ 0b000000000000000100,
+			want:  VersionInformation{Version: 40, Copy: 1, Errors: 1},
+		},
+		{
+			name:    "4 errors in both copies",
+			copy1:   version7 ^ 0b000000000000001111,
+			copy2:   version7 ^ 0b111100000000000000,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeVersionInformation(tt.copy1, tt.copy2)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeVersionInformation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DecodeVersionInformation() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
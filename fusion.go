@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/harogaston/qr-decoder/images"
+)
+
+// Fusion is how DecodeFrames combines the modules sampled in each frame
+type Fusion string
+
+const (
+	// Every frame has one vote per module
+	FusionMajority Fusion = "majority"
+	// Frames vote with the confidence of their samples, so a clear module
+	// outweighs blurred or worn ones
+	FusionConfidence Fusion = "confidence"
+)
+
+// frame is a symbol sampled in one image
+type frame struct {
+	grid       [][]bool
+	confidence [][]float64
+	// Light modules on a dark background, sampled in the inverted image
+	inverted bool
+}
+
+// DecodeFrames decodes a symbol seen in several images, e.g. a burst of
+// frames of the same label. The symbol is located and sampled in each frame
+// on its own, frames where it is not found are skipped, and so are those
+// that disagree with most frames on its dimension. Every module then takes
+// the value with the most votes, and the share of the votes for that value
+// is its confidence for DecodeSoftGrid. No frame needs to be readable alone.
+// A symbol not found in a frame is looked for again in the frame inverted,
+// and the qr reports in reversed whether most frames were. The zero Fusion
+// is FusionConfidence.
+func DecodeFrames(frames []image.Image, binarizer images.Binarizer, fusion Fusion) (*qr, error) {
+	if fusion != "" && fusion != FusionMajority && fusion != FusionConfidence {
+		return nil, fmt.Errorf("decode: unknown fusion %q", fusion)
+	}
+
+	// Sampled frames by dimension
+	sampled := map[int][]frame{}
+	dimension := 0
+	var first error
+	for i, img := range frames {
+		b, err := binarizer.Binarize(img)
+		if err != nil {
+			return nil, err
+		}
+		var f frame
+		symbol, err := images.Locate(b)
+		if err != nil {
+			inverted := b.Inverted()
+			if invertedSymbol, invertedErr := images.Locate(inverted); invertedErr == nil {
+				b, symbol, err = inverted, invertedSymbol, nil
+				f.inverted = true
+			}
+		}
+		if err == nil {
+			f.grid, f.confidence, err = sampleSymbol(b, symbol)
+			if err == nil {
+				d := len(f.grid)
+				sampled[d] = append(sampled[d], f)
+				if len(sampled[d]) > len(sampled[dimension]) {
+					dimension = d
+				}
+				continue
+			}
+		}
+		if first == nil {
+			first = fmt.Errorf("decode: frame %d: %w", i+1, err)
+		}
+	}
+	if dimension == 0 {
+		if first == nil {
+			return nil, errors.New("decode: no frames")
+		}
+		return nil, first
+	}
+
+	grid, confidence := fuseFrames(sampled[dimension], fusion)
+	qr, err := DecodeSoftGrid(grid, confidence)
+	if err != nil {
+		return nil, err
+	}
+	inverted := 0
+	for _, f := range sampled[dimension] {
+		if f.inverted {
+			inverted++
+		}
+	}
+	qr.reversed = 2*inverted > len(sampled[dimension])
+	return qr, nil
+}
+
+// fuseFrames votes for the value of every module of frames of the same
+// dimension. Ties are light.
+// returns the modules and the share of the votes for their value
+func fuseFrames(frames []frame, fusion Fusion) ([][]bool, [][]float64) {
+	size := len(frames[0].grid)
+	grid := make([][]bool, size)
+	confidence := make([][]float64, size)
+	for i := range size {
+		grid[i] = make([]bool, size)
+		confidence[i] = make([]float64, size)
+		for j := range size {
+			var dark, light float64
+			for _, f := range frames {
+				vote := 1.0
+				if fusion != FusionMajority {
+					vote = f.confidence[i][j]
+				}
+				if f.grid[i][j] {
+					dark += vote
+				} else {
+					light += vote
+				}
+			}
+			grid[i][j] = dark > light
+			confidence[i][j] = max(dark, light) / (dark + light)
+		}
+	}
+	return grid, confidence
+}
+
+// DecodeFrameDirectory decodes the PNG images of dir, in name order, as
+// frames of the same symbol with DecodeFrames
+func DecodeFrameDirectory(dir string, binarizer images.Binarizer, fusion Fusion) (*qr, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var frames []image.Image
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".png") {
+			continue
+		}
+		img, err := images.Load(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		frames = append(frames, img)
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("decode: no PNG images in %s", dir)
+	}
+	return DecodeFrames(frames, binarizer, fusion)
+}
@@ -3,3 +3,5 @@ module github.com/harogaston/qr-decoder
 go 1.24.1
 
 require github.com/twpayne/go-svg v1.0.0
+
+require golang.org/x/text v0.28.0
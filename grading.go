@@ -0,0 +1,364 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/harogaston/qr-decoder/images"
+	"github.com/harogaston/qr-decoder/version"
+)
+
+// Grade is an ISO/IEC 15415 print quality grade, A being the best. The zero
+// value is F.
+type Grade int
+
+const (
+	GradeF Grade = iota
+	GradeD
+	GradeC
+	GradeB
+	GradeA
+)
+
+func (g Grade) String() string {
+	return string("FDCBA"[g])
+}
+
+// Lower bounds of grades A, B, C and D
+var (
+	symbolContrastGrades        = [4]float64{0.70, 0.55, 0.40, 0.20}
+	modulationGrades            = [4]float64{0.50, 0.40, 0.30, 0.20}
+	unusedErrorCorrectionGrades = [4]float64{0.62, 0.50, 0.37, 0.25}
+)
+
+// Upper bounds of grades A, B, C and D
+var (
+	axialNonuniformityGrades = [4]float64{0.06, 0.08, 0.10, 0.12}
+	// In modules
+	gridNonuniformityGrades = [4]float64{0.38, 0.50, 0.63, 0.75}
+	// Damaged modules of a finder pattern and its separator
+	finderDamageGrades = [4]float64{0, 1, 2, 3}
+	// Share of the timing pattern modules damaged
+	timingDamageGrades = [4]float64{0, 0.07, 0.10, 0.15}
+)
+
+// gradeAtLeast grades value by the lower bounds of grades A to D
+func gradeAtLeast(value float64, bounds [4]float64) Grade {
+	for i, bound := range bounds {
+		if value >= bound {
+			return GradeA - Grade(i)
+		}
+	}
+	return GradeF
+}
+
+// gradeAtMost grades value by the upper bounds of grades A to D
+func gradeAtMost(value float64, bounds [4]float64) Grade {
+	for i, bound := range bounds {
+		if value <= bound {
+			return GradeA - Grade(i)
+		}
+	}
+	return GradeF
+}
+
+// Measure is a print quality parameter and its grade
+type Measure struct {
+	Value float64
+	Grade Grade
+}
+
+// QualityReport holds the ISO/IEC 15415 print quality parameters of a
+// symbol. Reflectances go from 0 (black) to 1 (white).
+type QualityReport struct {
+	// A if the symbol decodes, F otherwise
+	Decode Grade
+	// Highest minus lowest module reflectance
+	SymbolContrast Measure
+	// Lowest module modulation 2 x |R - GT| / SC of the codewords, GT being
+	// halfway between the highest and lowest reflectances. The grade allows
+	// for error correction.
+	Modulation Measure
+	// Modulation of the modules on the right side of GT, 0 for the others.
+	// The grade allows for error correction.
+	ReflectanceMargin Measure
+	// Damaged modules in the finder patterns, their separators and the
+	// timing patterns
+	FixedPatternDamage Measure
+	// Difference between the module spacing along rows and along columns,
+	// relative to their mean
+	AxialNonuniformity Measure
+	// Largest distance in modules between the sampled module centers and
+	// the single perspective transform of the finder patterns
+	GridNonuniformity Measure
+	// Share of the error correction capacity left by the worst block
+	UnusedErrorCorrection Measure
+	// Lowest grade of all
+	Overall Grade
+	// Light modules on a dark background, graded in the inverted image
+	Reversed bool
+}
+
+// GradeImage grades the symbol of img, its luminance binarized with the
+// zero Binarizer. A symbol that is not found or does not decode is graded
+// again in the inverted image, for light modules on a dark background, if it
+// decodes there.
+func GradeImage(img image.Image) (*QualityReport, error) {
+	gray, err := images.Grayscale(img, images.ChannelLuminance)
+	if err != nil {
+		return nil, err
+	}
+	b := images.HybridBinarize(gray)
+	report, err := gradeBitImage(gray, b)
+	if err == nil && report.Decode == GradeA {
+		return report, nil
+	}
+	if inverted, invertedErr := gradeBitImage(invertGray(gray), b.Inverted()); invertedErr == nil && inverted.Decode == GradeA {
+		inverted.Reversed = true
+		return inverted, nil
+	}
+	return report, err
+}
+
+func gradeBitImage(gray *image.Gray, b *images.BitImage) (*QualityReport, error) {
+	symbol, err := images.Locate(b)
+	if err != nil {
+		return nil, err
+	}
+	return GradeSymbol(gray, b, symbol)
+}
+
+// invertGray returns the negative of gray
+func invertGray(gray *image.Gray) *image.Gray {
+	bounds := gray.Bounds()
+	inverted := image.NewGray(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			inverted.SetGray(x, y, color.Gray{Y: 255 - gray.GrayAt(x, y).Y})
+		}
+	}
+	return inverted
+}
+
+// GradeSymbol measures the print quality of a symbol located in b, binarized
+// from gray. The reflectance of each module is the mean grey level of an
+// aperture 0.8 modules wide at its center. Reflectance margin and fixed
+// pattern damage compare the modules with the symbol rebuilt from the
+// decoded codewords, an undecodable symbol grades F on them.
+func GradeSymbol(gray *image.Gray, b *images.BitImage, symbol *images.Symbol) (*QualityReport, error) {
+	grid, confidence, err := sampleSymbol(b, symbol)
+	if err != nil {
+		return nil, err
+	}
+	report := &QualityReport{}
+	decoded, err := DecodeSoftGrid(grid, confidence)
+	if err == nil {
+		report.Decode = GradeA
+	}
+
+	// Step 1 - Reflectances, in the orientation of the decoded symbol
+	centers := symbol.ModuleCenters(b)
+	d := len(centers)
+	reflectance := make([][]float64, d)
+	rmin, rmax := 1.0, 0.0
+	for i := range d {
+		reflectance[i] = make([]float64, d)
+		for j := range d {
+			c := centers[i][j]
+			if decoded != nil && decoded.mirrored {
+				c = centers[j][i]
+			}
+			r := apertureReflectance(gray, c, 0.4*symbol.ModuleSize)
+			reflectance[i][j] = r
+			rmin, rmax = min(rmin, r), max(rmax, r)
+		}
+	}
+	sc := rmax - rmin
+	report.SymbolContrast = Measure{sc, gradeAtLeast(sc, symbolContrastGrades)}
+	gt := (rmax + rmin) / 2
+	modulation := make([][]float64, d)
+	for i := range d {
+		modulation[i] = make([]float64, d)
+		for j := range d {
+			if sc > 0 {
+				modulation[i][j] = 2 * math.Abs(reflectance[i][j]-gt) / sc
+			}
+		}
+	}
+
+	// Step 2 - Grid geometry
+	var x, y float64
+	for i := range d {
+		for j := range d - 1 {
+			x += distance(centers[i][j], centers[i][j+1])
+			y += distance(centers[j][i], centers[j+1][i])
+		}
+	}
+	x, y = x/float64(d*(d-1)), y/float64(d*(d-1))
+	an := math.Abs(x-y) / ((x + y) / 2)
+	report.AxialNonuniformity = Measure{an, gradeAtMost(an, axialNonuniformityGrades)}
+	global := symbol.Transform()
+	gn := 0.0
+	for i := range d {
+		for j := range d {
+			ideal := global.Apply(images.Point{X: float64(j) + 0.5, Y: float64(i) + 0.5})
+			gn = max(gn, distance(centers[i][j], ideal)/((x+y)/2))
+		}
+	}
+	report.GridNonuniformity = Measure{gn, gradeAtMost(gn, gridNonuniformityGrades)}
+
+	if decoded == nil {
+		report.Overall = GradeF
+		return report, nil
+	}
+
+	// Step 3 - Against the symbol rebuilt from the decoded codewords
+	ideal := decoded.withMask(decoded.mask).matrix
+	margin := make([][]float64, d)
+	damaged := make([][]bool, d)
+	for i := range d {
+		margin[i] = make([]float64, d)
+		damaged[i] = make([]bool, d)
+		for j := range d {
+			damaged[i][j] = (reflectance[i][j] < gt) != (ideal[i][j].bit == One)
+			if !damaged[i][j] {
+				margin[i][j] = modulation[i][j]
+			}
+		}
+	}
+	report.Modulation = decoded.codewordsMeasure(modulation)
+	report.ReflectanceMargin = decoded.codewordsMeasure(margin)
+	report.FixedPatternDamage = fixedPatternDamage(damaged)
+
+	uec := decoded.unused_error_correction
+	report.UnusedErrorCorrection = Measure{uec, gradeAtLeast(uec, unusedErrorCorrectionGrades)}
+
+	report.Overall = min(report.Decode, report.SymbolContrast.Grade, report.Modulation.Grade,
+		report.ReflectanceMargin.Grade, report.FixedPatternDamage.Grade, report.AxialNonuniformity.Grade,
+		report.GridNonuniformity.Grade, report.UnusedErrorCorrection.Grade)
+	return report, nil
+}
+
+// apertureReflectance returns the mean grey level of the pixels of gray
+// within radius of center, from 0 (black) to 1 (white)
+func apertureReflectance(gray *image.Gray, center images.Point, radius float64) float64 {
+	bounds := gray.Bounds()
+	sum, n := 0.0, 0
+	for y := int(math.Floor(center.Y - radius)); y <= int(math.Ceil(center.Y+radius)); y++ {
+		for x := int(math.Floor(center.X - radius)); x <= int(math.Ceil(center.X+radius)); x++ {
+			px, py := float64(x)+0.5-center.X, float64(y)+0.5-center.Y
+			if px*px+py*py > radius*radius || !(image.Point{X: x, Y: y}).In(bounds) {
+				continue
+			}
+			sum += float64(gray.GrayAt(x, y).Y)
+			n++
+		}
+	}
+	if n == 0 {
+		// Smaller than a pixel
+		return float64(gray.GrayAt(int(center.X), int(center.Y)).Y) / 255
+	}
+	return sum / float64(n) / 255
+}
+
+func distance(a, b images.Point) float64 {
+	return math.Hypot(a.X-b.X, a.Y-b.Y)
+}
+
+// codewordsMeasure grades the codewords by the lowest value of their modules,
+// allowing for error correction: for each grade, the codewords graded lower
+// are taken as erasures, and the grade is capped by that of the error
+// correction they leave unused in the worst block. The symbol gets the best
+// of these grades.
+// returns the lowest value of a codeword module
+func (qr *qr) codewordsMeasure(values [][]float64) Measure {
+	lowest := math.Inf(1)
+	grades := make([]byte, getTotalCodewords(qr.version))
+	for i, modules := range qr.codeword_module_positions()[:len(grades)] {
+		value := math.Inf(1)
+		for _, pos := range modules {
+			value = min(value, values[pos[0]][pos[1]])
+		}
+		lowest = min(lowest, value)
+		grades[i] = byte(gradeAtLeast(value, modulationGrades))
+	}
+	dataGrades, ecGrades := qr.deinterleave(grades)
+	p := misdecodeProtectionCodewords(qr.version, qr.error_corr_level)
+
+	best := GradeF
+	for level := GradeA; level > GradeF; level-- {
+		uec := 1.0
+		for i := range dataGrades {
+			erasures := 0
+			for _, g := range dataGrades[i] {
+				if Grade(g) < level {
+					erasures++
+				}
+			}
+			for _, g := range ecGrades[i] {
+				if Grade(g) < level {
+					erasures++
+				}
+			}
+			uec = min(uec, unusedErrorCorrection(0, erasures, len(ecGrades[i]), p))
+		}
+		best = max(best, min(level, gradeAtLeast(uec, unusedErrorCorrectionGrades)))
+	}
+	return Measure{lowest, best}
+}
+
+// fixedPatternDamage grades the finder patterns with their separators by the
+// number of damaged modules of the worst one, and the timing patterns by
+// their share of damaged modules
+func fixedPatternDamage(damaged [][]bool) Measure {
+	size := len(damaged)
+	total := 0
+	grade := GradeA
+	for _, corner := range [][2]int{{0, 0}, {0, size - 8}, {size - 8, 0}} {
+		n := 0
+		for i := corner[0]; i < corner[0]+8; i++ {
+			for j := corner[1]; j < corner[1]+8; j++ {
+				if damaged[i][j] {
+					n++
+				}
+			}
+		}
+		total += n
+		grade = min(grade, gradeAtMost(float64(n), finderDamageGrades))
+	}
+
+	n := 0
+	for k := 8; k < size-8; k++ {
+		if damaged[6][k] {
+			n++
+		}
+		if damaged[k][6] {
+			n++
+		}
+	}
+	total += n
+	grade = min(grade, gradeAtMost(float64(n)/float64(2*(size-16)), timingDamageGrades))
+	return Measure{float64(total), grade}
+}
+
+// unusedErrorCorrection returns the share of the error correction capacity
+// of a block left after correcting errors and erasures, the p misdecode
+// protection codewords aside. Below 0 the block could not be corrected.
+func unusedErrorCorrection(errors, erasures, numECCodewords, p int) float64 {
+	return 1 - float64(erasures+2*errors)/float64(numECCodewords-p)
+}
+
+// misdecodeProtectionCodewords returns the error correction codewords of the
+// smallest versions that only detect errors (ISO/IEC 18004, table 9)
+func misdecodeProtectionCodewords(v version.QRVersion, ecLevel errcorr) int {
+	switch {
+	case v.Number == 1 && ecLevel == ERR_CORR_L:
+		return 3
+	case v.Number == 1 && ecLevel == ERR_CORR_M, v.Number == 2 && ecLevel == ERR_CORR_L:
+		return 2
+	case v.Number == 1, v.Number == 3 && ecLevel == ERR_CORR_L:
+		return 1
+	}
+	return 0
+}
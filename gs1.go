@@ -0,0 +1,74 @@
+package main
+
+import (
+	"math"
+
+	"github.com/harogaston/qr-decoder/gs1"
+	"github.com/harogaston/qr-decoder/modes"
+	"github.com/harogaston/qr-decoder/version"
+)
+
+// Above this number of variable length elements their order is kept as given
+const maxPermutedGS1Elements = 6
+
+// NewGS1Request builds a GS1 QR Code request (FNC1 in first position) from
+// the human readable interpretation of GS1 element strings, for example
+// "(01)09501101530003(17)260101(10)ABC".
+func NewGS1Request(hri string, err_corr_level string) (QRRequest, error) {
+	elements, err := gs1.Parse(hri)
+	if err != nil {
+		return QRRequest{}, err
+	}
+	return QRRequest{
+		input_data:     gs1ElementString(elements),
+		err_corr_level: err_corr_level,
+		fnc1:           &modes.Segment{Mode: modes.FNC1FirstPosition},
+	}, nil
+}
+
+// gs1ElementString concatenates elements in the order that segments into the
+// fewest bits. Elements of predefined length go first since they need no
+// separator, then the remaining ones are tried in every order so that the
+// separators and mode switches cost as little as possible.
+func gs1ElementString(elements []gs1.Element) string {
+	var fixed, variable []gs1.Element
+	for _, e := range elements {
+		if gs1.HasPredefinedLength(e.AI) {
+			fixed = append(fixed, e)
+		} else {
+			variable = append(variable, e)
+		}
+	}
+
+	if len(variable) > maxPermutedGS1Elements {
+		return gs1.ElementString(append(fixed, variable...))
+	}
+
+	v := version.QRVersion{Format: version.FORMAT_QR_MODEL_2, Number: 1}
+	charset := modes.DetectCharset("")
+	best, bestBits := "", math.MaxInt
+	permute(variable, 0, func(order []gs1.Element) {
+		candidate := gs1.ElementString(append(append([]gs1.Element{}, fixed...), order...))
+		segments, err := GetSegments(candidate, v, charset, true)
+		if err != nil {
+			return
+		}
+		if bits := segmentsBitLength(segments, v); bits >= 0 && bits < bestBits {
+			best, bestBits = candidate, bits
+		}
+	})
+	return best
+}
+
+// permute calls fn with every ordering of elements[k:].
+func permute(elements []gs1.Element, k int, fn func([]gs1.Element)) {
+	if k >= len(elements)-1 {
+		fn(elements)
+		return
+	}
+	for i := k; i < len(elements); i++ {
+		elements[k], elements[i] = elements[i], elements[k]
+		permute(elements, k+1, fn)
+		elements[k], elements[i] = elements[i], elements[k]
+	}
+}
@@ -0,0 +1,108 @@
+package gs1
+
+// GS1 AI encodable character set 82
+const cset82 = "!\"%&'()*+,-./0123456789:;<=>?ABCDEFGHIJKLMNOPQRSTUVWXYZ_abcdefghijklmnopqrstuvwxyz"
+
+// aiSpec describes the data field of an AI. format lists its components
+// joined by '+': N for digits, X for character set 82, followed by a fixed
+// length or by ".." and a maximum length.
+type aiSpec struct {
+	title      string
+	format     string
+	checkDigit bool // the first component ends with a check digit
+	date       bool // the first component is a YYMMDD date, or YYMMDDHHMM for N10
+}
+
+// Element strings whose total length (AI included) is predefined by the
+// first two digits of the AI. No separator is needed after them.
+var predefinedLengths = map[string]int{
+	"00": 20, "01": 16, "02": 16, "03": 16, "04": 18,
+	"11": 8, "12": 8, "13": 8, "14": 8, "15": 8, "16": 8, "17": 8, "18": 8, "19": 8,
+	"20": 4,
+	"31": 10, "32": 10, "33": 10, "34": 10, "35": 10, "36": 10,
+	"41": 16,
+}
+
+// Subset of the GS1 General Specifications AI table. A trailing 'n' stands
+// for the decimal point position digit.
+var applicationIdentifiers = map[string]aiSpec{
+	"00":   {title: "SSCC", format: "N18", checkDigit: true},
+	"01":   {title: "GTIN", format: "N14", checkDigit: true},
+	"02":   {title: "CONTENT", format: "N14", checkDigit: true},
+	"10":   {title: "BATCH/LOT", format: "X..20"},
+	"11":   {title: "PROD DATE", format: "N6", date: true},
+	"12":   {title: "DUE DATE", format: "N6", date: true},
+	"13":   {title: "PACK DATE", format: "N6", date: true},
+	"15":   {title: "BEST BEFORE or BEST BY", format: "N6", date: true},
+	"16":   {title: "SELL BY", format: "N6", date: true},
+	"17":   {title: "USE BY or EXPIRY", format: "N6", date: true},
+	"20":   {title: "VARIANT", format: "N2"},
+	"21":   {title: "SERIAL", format: "X..20"},
+	"22":   {title: "CPV", format: "X..20"},
+	"235":  {title: "TPX", format: "X..28"},
+	"240":  {title: "ADDITIONAL ID", format: "X..30"},
+	"241":  {title: "CUST. PART No.", format: "X..30"},
+	"242":  {title: "MTO VARIANT", format: "N..6"},
+	"243":  {title: "PCN", format: "X..20"},
+	"250":  {title: "SECONDARY SERIAL", format: "X..30"},
+	"251":  {title: "REF. TO SOURCE", format: "X..30"},
+	"253":  {title: "GDTI", format: "N13+X..17", checkDigit: true},
+	"254":  {title: "GLN EXTENSION COMPONENT", format: "X..20"},
+	"255":  {title: "GCN", format: "N13+N..12", checkDigit: true},
+	"30":   {title: "VAR. COUNT", format: "N..8"},
+	"310n": {title: "NET WEIGHT (kg)", format: "N6"},
+	"311n": {title: "LENGTH (m)", format: "N6"},
+	"312n": {title: "WIDTH (m)", format: "N6"},
+	"313n": {title: "HEIGHT (m)", format: "N6"},
+	"314n": {title: "AREA (m2)", format: "N6"},
+	"315n": {title: "NET VOLUME (l)", format: "N6"},
+	"316n": {title: "NET VOLUME (m3)", format: "N6"},
+	"320n": {title: "NET WEIGHT (lb)", format: "N6"},
+	"330n": {title: "GROSS WEIGHT (kg)", format: "N6"},
+	"331n": {title: "LENGTH (m), log", format: "N6"},
+	"332n": {title: "WIDTH (m), log", format: "N6"},
+	"333n": {title: "HEIGHT (m), log", format: "N6"},
+	"334n": {title: "AREA (m2), log", format: "N6"},
+	"335n": {title: "VOLUME (l), log", format: "N6"},
+	"336n": {title: "VOLUME (m3), log", format: "N6"},
+	"37":   {title: "COUNT", format: "N..8"},
+	"390n": {title: "AMOUNT", format: "N..15"},
+	"391n": {title: "AMOUNT", format: "N3+N..15"},
+	"392n": {title: "PRICE", format: "N..15"},
+	"393n": {title: "PRICE", format: "N3+N..15"},
+	"400":  {title: "ORDER NUMBER", format: "X..30"},
+	"401":  {title: "GINC", format: "X..30"},
+	"402":  {title: "GSIN", format: "N17", checkDigit: true},
+	"403":  {title: "ROUTE", format: "X..30"},
+	"410":  {title: "SHIP TO LOC", format: "N13", checkDigit: true},
+	"411":  {title: "BILL TO", format: "N13", checkDigit: true},
+	"412":  {title: "PURCHASE FROM", format: "N13", checkDigit: true},
+	"413":  {title: "SHIP FOR LOC", format: "N13", checkDigit: true},
+	"414":  {title: "LOC No.", format: "N13", checkDigit: true},
+	"415":  {title: "PAY TO", format: "N13", checkDigit: true},
+	"416":  {title: "PROD/SERV LOC", format: "N13", checkDigit: true},
+	"417":  {title: "PARTY", format: "N13", checkDigit: true},
+	"420":  {title: "SHIP TO POST", format: "X..20"},
+	"421":  {title: "SHIP TO POST", format: "N3+X..9"},
+	"422":  {title: "ORIGIN", format: "N3"},
+	"7003": {title: "EXPIRY TIME", format: "N10", date: true},
+	"7006": {title: "FIRST FREEZE DATE", format: "N6", date: true},
+	"8003": {title: "GRAI", format: "N14+X..16", checkDigit: true},
+	"8004": {title: "GIAI", format: "X..30"},
+	"8005": {title: "PRICE PER UNIT", format: "N6"},
+	"8006": {title: "ITIP", format: "N14+N2+N2", checkDigit: true},
+	"8017": {title: "GSRN - PROVIDER", format: "N18", checkDigit: true},
+	"8018": {title: "GSRN - RECIPIENT", format: "N18", checkDigit: true},
+	"8020": {title: "REF. No.", format: "X..25"},
+	"8200": {title: "PRODUCT URL", format: "X..70"},
+	"90":   {title: "INTERNAL", format: "X..30"},
+	"91":   {title: "INTERNAL", format: "X..90"},
+	"92":   {title: "INTERNAL", format: "X..90"},
+	"93":   {title: "INTERNAL", format: "X..90"},
+	"94":   {title: "INTERNAL", format: "X..90"},
+	"95":   {title: "INTERNAL", format: "X..90"},
+	"96":   {title: "INTERNAL", format: "X..90"},
+	"97":   {title: "INTERNAL", format: "X..90"},
+	"98":   {title: "INTERNAL", format: "X..90"},
+	"99":   {title: "INTERNAL", format: "X..90"},
+}
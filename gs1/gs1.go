@@ -0,0 +1,220 @@
+package gs1
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Group separator (FNC1) terminating element strings not of predefined length
+const GroupSeparator = "\x1D"
+
+// Element is an Application Identifier (AI) and its data field.
+type Element struct {
+	AI    string
+	Value string
+}
+
+func (e Element) String() string {
+	return fmt.Sprintf("(%s)%s", e.AI, e.Value)
+}
+
+// Bracketed AI starting an element in the human readable interpretation
+var aiPattern = regexp.MustCompile(`\((\d{2,4})\)`)
+
+// Parse parses the human readable interpretation of GS1 element strings, e.g.
+// "(01)09501101530003(17)260101(10)ABC", and validates every element.
+func Parse(hri string) ([]Element, error) {
+	matches := aiPattern.FindAllStringSubmatchIndex(hri, -1)
+	if len(matches) == 0 || matches[0][0] != 0 {
+		return nil, errors.New("gs1: input must start with a bracketed AI, e.g. (01)")
+	}
+
+	var elements []Element
+	for i, m := range matches {
+		end := len(hri)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		e := Element{AI: hri[m[2]:m[3]], Value: hri[m[1]:end]}
+		if err := e.Validate(); err != nil {
+			return nil, err
+		}
+		elements = append(elements, e)
+	}
+	return elements, nil
+}
+
+// ParseElementString splits concatenated element strings, as encoded after
+// FNC1 in first position, into elements and validates every element. An
+// element not of predefined length ends with a group separator, unless it
+// is the last one.
+func ParseElementString(s string) ([]Element, error) {
+	var elements []Element
+	for s != "" {
+		var ai string
+		for n := 2; n <= 4 && n <= len(s); n++ {
+			if _, ok := lookup(s[:n]); ok {
+				ai = s[:n]
+				break
+			}
+		}
+		if ai == "" {
+			return nil, fmt.Errorf("gs1: unknown AI at %q", s)
+		}
+
+		e := Element{AI: ai}
+		if length, ok := predefinedLengths[ai[:2]]; ok {
+			if len(s) < length {
+				return nil, fmt.Errorf("gs1: (%s) element string is too short, %d characters with the AI", ai, length)
+			}
+			// A separator after it is redundant but allowed
+			e.Value, s = s[len(ai):length], strings.TrimPrefix(s[length:], GroupSeparator)
+		} else if end := strings.Index(s, GroupSeparator); end >= 0 {
+			e.Value, s = s[len(ai):end], s[end+1:]
+		} else {
+			e.Value, s = s[len(ai):], ""
+		}
+		if err := e.Validate(); err != nil {
+			return nil, err
+		}
+		elements = append(elements, e)
+	}
+	if len(elements) == 0 {
+		return nil, errors.New("gs1: empty element string")
+	}
+	return elements, nil
+}
+
+// Validate checks the data field of the element against the format of its AI,
+// including check digits and dates.
+func (e Element) Validate() error {
+	spec, ok := lookup(e.AI)
+	if !ok {
+		return fmt.Errorf("gs1: unknown AI (%s)", e.AI)
+	}
+
+	value := e.Value
+	components := strings.Split(spec.format, "+")
+	for i, c := range components {
+		numeric := c[0] == 'N'
+		variable := strings.Contains(c, "..")
+		length, err := strconv.Atoi(strings.TrimLeft(c[1:], "."))
+		if err != nil {
+			panic(fmt.Sprintf("gs1: invalid format %q for AI (%s)", spec.format, e.AI))
+		}
+
+		// Fixed length components take exactly their length, a variable
+		// length component can only be the last one
+		n := length
+		if variable {
+			if len(value) > length {
+				return fmt.Errorf("gs1: (%s) %s data is too long, %d characters max", e.AI, spec.title, length)
+			}
+			if len(value) == 0 {
+				return fmt.Errorf("gs1: (%s) %s data is empty", e.AI, spec.title)
+			}
+			n = len(value)
+		} else if len(value) < length || (i == len(components)-1 && len(value) != length) {
+			return fmt.Errorf("gs1: (%s) %s data has the wrong length, format is %s", e.AI, spec.title, spec.format)
+		}
+
+		field := value[:n]
+		value = value[n:]
+		for _, r := range field {
+			if numeric && (r < '0' || r > '9') {
+				return fmt.Errorf("gs1: (%s) %s data must be numeric, got %q", e.AI, spec.title, field)
+			}
+			if !numeric && !strings.ContainsRune(cset82, r) {
+				return fmt.Errorf("gs1: (%s) %s data contains invalid character %q", e.AI, spec.title, r)
+			}
+		}
+
+		if i == 0 && spec.checkDigit && !ValidCheckDigit(field) {
+			return fmt.Errorf("gs1: (%s) %s has an invalid check digit", e.AI, spec.title)
+		}
+		if i == 0 && spec.date && !validDate(field) {
+			return fmt.Errorf("gs1: (%s) %s is not a valid %s date", e.AI, spec.title, "YYMMDDHHMM"[:len(field)])
+		}
+	}
+	return nil
+}
+
+// HasPredefinedLength reports whether the element string of ai has a length
+// predefined by its first two digits, in which case no separator follows it.
+func HasPredefinedLength(ai string) bool {
+	_, ok := predefinedLengths[ai[:2]]
+	return ok
+}
+
+// ElementString concatenates elements in the given order, terminating every
+// element not of predefined length with a group separator except the last one.
+func ElementString(elements []Element) string {
+	var b strings.Builder
+	for i, e := range elements {
+		b.WriteString(e.AI)
+		b.WriteString(e.Value)
+		if i < len(elements)-1 && !HasPredefinedLength(e.AI) {
+			b.WriteString(GroupSeparator)
+		}
+	}
+	return b.String()
+}
+
+// CheckDigit computes the GS1 modulo 10 check digit of digits: weights 3 and
+// 1 alternate starting from the rightmost digit.
+func CheckDigit(digits string) byte {
+	sum := 0
+	for i := range len(digits) {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// ValidCheckDigit reports whether the last digit of digits is its check digit.
+func ValidCheckDigit(digits string) bool {
+	if len(digits) < 2 {
+		return false
+	}
+	return CheckDigit(digits[:len(digits)-1]) == digits[len(digits)-1]
+}
+
+// validDate checks a YYMMDD date, day 00 stands for the end of the month,
+// or a YYMMDDHHMM date and time. Years are taken from 2000 to 2099, where
+// every fourth year is a leap year.
+func validDate(date string) bool {
+	year, _ := strconv.Atoi(date[0:2])
+	month, _ := strconv.Atoi(date[2:4])
+	day, _ := strconv.Atoi(date[4:6])
+	if month < 1 || month > 12 {
+		return false
+	}
+	if len(date) == 10 {
+		hour, _ := strconv.Atoi(date[6:8])
+		minute, _ := strconv.Atoi(date[8:10])
+		if hour > 23 || minute > 59 {
+			return false
+		}
+	}
+	// Day 0 of the next month is the last day of this one
+	return day <= time.Date(2000+year, time.Month(month+1), 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func lookup(ai string) (aiSpec, bool) {
+	if spec, ok := applicationIdentifiers[ai]; ok {
+		return spec, true
+	}
+	// AIs whose last digit is a decimal point position, e.g. 310n
+	if len(ai) == 4 {
+		spec, ok := applicationIdentifiers[ai[:3]+"n"]
+		return spec, ok
+	}
+	return aiSpec{}, false
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/harogaston/qr-decoder/gs1"
+	"github.com/harogaston/qr-decoder/modes"
+	"github.com/harogaston/qr-decoder/payload"
+)
+
+func TestGS1Parse(t *testing.T) {
+	elements, err := gs1.Parse("(01)09501101530003(17)260101(10)ABC")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := []gs1.Element{{AI: "01", Value: "09501101530003"}, {AI: "17", Value: "260101"}, {AI: "10", Value: "ABC"}}
+	if len(elements) != len(want) {
+		t.Fatalf("Parse() = %v, want %v", elements, want)
+	}
+	for i := range want {
+		if elements[i] != want[i] {
+			t.Errorf("Parse()[%d] = %v, want %v", i, elements[i], want[i])
+		}
+	}
+
+	// Leap day, end of month, last minute of a day and a returnable asset
+	// with its check digit
+	for _, hri := range []string{"(17)280229", "(15)270200", "(7003)2612312359", "(8003)09501101530003A1"} {
+		if _, err := gs1.Parse(hri); err != nil {
+			t.Errorf("Parse(%q) error = %v", hri, err)
+		}
+	}
+
+	invalid := []struct {
+		name string
+		hri  string
+	}{
+		{"Check digit", "(01)09501101530004"},
+		{"GRAI check digit", "(8003)09501101530004A1"},
+		{"Length", "(01)0950110153000"},
+		{"Date", "(17)261301"},
+		{"Day of month", "(17)260231"},
+		{"Not a leap year", "(17)270229"},
+		{"Hour", "(7003)2612312400"},
+		{"Minute", "(7003)2612311260"},
+		{"Unknown AI", "(23)123"},
+		{"Too long", "(10)ABCDEFGHIJKLMNOPQRSTU"},
+		{"Character set", "(10)AB#"},
+		{"No AI", "0950110153000"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := gs1.Parse(tt.hri); err == nil {
+				t.Errorf("Parse(%q) expected an error", tt.hri)
+			}
+		})
+	}
+}
+
+func TestGS1ElementString(t *testing.T) {
+	// Separators only after elements not of predefined length
+	elements := []gs1.Element{{AI: "10", Value: "ABC"}, {AI: "01", Value: "09501101530003"}, {AI: "21", Value: "12"}}
+	if got, want := gs1.ElementString(elements), "10ABC\x1D010950110153000321"+"12"; got != want {
+		t.Errorf("ElementString() = %q, want %q", got, want)
+	}
+
+	// Elements of predefined length first, the batch last needs no separator
+	req, err := NewGS1Request("(10)ABC(01)09501101530003(17)260101", ERR_CORR_M)
+	if err != nil {
+		t.Fatalf("NewGS1Request() error = %v", err)
+	}
+	if want := "01095011015300031726010110ABC"; req.input_data != want {
+		t.Errorf("input_data = %q, want %q", req.input_data, want)
+	}
+
+	qr := NewQRCode(req)
+	if qr.segments[0].Mode != modes.FNC1FirstPosition {
+		t.Errorf("Expected FNC1 first position header, got %v", qr.segments)
+	}
+}
+
+func TestFNC1(t *testing.T) {
+	if got := modes.EncodeApplicationIndicator("37").String(); got != "00100101" {
+		t.Errorf("EncodeApplicationIndicator(37) = %s", got)
+	}
+	if got := modes.EncodeApplicationIndicator("a").String(); got != "11000101" {
+		t.Errorf("EncodeApplicationIndicator(a) = %s", got)
+	}
+
+	// Group separators and literal '%' inside alphanumeric segments
+	qr := NewQRCode(QRRequest{
+		input_data:     "AB%C\x1DDEF",
+		err_corr_level: ERR_CORR_L,
+		fnc1:           &modes.Segment{Mode: modes.FNC1SecondPosition, Data: "37"},
+	})
+	want := []modes.Segment{
+		{Mode: modes.FNC1SecondPosition, Data: "37"},
+		{Mode: modes.AlphanumericMode, Data: "AB%%C%DEF"},
+	}
+	if len(qr.segments) != len(want) || qr.segments[0] != want[0] || qr.segments[1] != want[1] {
+		t.Errorf("segments = %v, want %v", qr.segments, want)
+	}
+	if got := modes.UnescapeFNC1Alphanumeric("AB%%C%DEF"); got != "AB%C\x1DDEF" {
+		t.Errorf("UnescapeFNC1Alphanumeric() = %q", got)
+	}
+}
+
+func TestGS1Payload(t *testing.T) {
+	// Only elements of predefined length: no group separator or bracket
+	req, err := NewGS1Request("(01)09501101530003(17)260101", ERR_CORR_M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeMatrix(NewQRCode(req).matrix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kind := payload.Classify(string(decoded.data)); kind != payload.KindText {
+		t.Errorf("Classify(%q) = %s, want %s", decoded.data, kind, payload.KindText)
+	}
+	p, err := payload.Parse(decoded.payloadText())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Kind != payload.KindGS1 || len(p.GS1) != 2 || p.GS1[1] != (gs1.Element{AI: "17", Value: "260101"}) {
+		t.Errorf("Parse(%q) = %s %v", decoded.payloadText(), p.Kind, p.GS1)
+	}
+
+	plain := NewQRCode(QRRequest{input_data: "0109501101530003", err_corr_level: ERR_CORR_M})
+	if decoded, _ := DecodeMatrix(plain.matrix); decoded.payloadText() != "0109501101530003" {
+		t.Errorf("payloadText() = %q without FNC1", decoded.payloadText())
+	}
+}
@@ -0,0 +1,169 @@
+package images
+
+import "math"
+
+// Alignment patterns are searched this many modules around their predicted
+// center
+const alignmentSearchRadius = 4
+
+// mapping maps module coordinates onto the image
+type mapping interface {
+	Apply(p Point) Point
+}
+
+// piecewiseTransform maps each cell of the grid of alignment pattern centers
+// with its own perspective transform, so that distortion does not build up
+// across large symbols. Modules outside of the grid use the nearest cell.
+type piecewiseTransform struct {
+	// Grid lines in module coordinates, the centers of the alignment patterns
+	lines []float64
+	// cells[i][j] maps rows lines[i] to lines[i+1] and columns lines[j] to lines[j+1]
+	cells [][]PerspectiveTransform
+}
+
+func (t piecewiseTransform) Apply(p Point) Point {
+	return t.cells[t.cell(p.Y)][t.cell(p.X)].Apply(p)
+}
+
+func (t piecewiseTransform) cell(v float64) int {
+	i := 0
+	for i < len(t.lines)-2 && v >= t.lines[i+1] {
+		i++
+	}
+	return i
+}
+
+// alignedTransform locates the alignment patterns of the symbol and builds a
+// transform per cell between their centers. The patterns that overlap the
+// finder patterns do not exist, their corners of the grid are mapped with
+// the finder pattern transform, which is accurate around them. Patterns that
+// are not found stay at their predicted position.
+func (s *Symbol) alignedTransform(b *BitImage, moduleSize float64) piecewiseTransform {
+	global := s.Transform()
+	n := len(s.Alignment)
+	t := piecewiseTransform{lines: make([]float64, n)}
+	for i, c := range s.Alignment {
+		t.lines[i] = float64(c) + 0.5
+	}
+
+	// Row by row, each pattern is predicted from the three found above and
+	// to the left of it, completing the parallelogram
+	centers := make([][]Point, n)
+	for i := range n {
+		centers[i] = make([]Point, n)
+		for j := range n {
+			module := Point{X: t.lines[j], Y: t.lines[i]}
+			if (i == 0 && j == 0) || (i == 0 && j == n-1) || (i == n-1 && j == 0) {
+				centers[i][j] = global.Apply(module)
+				continue
+			}
+			predicted := global.Apply(module)
+			if i > 0 && j > 0 {
+				predicted = Point{
+					X: centers[i-1][j].X + centers[i][j-1].X - centers[i-1][j-1].X,
+					Y: centers[i-1][j].Y + centers[i][j-1].Y - centers[i-1][j-1].Y,
+				}
+			}
+			centers[i][j] = predicted
+			if found, ok := b.findAlignmentPattern(predicted, moduleSize); ok {
+				centers[i][j] = found
+			}
+		}
+	}
+
+	t.cells = make([][]PerspectiveTransform, n-1)
+	for i := range n - 1 {
+		t.cells[i] = make([]PerspectiveTransform, n-1)
+		for j := range n - 1 {
+			t.cells[i][j] = QuadrilateralToQuadrilateral(
+				[4]Point{
+					{X: t.lines[j], Y: t.lines[i]}, {X: t.lines[j+1], Y: t.lines[i]},
+					{X: t.lines[j+1], Y: t.lines[i+1]}, {X: t.lines[j], Y: t.lines[i+1]},
+				},
+				[4]Point{centers[i][j], centers[i][j+1], centers[i+1][j+1], centers[i+1][j]},
+			)
+		}
+	}
+	return t
+}
+
+// findAlignmentPattern returns the center of the alignment pattern closest to
+// predicted: a dark module inside a light ring inside a dark ring, which any
+// line through the center crosses as dark, light, dark, light, dark with the
+// inner three runs of about the same length
+func (b *BitImage) findAlignmentPattern(predicted Point, moduleSize float64) (Point, bool) {
+	radius := alignmentSearchRadius * moduleSize
+	x0, x1 := int(predicted.X-radius), int(predicted.X+radius)
+	y0, y1 := int(predicted.Y-radius), int(predicted.Y+radius)
+	maxRun := int(math.Ceil(2 * moduleSize))
+
+	var best Point
+	bestDistance := math.Inf(1)
+	for y := max(y0, 0); y <= min(y1, b.Height-1); y++ {
+		row := line{b: b, at: y}
+		for x := max(x0, 0); x <= min(x1, b.Width-1); x++ {
+			// Only the first pixel of each dark run
+			if !row.dark(x) || row.dark(x-1) {
+				continue
+			}
+			cx, ok := row.alignmentCheck(x, maxRun)
+			if !ok {
+				continue
+			}
+			cy, ok := line{b: b, vertical: true, at: int(cx)}.alignmentCheck(y, maxRun)
+			if !ok {
+				continue
+			}
+			// Back along the row, through the vertical center
+			cx, ok = line{b: b, at: int(cy)}.alignmentCheck(int(cx), maxRun)
+			if !ok {
+				continue
+			}
+			p := Point{X: cx, Y: cy}
+			if d := distance(p, predicted); d < bestDistance {
+				best, bestDistance = p, d
+			}
+		}
+	}
+	return best, bestDistance <= radius
+}
+
+// alignmentCheck checks that the dark run of l through i is the center of an
+// alignment pattern.
+// returns the center of the run along l
+func (l line) alignmentCheck(i int, maxRun int) (float64, bool) {
+	if !l.dark(i) {
+		return 0, false
+	}
+	start, end := i, i
+	for start > 0 && l.dark(start-1) {
+		start--
+	}
+	for end < l.len()-1 && l.dark(end+1) {
+		end++
+	}
+	center := end - start + 1
+
+	run := func(from, step int, dark bool) int {
+		n := 0
+		for p := from; p >= 0 && p < l.len() && l.dark(p) == dark && n <= maxRun; p += step {
+			n++
+		}
+		return n
+	}
+	before := run(start-1, -1, false)
+	after := run(end+1, 1, false)
+	// The dark ring, only its start matters as data modules may follow
+	if run(start-1-before, -1, true) == 0 || run(end+1+after, 1, true) == 0 {
+		return 0, false
+	}
+
+	runs := []int{before, center, after}
+	mean := float64(before+center+after) / 3
+	for _, r := range runs {
+		if r == 0 || r > maxRun || math.Abs(float64(r)-mean) > mean/2+1 {
+			return 0, false
+		}
+	}
+	return float64(start) + float64(center)/2, true
+}
@@ -0,0 +1,271 @@
+package images
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+)
+
+// Channel selects how colors are converted to grey levels. A single channel
+// gives more contrast to codes printed in color, e.g. the green channel for
+// red ink.
+type Channel string
+
+const (
+	ChannelLuminance Channel = "luminance"
+	ChannelRed       Channel = "red"
+	ChannelGreen     Channel = "green"
+	ChannelBlue      Channel = "blue"
+)
+
+// Binarization is the method choosing between dark and light for each pixel
+type Binarization string
+
+const (
+	// One threshold for the whole image, maximizing the variance between the
+	// dark and light classes of the histogram
+	BinarizationOtsu Binarization = "otsu"
+	// Thresholds from the mean of 8 x 8 pixel blocks and their neighbours,
+	// low contrast blocks taking the level of their surroundings
+	BinarizationHybrid Binarization = "hybrid"
+	// Thresholds from the local mean m and standard deviation s:
+	// m * (1 + k * (s / 128 - 1))
+	BinarizationSauvola Binarization = "sauvola"
+)
+
+const (
+	hybridBlockSize = 8
+	// Blocks whose darkest and lightest pixels are closer are taken as flat
+	hybridMinDynamicRange = 24
+	// Thresholds average the block means 2 blocks around
+	hybridNeighbourhood = 2
+
+	sauvolaK = 0.34
+	// Window side in pixels, or the image size over sauvolaWindowFraction if
+	// larger
+	sauvolaMinWindow      = 15
+	sauvolaWindowFraction = 8
+)
+
+// Binarizer converts images to bit images. The zero value uses luminance and
+// hybrid binarization.
+type Binarizer struct {
+	Channel      Channel
+	Binarization Binarization
+}
+
+// Binarize converts img to a bit image
+func (bz Binarizer) Binarize(img image.Image) (*BitImage, error) {
+	gray, err := Grayscale(img, bz.Channel)
+	if err != nil {
+		return nil, err
+	}
+	switch bz.Binarization {
+	case BinarizationOtsu:
+		return Threshold(gray, OtsuThreshold(gray)), nil
+	case BinarizationHybrid, "":
+		return HybridBinarize(gray), nil
+	case BinarizationSauvola:
+		return SauvolaBinarize(gray), nil
+	default:
+		return nil, fmt.Errorf("images: unknown binarization %q", bz.Binarization)
+	}
+}
+
+// Grayscale converts img to grey levels from the given channel, compositing
+// transparent pixels over a white background. The result starts at (0, 0).
+func Grayscale(img image.Image, channel Channel) (*image.Gray, error) {
+	var level func(r, g, b uint32) uint32
+	switch channel {
+	case ChannelLuminance, "":
+		// Same weights as color.GrayModel
+		level = func(r, g, b uint32) uint32 { return (19595*r + 38470*g + 7471*b + 1<<15) >> 16 }
+	case ChannelRed:
+		level = func(r, g, b uint32) uint32 { return r }
+	case ChannelGreen:
+		level = func(r, g, b uint32) uint32 { return g }
+	case ChannelBlue:
+		level = func(r, g, b uint32) uint32 { return b }
+	default:
+		return nil, fmt.Errorf("images: unknown channel %q", channel)
+	}
+
+	bounds := img.Bounds()
+	gray := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := range bounds.Dy() {
+		for x := range bounds.Dx() {
+			r, g, b, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			// The channels are already premultiplied by alpha
+			v := level(r, g, b) + 0xFFFF - a
+			gray.SetGray(x, y, color.Gray{Y: uint8(v >> 8)})
+		}
+	}
+	return gray, nil
+}
+
+// OtsuThreshold returns the threshold for Threshold that maximizes the
+// variance between the dark and light pixels of gray
+func OtsuThreshold(gray *image.Gray) uint8 {
+	var histogram [256]int
+	for y := range gray.Bounds().Dy() {
+		for _, v := range gray.Pix[y*gray.Stride : y*gray.Stride+gray.Bounds().Dx()] {
+			histogram[v]++
+		}
+	}
+	total, sum := 0, 0.0
+	for v, n := range histogram {
+		total += n
+		sum += float64(v * n)
+	}
+
+	// Dark pixels are the levels below t
+	best, bestVariance := 0, -1.0
+	darkCount, darkSum := 0, 0.0
+	for t := 1; t < 256; t++ {
+		darkCount += histogram[t-1]
+		darkSum += float64((t - 1) * histogram[t-1])
+		lightCount := total - darkCount
+		if darkCount == 0 || lightCount == 0 {
+			continue
+		}
+		darkMean := darkSum / float64(darkCount)
+		lightMean := (sum - darkSum) / float64(lightCount)
+		variance := float64(darkCount) * float64(lightCount) * (darkMean - lightMean) * (darkMean - lightMean)
+		if variance > bestVariance {
+			best, bestVariance = t, variance
+		}
+	}
+	if bestVariance < 0 {
+		// A single grey level, take it as light
+		return 0
+	}
+	return uint8(best)
+}
+
+// HybridBinarize thresholds every 8 x 8 block of gray with the average of the
+// means of the blocks around it. Images smaller than the neighbourhood get an
+// Otsu threshold instead.
+func HybridBinarize(gray *image.Gray) *BitImage {
+	width, height := gray.Bounds().Dx(), gray.Bounds().Dy()
+	blocksX := (width + hybridBlockSize - 1) / hybridBlockSize
+	blocksY := (height + hybridBlockSize - 1) / hybridBlockSize
+	if blocksX < 2*hybridNeighbourhood+1 || blocksY < 2*hybridNeighbourhood+1 {
+		return Threshold(gray, OtsuThreshold(gray))
+	}
+
+	// Step 1 - Block means. Flat blocks are probably background, or part of
+	// a large dark area if their surroundings are darker.
+	means := make([][]int, blocksY)
+	for by := range blocksY {
+		means[by] = make([]int, blocksX)
+		for bx := range blocksX {
+			sum, n, lo, hi := 0, 0, 255, 0
+			for y := by * hybridBlockSize; y < min((by+1)*hybridBlockSize, height); y++ {
+				for x := bx * hybridBlockSize; x < min((bx+1)*hybridBlockSize, width); x++ {
+					v := int(gray.GrayAt(x, y).Y)
+					sum += v
+					n++
+					lo, hi = min(lo, v), max(hi, v)
+				}
+			}
+			mean := sum / n
+			if hi-lo <= hybridMinDynamicRange {
+				mean = lo / 2
+				if by > 0 && bx > 0 {
+					neighbours := (means[by-1][bx] + 2*means[by][bx-1] + means[by-1][bx-1]) / 4
+					if lo < neighbours {
+						mean = neighbours
+					}
+				}
+			}
+			means[by][bx] = mean
+		}
+	}
+
+	// Step 2 - Threshold each block with the average of its neighbourhood
+	b := NewBitImage(width, height)
+	for by := range blocksY {
+		for bx := range blocksX {
+			cy := min(max(by, hybridNeighbourhood), blocksY-hybridNeighbourhood-1)
+			cx := min(max(bx, hybridNeighbourhood), blocksX-hybridNeighbourhood-1)
+			sum := 0
+			for y := cy - hybridNeighbourhood; y <= cy+hybridNeighbourhood; y++ {
+				for x := cx - hybridNeighbourhood; x <= cx+hybridNeighbourhood; x++ {
+					sum += means[y][x]
+				}
+			}
+			side := 2*hybridNeighbourhood + 1
+			threshold := sum / (side * side)
+			for y := by * hybridBlockSize; y < min((by+1)*hybridBlockSize, height); y++ {
+				for x := bx * hybridBlockSize; x < min((bx+1)*hybridBlockSize, width); x++ {
+					b.Set(x, y, int(gray.GrayAt(x, y).Y) <= threshold)
+				}
+			}
+		}
+	}
+	return b
+}
+
+// SauvolaBinarize thresholds each pixel of gray from the mean and standard
+// deviation of the window around it, computed with integral images
+func SauvolaBinarize(gray *image.Gray) *BitImage {
+	width, height := gray.Bounds().Dx(), gray.Bounds().Dy()
+	window := max(sauvolaMinWindow, min(width, height)/sauvolaWindowFraction)
+	half := window / 2
+
+	// sums[y][x] holds the sum of the pixels above and left of (x, y)
+	sums := make([][]float64, height+1)
+	squares := make([][]float64, height+1)
+	sums[0] = make([]float64, width+1)
+	squares[0] = make([]float64, width+1)
+	for y := range height {
+		sums[y+1] = make([]float64, width+1)
+		squares[y+1] = make([]float64, width+1)
+		rowSum, rowSquares := 0.0, 0.0
+		for x := range width {
+			v := float64(gray.GrayAt(x, y).Y)
+			rowSum += v
+			rowSquares += v * v
+			sums[y+1][x+1] = sums[y][x+1] + rowSum
+			squares[y+1][x+1] = squares[y][x+1] + rowSquares
+		}
+	}
+	area := func(table [][]float64, x0, y0, x1, y1 int) float64 {
+		return table[y1][x1] - table[y0][x1] - table[y1][x0] + table[y0][x0]
+	}
+
+	b := NewBitImage(width, height)
+	for y := range height {
+		y0, y1 := max(0, y-half), min(height, y+half+1)
+		for x := range width {
+			x0, x1 := max(0, x-half), min(width, x+half+1)
+			n := float64((x1 - x0) * (y1 - y0))
+			mean := area(sums, x0, y0, x1, y1) / n
+			variance := max(0, area(squares, x0, y0, x1, y1)/n-mean*mean)
+			threshold := mean * (1 + sauvolaK*(math.Sqrt(variance)/128-1))
+			b.Set(x, y, float64(gray.GrayAt(x, y).Y) < threshold)
+		}
+	}
+	return b
+}
+
+// WritePNG saves b as a black and white PNG image, to check binarization
+func (b *BitImage) WritePNG(path string) error {
+	img := image.NewGray(image.Rect(0, 0, b.Width, b.Height))
+	for y := range b.Height {
+		for x := range b.Width {
+			if !b.Dark(x, y) {
+				img.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return png.Encode(file, img)
+}
@@ -0,0 +1,48 @@
+package images
+
+import "image"
+
+// BitImage is a binarized image where each pixel is either dark or light
+type BitImage struct {
+	Width, Height int
+	bits          []bool
+}
+
+func NewBitImage(width, height int) *BitImage {
+	return &BitImage{Width: width, Height: height, bits: make([]bool, width*height)}
+}
+
+// Dark reports whether the pixel at (x, y) is dark. Pixels outside of the
+// image are light, like a quiet zone.
+func (b *BitImage) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= b.Width || y >= b.Height {
+		return false
+	}
+	return b.bits[y*b.Width+x]
+}
+
+func (b *BitImage) Set(x, y int, dark bool) {
+	b.bits[y*b.Width+x] = dark
+}
+
+// Inverted returns a copy of b with dark and light swapped, to read symbols
+// with light modules on a dark background
+func (b *BitImage) Inverted() *BitImage {
+	inverted := NewBitImage(b.Width, b.Height)
+	for i, dark := range b.bits {
+		inverted.bits[i] = !dark
+	}
+	return inverted
+}
+
+// Threshold binarizes gray, pixels darker than t being dark
+func Threshold(gray *image.Gray, t uint8) *BitImage {
+	bounds := gray.Bounds()
+	b := NewBitImage(bounds.Dx(), bounds.Dy())
+	for y := range b.Height {
+		for x := range b.Width {
+			b.Set(x, y, gray.GrayAt(bounds.Min.X+x, bounds.Min.Y+y).Y < t)
+		}
+	}
+	return b
+}
@@ -0,0 +1,414 @@
+package images
+
+import (
+	"cmp"
+	"errors"
+	"image"
+	"math"
+	"os"
+	"slices"
+)
+
+// Finder patterns seen by fewer scan lines are taken as noise
+const minFinderPatternCount = 2
+
+// Only the most confirmed candidates are combined into symbols
+const maxFinderPatternCandidates = 10
+
+// LocateAll combines more candidates, enough for a sheet of labels
+const maxFinderPatternCandidatesAll = 100
+
+// LocateAll rejects triples whose timing patterns match worse
+const minTimingMatch = 0.75
+
+// Symbol is a QR Code located in an image by its finder patterns
+type Symbol struct {
+	TopLeft, TopRight, BottomLeft FinderPattern
+	// Modules per side, estimated from the distance between finder patterns
+	// and checked against the timing patterns
+	Dimension int
+	// Pixels per module, measured along the finder patterns
+	ModuleSize float64
+	// Row and column of the alignment pattern centers of the version (see
+	// alignment_patterns_table), none for version 1. Sample uses them to
+	// correct distortion.
+	Alignment []int
+}
+
+// Load decodes a PNG, JPEG or GIF image file
+func Load(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	return img, err
+}
+
+// Locate finds the three finder patterns of a symbol in b: among the
+// triples of candidates that form a right isosceles triangle and whose module
+// sizes agree, the one whose least confirmed pattern has the highest count,
+// then the one closest to the ideal triangle.
+func Locate(b *BitImage) (*Symbol, error) {
+	var candidates []FinderPattern
+	for _, c := range FindFinderPatterns(b) {
+		if c.Count >= minFinderPatternCount && len(candidates) < maxFinderPatternCandidates {
+			candidates = append(candidates, c)
+		}
+	}
+	if len(candidates) < 3 {
+		return nil, errors.New("images: fewer than 3 finder patterns found")
+	}
+
+	var best *Symbol
+	bestCount, bestScore := 0, math.Inf(1)
+	for i := range candidates {
+		for j := i + 1; j < len(candidates); j++ {
+			for k := j + 1; k < len(candidates); k++ {
+				s, score, ok := newSymbol(b, candidates[i], candidates[j], candidates[k])
+				if !ok {
+					continue
+				}
+				// Candidates are sorted by decreasing count
+				count := candidates[k].Count
+				if count > bestCount || (count == bestCount && score < bestScore) {
+					best, bestCount, bestScore = s, count, score
+				}
+			}
+		}
+	}
+	if best == nil {
+		return nil, errors.New("images: no 3 finder patterns form a symbol")
+	}
+	return best, nil
+}
+
+// LocateAll finds every symbol in b. Triples of candidates are taken in
+// order of how well their timing patterns match, then as in Locate, each
+// finder pattern belonging to one symbol at most. Finder patterns of
+// neighbouring symbols can form triangles too, but those surround finder
+// patterns as confirmed as theirs and the timing patterns of the real
+// symbols match better. The symbols are sorted by their upper left corner,
+// top to bottom then left to right.
+func LocateAll(b *BitImage) []*Symbol {
+	var candidates []FinderPattern
+	for _, c := range FindFinderPatterns(b) {
+		if c.Count >= minFinderPatternCount && len(candidates) < maxFinderPatternCandidatesAll {
+			candidates = append(candidates, c)
+		}
+	}
+
+	type triple struct {
+		symbol       *Symbol
+		finders      [3]int
+		match, score float64
+	}
+	var triples []triple
+	for i := range candidates {
+		for j := i + 1; j < len(candidates); j++ {
+			for k := j + 1; k < len(candidates); k++ {
+				s, score, ok := orderFinderPatterns(candidates[i], candidates[j], candidates[k])
+				if !ok {
+					continue
+				}
+				// The finder patterns of a symbol do not surround others as
+				// confirmed as they are, only noise in the data modules
+				inside := slices.ContainsFunc(candidates, func(c FinderPattern) bool {
+					return c.Count >= candidates[k].Count && c != candidates[i] && c != candidates[j] && c != candidates[k] && s.contains(c.Center)
+				})
+				if inside || !s.measure(b) {
+					continue
+				}
+				match := s.timingMatch(b, s.Transform())
+				if match < minTimingMatch {
+					continue
+				}
+				triples = append(triples, triple{symbol: s, finders: [3]int{i, j, k}, match: match, score: score})
+			}
+		}
+	}
+	// Candidates are sorted by decreasing count, k is the least confirmed
+	slices.SortStableFunc(triples, func(a, b triple) int {
+		return cmp.Or(
+			cmp.Compare(b.match, a.match),
+			cmp.Compare(candidates[b.finders[2]].Count, candidates[a.finders[2]].Count),
+			cmp.Compare(a.score, b.score),
+		)
+	})
+
+	used := make([]bool, len(candidates))
+	var symbols []*Symbol
+	for _, t := range triples {
+		if used[t.finders[0]] || used[t.finders[1]] || used[t.finders[2]] {
+			continue
+		}
+		for _, f := range t.finders {
+			used[f] = true
+		}
+		symbols = append(symbols, t.symbol)
+	}
+	slices.SortFunc(symbols, func(a, b *Symbol) int {
+		ca, cb := a.Corners()[0], b.Corners()[0]
+		return cmp.Or(cmp.Compare(ca.Y, cb.Y), cmp.Compare(ca.X, cb.X))
+	})
+	return symbols
+}
+
+// newSymbol orders 3 finder patterns and estimates the dimension of the
+// symbol they would form.
+// returns how far they are from a right isosceles triangle, 0 being a perfect fit
+func newSymbol(b *BitImage, p1, p2, p3 FinderPattern) (*Symbol, float64, bool) {
+	s, score, ok := orderFinderPatterns(p1, p2, p3)
+	if !ok || !s.measure(b) {
+		return nil, 0, false
+	}
+	return s, score, true
+}
+
+// orderFinderPatterns finds the upper left, upper right and lower left
+// finder patterns among p1, p2 and p3, if their module sizes agree and they
+// are close enough to a right isosceles triangle.
+// returns how far they are from that triangle, 0 being a perfect fit
+func orderFinderPatterns(p1, p2, p3 FinderPattern) (*Symbol, float64, bool) {
+	// The upper left finder pattern is opposite the longest side
+	d12, d13, d23 := distance(p1.Center, p2.Center), distance(p1.Center, p3.Center), distance(p2.Center, p3.Center)
+	topLeft, a, c := p1, p2, p3
+	hypotenuse := d23
+	if d13 > hypotenuse && d13 >= d12 {
+		topLeft, a, c, hypotenuse = p2, p1, p3, d13
+	} else if d12 > hypotenuse {
+		topLeft, a, c, hypotenuse = p3, p1, p2, d12
+	}
+
+	// With y pointing down, going from the upper right to the lower left
+	// finder pattern turns clockwise around the upper left one
+	ax, ay := a.Center.X-topLeft.Center.X, a.Center.Y-topLeft.Center.Y
+	cx, cy := c.Center.X-topLeft.Center.X, c.Center.Y-topLeft.Center.Y
+	if ax*cy-ay*cx < 0 {
+		a, c = c, a
+	}
+	s := &Symbol{TopLeft: topLeft, TopRight: a, BottomLeft: c}
+
+	// Module sizes must agree
+	sizes := []float64{topLeft.ModuleSize, a.ModuleSize, c.ModuleSize}
+	if max(sizes[0], sizes[1], sizes[2]) > 1.5*min(sizes[0], sizes[1], sizes[2]) {
+		return nil, 0, false
+	}
+
+	top, left := distance(topLeft.Center, a.Center), distance(topLeft.Center, c.Center)
+	leg := (top + left) / 2
+	score := math.Abs(top-left)/leg + math.Abs(hypotenuse/math.Sqrt2-leg)/leg
+	if score > 0.5 {
+		return nil, 0, false
+	}
+	return s, score, true
+}
+
+// measure sets the module size and dimension of the symbol from its finder
+// patterns, false if they do not measure up to a symbol
+func (s *Symbol) measure(b *BitImage) bool {
+	leg := (distance(s.TopLeft.Center, s.TopRight.Center) + distance(s.TopLeft.Center, s.BottomLeft.Center)) / 2
+
+	// Finder pattern centers are 3.5 modules away from the edges
+	s.ModuleSize = s.moduleSize(b)
+	if s.ModuleSize == 0 {
+		return false
+	}
+	estimate := 17 + 4*int(math.Round((leg/s.ModuleSize+7-17)/4))
+
+	// Module sizes of a few pixels are too coarse to tell neighbouring
+	// versions apart, the timing patterns alternate only at the right dimension
+	bestDimension, bestMatch := 0, 0.0
+	for d := estimate - 8; d <= estimate+8; d += 4 {
+		if d < 21 || d > 177 {
+			continue
+		}
+		s.Dimension = d
+		if match := s.timingMatch(b, s.Transform()); match > bestMatch {
+			bestDimension, bestMatch = d, match
+		}
+	}
+	if bestDimension == 0 {
+		return false
+	}
+	s.Dimension = bestDimension
+	return true
+}
+
+// contains reports whether p is inside the parallelogram of the finder
+// pattern centers
+func (s *Symbol) contains(p Point) bool {
+	ax, ay := s.TopRight.Center.X-s.TopLeft.Center.X, s.TopRight.Center.Y-s.TopLeft.Center.Y
+	cx, cy := s.BottomLeft.Center.X-s.TopLeft.Center.X, s.BottomLeft.Center.Y-s.TopLeft.Center.Y
+	px, py := p.X-s.TopLeft.Center.X, p.Y-s.TopLeft.Center.Y
+	det := ax*cy - ay*cx
+	u, v := (px*cy-py*cx)/det, (ax*py-ay*px)/det
+	return u >= 0 && u <= 1 && v >= 0 && v <= 1
+}
+
+// timingMatch returns the fraction of the timing pattern modules sampled with
+// the expected color through t, between the separators of the finder patterns
+func (s *Symbol) timingMatch(b *BitImage, t mapping) float64 {
+	matches, total := 0, 0
+	for i := 8; i < s.Dimension-8; i++ {
+		dark := i%2 == 0
+		for _, p := range []Point{{X: float64(i) + 0.5, Y: 6.5}, {X: 6.5, Y: float64(i) + 0.5}} {
+			p = t.Apply(p)
+			if b.Dark(int(math.Floor(p.X)), int(math.Floor(p.Y))) == dark {
+				matches++
+			}
+			total++
+		}
+	}
+	return float64(matches) / float64(total)
+}
+
+// moduleSize measures the finder patterns along the lines joining them, which
+// unlike the row and column scans is not stretched when the symbol is rotated
+func (s *Symbol) moduleSize(b *BitImage) float64 {
+	var total float64
+	var n int
+	for _, pair := range [][2]FinderPattern{
+		{s.TopLeft, s.TopRight}, {s.TopRight, s.TopLeft},
+		{s.TopLeft, s.BottomLeft}, {s.BottomLeft, s.TopLeft},
+	} {
+		if size := b.finderWidthToward(pair[0], pair[1].Center); size > 0 {
+			total += size / 7
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return total / float64(n)
+}
+
+// finderWidthToward returns the width of finder pattern p along the line to
+// target, or 0 if its runs are not found
+func (b *BitImage) finderWidthToward(p FinderPattern, target Point) float64 {
+	length := distance(p.Center, target)
+	dx, dy := (target.X-p.Center.X)/length, (target.Y-p.Center.Y)/length
+	forward := b.finderRadius(p, dx, dy)
+	backward := b.finderRadius(p, -dx, -dy)
+	if forward == 0 || backward == 0 {
+		return 0
+	}
+	return forward + backward
+}
+
+// finderRadius walks from the center of p in the direction (dx, dy) across
+// the center square, the light ring and the dark ring, 3.5 modules.
+// returns the distance walked
+func (b *BitImage) finderRadius(p FinderPattern, dx, dy float64) float64 {
+	const step = 0.5
+	limit := 6 * p.ModuleSize
+	dark, transitions := true, 0
+	for t := 0.0; t < limit; t += step {
+		x, y := p.Center.X+t*dx, p.Center.Y+t*dy
+		if b.Dark(int(math.Floor(x)), int(math.Floor(y))) != dark {
+			dark = !dark
+			transitions++
+			if transitions == 3 {
+				return t
+			}
+		}
+	}
+	return 0
+}
+
+// Transform maps module coordinates onto the image, the center of the upper
+// left module being (0.5, 0.5). The lower right corner is extrapolated from
+// the other three.
+func (s *Symbol) Transform() PerspectiveTransform {
+	d := float64(s.Dimension)
+	tl, tr, bl := s.TopLeft.Center, s.TopRight.Center, s.BottomLeft.Center
+	br := Point{X: tr.X + bl.X - tl.X, Y: tr.Y + bl.Y - tl.Y}
+	return QuadrilateralToQuadrilateral(
+		[4]Point{{3.5, 3.5}, {d - 3.5, 3.5}, {d - 3.5, d - 3.5}, {3.5, d - 3.5}},
+		[4]Point{tl, tr, br, bl},
+	)
+}
+
+// Corners returns the corners of the symbol in the image, clockwise from the
+// upper left one
+func (s *Symbol) Corners() [4]Point {
+	t := s.Transform()
+	d := float64(s.Dimension)
+	return [4]Point{t.Apply(Point{0, 0}), t.Apply(Point{d, 0}), t.Apply(Point{d, d}), t.Apply(Point{0, d})}
+}
+
+// mapping returns the transform of module coordinates onto b that Sample
+// uses: through the alignment pattern grid unless that makes the timing
+// patterns worse
+func (s *Symbol) mapping(b *BitImage) mapping {
+	var t mapping = s.Transform()
+	if len(s.Alignment) > 0 {
+		aligned := s.alignedTransform(b, s.ModuleSize)
+		if s.timingMatch(b, aligned) >= s.timingMatch(b, t) {
+			t = aligned
+		}
+	}
+	return t
+}
+
+// ModuleCenters returns where the center of every module is in b, as
+// sampled by Sample
+func (s *Symbol) ModuleCenters(b *BitImage) [][]Point {
+	t := s.mapping(b)
+	centers := make([][]Point, s.Dimension)
+	for i := range centers {
+		centers[i] = make([]Point, s.Dimension)
+		for j := range centers[i] {
+			centers[i][j] = t.Apply(Point{X: float64(j) + 0.5, Y: float64(i) + 0.5})
+		}
+	}
+	return centers
+}
+
+// Sample reads the pixel at the center of every module of the symbol,
+// true being dark. With alignment patterns, modules are mapped through the
+// alignment pattern grid unless that makes the timing patterns worse.
+func (s *Symbol) Sample(b *BitImage) ([][]bool, error) {
+	grid, _, err := s.SampleConfidence(b)
+	return grid, err
+}
+
+// SampleConfidence samples like Sample, and also returns how sure each module
+// is: the fraction of a 3 x 3 pattern of pixels spread over the middle half
+// of the module that agree with its center. Worn or grey modules binarize to
+// mixed pixels and get low confidence.
+func (s *Symbol) SampleConfidence(b *BitImage) ([][]bool, [][]float64, error) {
+	t := s.mapping(b)
+
+	dark := func(module Point) (bool, bool) {
+		p := t.Apply(module)
+		x, y := int(math.Floor(p.X)), int(math.Floor(p.Y))
+		return b.Dark(x, y), x >= 0 && y >= 0 && x < b.Width && y < b.Height
+	}
+	grid := make([][]bool, s.Dimension)
+	confidence := make([][]float64, s.Dimension)
+	for i := range grid {
+		grid[i] = make([]bool, s.Dimension)
+		confidence[i] = make([]float64, s.Dimension)
+		for j := range grid[i] {
+			center, ok := dark(Point{X: float64(j) + 0.5, Y: float64(i) + 0.5})
+			if !ok {
+				return nil, nil, errors.New("images: the symbol extends beyond the image")
+			}
+			grid[i][j] = center
+
+			agree := 0
+			for _, dy := range []float64{0.25, 0.5, 0.75} {
+				for _, dx := range []float64{0.25, 0.5, 0.75} {
+					if d, _ := dark(Point{X: float64(j) + dx, Y: float64(i) + dy}); d == center {
+						agree++
+					}
+				}
+			}
+			confidence[i][j] = float64(agree) / 9
+		}
+	}
+	return grid, confidence, nil
+}
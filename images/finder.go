@@ -0,0 +1,215 @@
+package images
+
+import (
+	"math"
+	"slices"
+)
+
+// FinderPattern is a finder pattern candidate: the center of its 3 x 3 dark
+// square and the estimated module size, both in pixels. Count is the number
+// of scan lines that confirmed it.
+type FinderPattern struct {
+	Center     Point
+	ModuleSize float64
+	Count      int
+}
+
+// finderRatio checks the 5 runs dark, light, dark, light, dark for the 1:1:3:1:1
+// ratio of a finder pattern, with half a module of tolerance per module.
+// returns the module size
+func finderRatio(runs [5]int) (float64, bool) {
+	total := 0
+	for _, r := range runs {
+		if r == 0 {
+			return 0, false
+		}
+		total += r
+	}
+	if total < 7 {
+		return 0, false
+	}
+	moduleSize := float64(total) / 7
+	maxVariance := moduleSize / 2
+	for i, r := range runs {
+		want, variance := moduleSize, maxVariance
+		if i == 2 {
+			want, variance = 3*moduleSize, 3*maxVariance
+		}
+		if math.Abs(want-float64(r)) >= variance {
+			return 0, false
+		}
+	}
+	return moduleSize, true
+}
+
+// line gives access to a row (horizontal) or column (vertical) of b, so the
+// same scan works in both directions
+type line struct {
+	b        *BitImage
+	vertical bool
+	// index of the row or column
+	at int
+}
+
+func (l line) len() int {
+	if l.vertical {
+		return l.b.Height
+	}
+	return l.b.Width
+}
+
+func (l line) dark(i int) bool {
+	if l.vertical {
+		return l.b.Dark(l.at, i)
+	}
+	return l.b.Dark(i, l.at)
+}
+
+// crossCheck counts the runs of a finder pattern through position i of l,
+// which must be in the center square. maxRun bounds each run to stay away
+// from unrelated dark areas.
+// returns the center along l and the module size
+func (l line) crossCheck(i int, maxRun int) (float64, float64, bool) {
+	if !l.dark(i) {
+		return 0, 0, false
+	}
+	var runs [5]int
+
+	// Center square, then the light and dark rings before and after
+	start := i
+	for start > 0 && l.dark(start-1) {
+		start--
+	}
+	end := i
+	for end < l.len()-1 && l.dark(end+1) {
+		end++
+	}
+	runs[2] = end - start + 1
+
+	count := func(from, step int, dark bool) (int, int) {
+		n := 0
+		for p := from; p >= 0 && p < l.len() && l.dark(p) == dark && n <= maxRun; p += step {
+			n++
+		}
+		return n, from + n*step
+	}
+	var next int
+	runs[1], next = count(start-1, -1, false)
+	runs[0], _ = count(next, -1, true)
+	runs[3], next = count(end+1, 1, false)
+	runs[4], _ = count(next, 1, true)
+	for _, r := range runs {
+		if r > maxRun {
+			return 0, 0, false
+		}
+	}
+
+	moduleSize, ok := finderRatio(runs)
+	if !ok {
+		return 0, 0, false
+	}
+	return float64(start) + float64(runs[2])/2, moduleSize, true
+}
+
+// FindFinderPatterns scans every row and column of b for the 1:1:3:1:1 ratio
+// of the finder patterns. Each hit is cross-checked in the other direction,
+// then again in the first one, and hits close to each other are merged.
+// The candidates are sorted by decreasing count.
+func FindFinderPatterns(b *BitImage) []FinderPattern {
+	var candidates []FinderPattern
+	for _, vertical := range []bool{false, true} {
+		lines := b.Height
+		if vertical {
+			lines = b.Width
+		}
+		for at := range lines {
+			scanned := line{b: b, vertical: vertical, at: at}
+			for _, hit := range scanned.scan() {
+				if c, ok := confirm(scanned, hit); ok {
+					candidates = merge(candidates, c)
+				}
+			}
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b FinderPattern) int {
+		return b.Count - a.Count
+	})
+	return candidates
+}
+
+// scan returns the center (along l) and module size of every run of 5 that
+// fits the finder pattern ratio
+func (l line) scan() [][2]float64 {
+	// Run-length encoding, starting with a light run (possibly empty)
+	var runs []int
+	var starts []int
+	dark, n := false, 0
+	for i := range l.len() {
+		if l.dark(i) != dark {
+			runs = append(runs, n)
+			starts = append(starts, i-n)
+			dark, n = !dark, 0
+		}
+		n++
+	}
+	runs = append(runs, n)
+	starts = append(starts, l.len()-n)
+
+	var hits [][2]float64
+	// Odd runs are dark
+	for i := 1; i+4 < len(runs); i += 2 {
+		moduleSize, ok := finderRatio([5]int(runs[i : i+5]))
+		if ok {
+			hits = append(hits, [2]float64{float64(starts[i+2]) + float64(runs[i+2])/2, moduleSize})
+		}
+	}
+	return hits
+}
+
+// confirm cross-checks a hit of the scanned row or column
+func confirm(scanned line, hit [2]float64) (FinderPattern, bool) {
+	center, moduleSize := hit[0], hit[1]
+	maxRun := int(math.Ceil(4 * moduleSize))
+
+	// Across the scan line, at the center of the hit
+	across := line{b: scanned.b, vertical: !scanned.vertical, at: int(center)}
+	crossCenter, crossModuleSize, ok := across.crossCheck(scanned.at, maxRun)
+	if !ok || math.Abs(crossModuleSize-moduleSize) > moduleSize/2 {
+		return FinderPattern{}, false
+	}
+
+	// Back along the scan direction, through the refined center
+	scanned.at = int(crossCenter)
+	center, moduleSize2, ok := scanned.crossCheck(int(center), maxRun)
+	if !ok || math.Abs(moduleSize2-moduleSize) > moduleSize/2 {
+		return FinderPattern{}, false
+	}
+
+	p := Point{X: center, Y: crossCenter}
+	if scanned.vertical {
+		p = Point{X: crossCenter, Y: center}
+	}
+	return FinderPattern{Center: p, ModuleSize: (moduleSize2 + crossModuleSize) / 2, Count: 1}, true
+}
+
+// merge adds c to candidates, averaging it into a candidate within a module
+// of its center and of similar module size
+func merge(candidates []FinderPattern, c FinderPattern) []FinderPattern {
+	for i, other := range candidates {
+		if math.Abs(other.Center.X-c.Center.X) <= other.ModuleSize &&
+			math.Abs(other.Center.Y-c.Center.Y) <= other.ModuleSize &&
+			math.Abs(other.ModuleSize-c.ModuleSize) <= max(1, other.ModuleSize/2) {
+			n := float64(other.Count)
+			candidates[i] = FinderPattern{
+				Center: Point{
+					X: (other.Center.X*n + c.Center.X) / (n + 1),
+					Y: (other.Center.Y*n + c.Center.Y) / (n + 1),
+				},
+				ModuleSize: (other.ModuleSize*n + c.ModuleSize) / (n + 1),
+				Count:      other.Count + 1,
+			}
+			return candidates
+		}
+	}
+	return append(candidates, c)
+}
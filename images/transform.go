@@ -0,0 +1,88 @@
+package images
+
+import "math"
+
+// Point is a position in an image, in pixels. The center of pixel (x, y) is
+// (x+0.5, y+0.5).
+type Point struct {
+	X, Y float64
+}
+
+func distance(p1, p2 Point) float64 {
+	dx, dy := p1.X-p2.X, p1.Y-p2.Y
+	return math.Sqrt(dx*dx + dy*dy)
+}
+
+// PerspectiveTransform maps points of a plane onto another (a homography):
+//
+//	x' = (a11 x + a12 y + a13) / (a31 x + a32 y + a33)
+//	y' = (a21 x + a22 y + a23) / (a31 x + a32 y + a33)
+type PerspectiveTransform [3][3]float64
+
+// QuadrilateralToQuadrilateral returns the transform mapping the corners of
+// src onto the corners of dst. Corners go around the quadrilateral, e.g. top
+// left, top right, bottom right and bottom left.
+func QuadrilateralToQuadrilateral(src, dst [4]Point) PerspectiveTransform {
+	return squareToQuadrilateral(dst).times(squareToQuadrilateral(src).adjoint())
+}
+
+// squareToQuadrilateral maps (0, 0), (1, 0), (1, 1) and (0, 1) onto q
+// (Heckbert, Fundamentals of Texture Mapping and Image Warping)
+func squareToQuadrilateral(q [4]Point) PerspectiveTransform {
+	sx := q[0].X - q[1].X + q[2].X - q[3].X
+	sy := q[0].Y - q[1].Y + q[2].Y - q[3].Y
+	if sx == 0 && sy == 0 {
+		// Parallelogram, the transform is affine
+		return PerspectiveTransform{
+			{q[1].X - q[0].X, q[2].X - q[1].X, q[0].X},
+			{q[1].Y - q[0].Y, q[2].Y - q[1].Y, q[0].Y},
+			{0, 0, 1},
+		}
+	}
+	dx1, dx2 := q[1].X-q[2].X, q[3].X-q[2].X
+	dy1, dy2 := q[1].Y-q[2].Y, q[3].Y-q[2].Y
+	det := dx1*dy2 - dx2*dy1
+	g := (sx*dy2 - sy*dx2) / det
+	h := (dx1*sy - dy1*sx) / det
+	return PerspectiveTransform{
+		{q[1].X - q[0].X + g*q[1].X, q[3].X - q[0].X + h*q[3].X, q[0].X},
+		{q[1].Y - q[0].Y + g*q[1].Y, q[3].Y - q[0].Y + h*q[3].Y, q[0].Y},
+		{g, h, 1},
+	}
+}
+
+// adjoint returns the inverse of t up to a scale factor, which homogeneous
+// coordinates ignore
+func (t PerspectiveTransform) adjoint() PerspectiveTransform {
+	var adj PerspectiveTransform
+	for i := range 3 {
+		for j := range 3 {
+			// Cofactor of t[j][i]
+			r1, r2 := (j+1)%3, (j+2)%3
+			c1, c2 := (i+1)%3, (i+2)%3
+			adj[i][j] = t[r1][c1]*t[r2][c2] - t[r1][c2]*t[r2][c1]
+		}
+	}
+	return adj
+}
+
+func (t PerspectiveTransform) times(other PerspectiveTransform) PerspectiveTransform {
+	var product PerspectiveTransform
+	for i := range 3 {
+		for j := range 3 {
+			for k := range 3 {
+				product[i][j] += t[i][k] * other[k][j]
+			}
+		}
+	}
+	return product
+}
+
+// Apply maps p
+func (t PerspectiveTransform) Apply(p Point) Point {
+	w := t[2][0]*p.X + t[2][1]*p.Y + t[2][2]
+	return Point{
+		X: (t[0][0]*p.X + t[0][1]*p.Y + t[0][2]) / w,
+		Y: (t[1][0]*p.X + t[1][1]*p.Y + t[1][2]) / w,
+	}
+}
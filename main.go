@@ -1,7 +1,10 @@
 package main
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
+	"image"
 	"image/color"
 	"math"
 	"math/bits"
@@ -11,7 +14,9 @@ import (
 	"strings"
 
 	"github.com/harogaston/qr-decoder/bitseq"
+	"github.com/harogaston/qr-decoder/images"
 	"github.com/harogaston/qr-decoder/modes"
+	"github.com/harogaston/qr-decoder/payload"
 	"github.com/harogaston/qr-decoder/version"
 	"github.com/harogaston/qr-decoder/writer"
 )
@@ -21,25 +26,40 @@ type qr struct {
 	matrix              [][]module
 	version             version.QRVersion
 	error_corr_level    errcorr
-	size                int
+	size                int // modules per side of square symbols
+	width               int
+	height              int
 	data                []byte
 	encoded_data        bitseq.BitSeq
-	mode                modes.QRMode
+	segments            []modes.Segment
 	mask                int
 	logo                string
 	is_function_pattern [][]bool
 	debug               bool
+	reversed            bool // light modules on a dark background
+	mirrored            bool
+	// Share of the error correction capacity left by the worst block, once
+	// decoded
+	unused_error_correction float64
 }
 
 func (qr *qr) DebugPrint() {
 	black := "\u25A0"
 	white := "\u25A1"
 	fmt.Println(qr.String())
-	fmt.Printf("Mode: %s\n", qr.mode)
+	fmt.Printf("Segments: %v\n", qr.segments)
 	formatInfo, _ := GenerateFormatInformation(qr.error_corr_level, qr.mask)
 	bs := bitseq.FromInt(uint64(formatInfo), 15)
+	switch qr.version.Format {
+	case version.FORMAT_MICRO_QR:
+		formatInfo, _ = GenerateMicroFormatInformation(qr.version.Number, qr.error_corr_level, qr.mask)
+		bs = bitseq.FromInt(uint64(formatInfo), 15)
+	case version.FORMAT_RMQR:
+		finder, _, _ := GenerateRMQRFormatInformation(qr.version.Number, qr.error_corr_level)
+		bs = bitseq.FromInt(uint64(finder), 18)
+	}
 	var formatColors []string
-	for i := range 15 {
+	for i := range bs.Len() {
 		if bs.Bit(i) {
 			formatColors = append(formatColors, black)
 		} else {
@@ -50,6 +70,15 @@ func (qr *qr) DebugPrint() {
 }
 
 func (qr *qr) generate() {
+	switch qr.version.Format {
+	case version.FORMAT_MICRO_QR:
+		qr.generate_micro()
+		return
+	case version.FORMAT_RMQR:
+		qr.generate_rmqr()
+		return
+	}
+
 	// Functions patterns. This sections DO NOT encode data.
 	qr.finder_patterns()
 	qr.separators()
@@ -164,10 +193,10 @@ func (qr *qr) place_format_information(mask int) {
 	for i := range 8 {
 		qr.matrix[8][qr.size-8+i] = format_modules[7+i] // format_modules[7] is Bit 7, [14] is Bit 0
 	}
-	// Bottom-Left: Bits 14-8 at (size-7 to size-1, 8)
-	// Bit 14 at (size-7, 8) ... Bit 8 at (size-1, 8)
+	// Bottom-Left: Bits 14-8 at (size-1 to size-7, 8)
+	// Bit 14 at (size-1, 8) ... Bit 8 at (size-7, 8)
 	for i := range 7 {
-		qr.matrix[qr.size-7+i][8] = format_modules[i] // format_modules[0] is Bit 14, [6] is Bit 8
+		qr.matrix[qr.size-1-i][8] = format_modules[i] // format_modules[0] is Bit 14, [6] is Bit 8
 	}
 
 	// set always dark module 4V + 9, 8
@@ -497,8 +526,7 @@ func (qr *qr) version_information() {
 
 func (qr *qr) data_and_error_correction() {
 	// 1. Get data codewords and block info
-	data := capacityData[qr.version.Number]
-	ecInfo := data.ecInfo[qr.error_corr_level]
+	ecInfo, _ := getECInfo(qr.version, qr.error_corr_level)
 
 	// Convert bit_seq to bytes
 	dataBytes := qr.encoded_data.Bytes(bitseq.MSBFirst)
@@ -529,7 +557,7 @@ func (qr *qr) data_and_error_correction() {
 	}
 
 	// 3. Interleave Data
-	var finalMessage []byte
+	var finalMessage bitseq.BitSeq
 
 	// Max data length
 	maxDataLen := 0
@@ -542,7 +570,12 @@ func (qr *qr) data_and_error_correction() {
 	for i := 0; i < maxDataLen; i++ {
 		for _, block := range dataBlocks {
 			if i < len(block) {
-				finalMessage = append(finalMessage, block[i])
+				codeword := bitseq.FromInt(uint64(block[i]), 8)
+				if i == len(block)-1 && hasHalfDataCodeword(qr.version) {
+					// Only the 4 most significant bits are placed
+					codeword = bitseq.FromInt(uint64(block[i]>>4), 4)
+				}
+				finalMessage = finalMessage.Append(codeword)
 			}
 		}
 	}
@@ -558,7 +591,7 @@ func (qr *qr) data_and_error_correction() {
 	for i := 0; i < maxECLen; i++ {
 		for _, block := range ecBlocks {
 			if i < len(block) {
-				finalMessage = append(finalMessage, block[i])
+				finalMessage = finalMessage.Append(bitseq.FromInt(uint64(block[i]), 8))
 			}
 		}
 	}
@@ -567,51 +600,56 @@ func (qr *qr) data_and_error_correction() {
 	qr.placeCodewords(finalMessage)
 }
 
-func (qr *qr) placeCodewords(data []byte) {
+func (qr *qr) placeCodewords(data bitseq.BitSeq) {
+	for bitIndex, pos := range qr.data_module_positions() {
+		// Remainder bits (past the end of data) are 0
+		if bitIndex < data.Len() && data.Bit(bitIndex) {
+			qr.matrix[pos[0]][pos[1]] = module{bit: One}
+		} else {
+			qr.matrix[pos[0]][pos[1]] = module{bit: Zero}
+		}
+	}
+}
+
+// codeword_module_positions maps each codeword, in placement order, to the
+// (row, column) of the modules of its bits, most significant first. This is
+// the reverse of placeCodewords, remainder bits are left out.
+func (qr *qr) codeword_module_positions() [][8][2]int {
+	positions := qr.data_module_positions()
+	codewords := make([][8][2]int, len(positions)/8)
+	for i := range codewords {
+		codewords[i] = [8][2]int(positions[i*8 : i*8+8])
+	}
+	return codewords
+}
+
+// data_module_positions returns the (row, column) of every module of the
+// encoding region not taken by function patterns, in codeword placement order
+func (qr *qr) data_module_positions() [][2]int {
+	var positions [][2]int
+
 	// Zig-zag scan
 	// Start at bottom right
-	row := qr.size - 1
-	col := qr.size - 1
+	row := qr.height - 1
+	col := qr.width - 1
+	if qr.version.Format == version.FORMAT_RMQR {
+		col-- // Skip right edge timing pattern
+	}
 	direction := -1 // -1 for up, 1 for down
 
-	bitIndex := 0
-	byteIndex := 0
-
 	for col > 0 {
-		if col == 6 { // Skip timing pattern column
+		if col == 6 && qr.version.Format == version.FORMAT_QR_MODEL_2 { // Skip timing pattern column
 			col--
 		}
 
-		for row >= 0 && row < qr.size {
+		for row >= 0 && row < qr.height {
 			for c := range 2 {
 				x := col - c
 				y := row
 
 				// Skip function patterns
 				if !qr.isFunctionPattern(y, x) {
-					// Place bit
-					var bit int
-					if byteIndex < len(data) {
-						if (data[byteIndex]>>(7-bitIndex))&1 == 1 {
-							bit = 1
-						} else {
-							bit = 0
-						}
-						bitIndex++
-						if bitIndex == 8 {
-							bitIndex = 0
-							byteIndex++
-						}
-					} else {
-						// Remainder bits (should be 0)
-						bit = 0
-					}
-
-					if bit == 1 {
-						qr.matrix[y][x] = module{bit: One}
-					} else {
-						qr.matrix[y][x] = module{bit: Zero}
-					}
+					positions = append(positions, [2]int{y, x})
 				}
 			}
 			row += direction
@@ -620,12 +658,13 @@ func (qr *qr) placeCodewords(data []byte) {
 		direction = -direction // Change direction
 		col -= 2
 	}
+	return positions
 }
 
 // Calculates character count of given input data in the
 // corresponding data mode
 func character_count(mode modes.QRMode, version version.QRVersion, input string) bitseq.BitSeq {
-	bs := bitseq.FromInt(uint64(len(input)), GetCharCountLength(version, mode))
+	bs := bitseq.FromInt(uint64(modes.CharCount(mode, input)), GetCharCountLength(version, mode))
 	return bs
 }
 
@@ -637,11 +676,54 @@ func encode(mode modes.QRMode, input string) bitseq.BitSeq {
 		return modes.EncodeAlphanumeric(input)
 	case modes.ByteMode:
 		return encode_byte(input)
+	case modes.KanjiMode:
+		return modes.EncodeKanji(input)
+	case modes.HanziMode:
+		return modes.EncodeHanzi(input)
 	default:
 		panic("encode: data mode not implemented!")
 	}
 }
 
+// encodeSegments writes the mode indicator, character count indicator and
+// data of each segment back to back.
+func encodeSegments(segments []modes.Segment, version version.QRVersion) bitseq.BitSeq {
+	var output bitseq.BitSeq
+	for _, s := range segments {
+		output = output.Append(encodeSegment(s, version))
+	}
+	return output
+}
+
+func encodeSegment(s modes.Segment, version version.QRVersion) bitseq.BitSeq {
+	switch s.Mode {
+	case modes.ECI:
+		// No character count, only the assignment number
+		return bitseq.ConcatMany(modes.GetModeIndicatorBits(version, s.Mode), modes.EncodeECIDesignator(s.ECI))
+	case modes.StructuredAppend:
+		return bitseq.ConcatMany(modes.GetModeIndicatorBits(version, s.Mode), modes.EncodeStructuredAppend(s.Position, s.Total, s.Parity))
+	case modes.FNC1FirstPosition:
+		return modes.GetModeIndicatorBits(version, s.Mode)
+	case modes.FNC1SecondPosition:
+		// Data holds the application indicator
+		return bitseq.ConcatMany(modes.GetModeIndicatorBits(version, s.Mode), modes.EncodeApplicationIndicator(s.Data))
+	case modes.HanziMode:
+		// Subset indicator between the mode indicator and the character count
+		return bitseq.ConcatMany(
+			modes.GetModeIndicatorBits(version, s.Mode),
+			modes.EncodeHanziSubset(),
+			character_count(s.Mode, version, s.Data),
+			encode(s.Mode, s.Data),
+		)
+	default:
+		return bitseq.ConcatMany(
+			modes.GetModeIndicatorBits(version, s.Mode),
+			character_count(s.Mode, version, s.Data),
+			encode(s.Mode, s.Data),
+		)
+	}
+}
+
 func encode_byte(input string) bitseq.BitSeq {
 	var output bitseq.BitSeq
 	for i := 0; i < len(input); i++ {
@@ -651,18 +733,17 @@ func encode_byte(input string) bitseq.BitSeq {
 	return output
 }
 
-func ApplyQRPadding(bs bitseq.BitSeq, capacityBytes int) bitseq.BitSeq {
-	capacityBits := capacityBytes * 8
-
-	// 1. Terminator: Up to 4 bits of 0s
-	// TODO: Call modes.GetTerminatorBits
-	termLen := min(4, capacityBits-bs.Len())
+// ApplyQRPadding fills the data capacity of version v. capacityBits is not a
+// multiple of 8 in Micro QR M1 and M3, whose final data codeword is 4 bits long.
+func ApplyQRPadding(bs bitseq.BitSeq, v version.QRVersion, capacityBits int) bitseq.BitSeq {
+	// 1. Terminator: 4 bits of 0s (3 to 9 in Micro QR), truncated when the capacity is reached
+	termLen := min(modes.GetTerminatorBits(v).Len(), capacityBits-bs.Len())
 	if termLen > 0 {
 		bs = bitseq.ConcatMany(bs, bitseq.ZeroSequence(termLen))
 	}
 
 	// 2. Bit alignment: Make it a multiple of 8
-	if alignLen := bs.AlignToByte(); alignLen > 0 {
+	if alignLen := min(bs.AlignToByte(), capacityBits-bs.Len()); alignLen > 0 {
 		bs = bitseq.ConcatMany(bs, bitseq.ZeroSequence(alignLen))
 	}
 
@@ -670,7 +751,7 @@ func ApplyQRPadding(bs bitseq.BitSeq, capacityBytes int) bitseq.BitSeq {
 	padPatterns := []uint64{0xEC, 0x11}
 	patternIdx := 0
 
-	for bs.Len() < capacityBits {
+	for bs.Len()+8 <= capacityBits {
 		// Create a full byte (8 bits) from the pattern
 		pattern := bitseq.FromInt(padPatterns[patternIdx], 8)
 		bs = bitseq.ConcatMany(bs, pattern)
@@ -679,67 +760,213 @@ func ApplyQRPadding(bs bitseq.BitSeq, capacityBytes int) bitseq.BitSeq {
 		patternIdx = (patternIdx + 1) % len(padPatterns)
 	}
 
+	// 4. The final 4-bit data codeword of M1 and M3 is padded with 0000
+	if bs.Len() < capacityBits {
+		bs = bitseq.ConcatMany(bs, bitseq.ZeroSequence(capacityBits-bs.Len()))
+	}
+
 	return bs
 }
 
-func NewQRCode(r QRRequest) *qr {
-	// Step 1 - Data analysis
-	mode := modes.GetMode(r.input_data)
-
+// analyze selects the byte mode charset, the segments and, unless requested
+// explicitly, the smallest version that fits the input data of r.
+func analyze(r QRRequest) (version.QRVersion, []modes.Segment, error) {
 	format := version.FORMAT_QR_MODEL_2
-	// TODO: Format
-	// if r.is_micro {
-	// 	format = QR_FORMAT_MICRO_QR
-	// }
+	if r.is_micro {
+		format = version.FORMAT_MICRO_QR
+		if err := r.checkMicro(); err != nil {
+			return version.QRVersion{}, nil, err
+		}
+	}
+	if r.is_rmqr {
+		format = version.FORMAT_RMQR
+		if err := r.checkRMQR(); err != nil {
+			return version.QRVersion{}, nil, err
+		}
+	}
 
-	// Encode input_data_bits
-	input_data_bits := encode(mode, r.input_data)
+	charset, err := r.getCharset()
+	if err != nil {
+		return version.QRVersion{}, nil, err
+	}
 
-	var version_num int
-	if r.version != 0 {
-		version_num = r.version
-	} else {
-		version_num = GetVersionNumber(mode, format, input_data_bits, errcorr(r.err_corr_level))
+	// Segments depend on the version class, unless explicitly requested
+	var segmentsErr error
+	segmentsFor := func(v version.QRVersion) []modes.Segment {
+		if _, height := v.Size(); r.rmqr_height != 0 && height != r.rmqr_height {
+			return nil
+		}
+		segments := r.segments
+		if segments == nil {
+			segments, segmentsErr = GetSegments(r.input_data, v, charset, r.fnc1 != nil)
+			if segmentsErr != nil {
+				// e.g. a mode not available in a Micro QR version
+				return nil
+			}
+			if r.fnc1 != nil {
+				segments = append([]modes.Segment{*r.fnc1}, segments...)
+			}
+			segments = withECIHeader(segments, charset, r.charset != "")
+		}
+		if r.structured_append != nil {
+			segments = append([]modes.Segment{*r.structured_append}, segments...)
+		}
+		return segments
+	}
+
+	version_num := r.version
+	if version_num == 0 {
+		version_num = GetVersionNumber(format, errcorr(r.err_corr_level), segmentsFor)
+		if segmentsErr != nil {
+			return version.QRVersion{}, nil, segmentsErr
+		}
+		if version_num == 0 && r.is_micro {
+			return version.QRVersion{}, nil, fmt.Errorf("data does not fit in a Micro QR symbol with error correction level %s", r.err_corr_level)
+		}
+		if version_num == 0 && r.is_rmqr {
+			return version.QRVersion{}, nil, fmt.Errorf("data does not fit in an rMQR symbol with error correction level %s", r.err_corr_level)
+		}
+		if version_num == 0 {
+			return version.QRVersion{}, nil, errors.New("data does not fit in a single symbol, use structured append")
+		}
 	}
 
 	version := version.QRVersion{
 		Format: format,
 		Number: version_num,
 	}
+	segments := segmentsFor(version)
+	if segmentsErr != nil {
+		return version, nil, segmentsErr
+	}
+	if r.is_micro && slices.ContainsFunc(segments, func(s modes.Segment) bool {
+		return s.Mode == modes.ECI
+	}) {
+		return version, nil, fmt.Errorf("charset %s needs an ECI header, not supported in Micro QR", charset.Name)
+	}
+
+	bitLength := segmentsBitLength(segments, version)
+	if bitLength < 0 || bitLength > getDataCapacityBits(version, errcorr(r.err_corr_level)) {
+		return version, nil, fmt.Errorf("data does not fit in version %s-%s", version, r.err_corr_level)
+	}
+	return version, segments, nil
+}
+
+// checkMicro reports the options of r that are not available in Micro QR.
+func (r QRRequest) checkMicro() error {
+	if r.version < 0 || r.version > 4 {
+		return fmt.Errorf("Micro QR versions are M1 to M4, got M%d", r.version)
+	}
+	if r.err_corr_level == ERR_CORR_H {
+		return errors.New("error correction level H is not available in Micro QR")
+	}
+	if r.structured_append != nil {
+		return errors.New("structured append is not supported in Micro QR")
+	}
+	if r.fnc1 != nil {
+		return errors.New("FNC1 is not supported in Micro QR")
+	}
+	return nil
+}
+
+// checkRMQR reports the options of r that are not available in rMQR.
+func (r QRRequest) checkRMQR() error {
+	if r.version < 0 || r.version > version.RMQRVersionCount {
+		return fmt.Errorf("rMQR versions are 1 (R7x43) to %d (R17x139), got %d", version.RMQRVersionCount, r.version)
+	}
+	if r.rmqr_height != 0 {
+		if r.rmqr_height < 7 || r.rmqr_height > 17 || r.rmqr_height%2 == 0 {
+			return fmt.Errorf("rMQR heights are 7, 9, 11, 13, 15 and 17, got %d", r.rmqr_height)
+		}
+		v := version.QRVersion{Format: version.FORMAT_RMQR, Number: r.version}
+		if _, height := v.Size(); r.version != 0 && height != r.rmqr_height {
+			return fmt.Errorf("rMQR version %s is not %d modules high", v, r.rmqr_height)
+		}
+	}
+	if r.err_corr_level != ERR_CORR_M && r.err_corr_level != ERR_CORR_H {
+		return fmt.Errorf("error correction level %s is not available in rMQR, use M or H", r.err_corr_level)
+	}
+	if r.structured_append != nil {
+		return errors.New("structured append is not supported in rMQR")
+	}
+	return nil
+}
+
+// getCharset returns the requested byte mode charset, or ISO/IEC 8859-1
+// unless the data needs UTF-8.
+func (r QRRequest) getCharset() (modes.Charset, error) {
+	if r.charset == "" {
+		return modes.DetectCharset(r.input_data), nil
+	}
+	c, ok := modes.GetCharset(r.charset)
+	if !ok {
+		return modes.Charset{}, fmt.Errorf("unknown charset %q", r.charset)
+	}
+	return c, nil
+}
 
-	character_count := character_count(mode, version, r.input_data)
+func NewQRCode(r QRRequest) *qr {
+	// Step 1 - Data analysis
+	version, segments, err := analyze(r)
+	if err != nil {
+		panic(err)
+	}
 
-	output := bitseq.ConcatMany(modes.GetModeIndicatorBits(version, mode), character_count, input_data_bits)
+	// Step 2 - Data encoding
+	output := encodeSegments(segments, version)
 
-	// Calculate total data capacity in bytes, each word is 8 bits
-	dataCapacityBytes := getTotalDataCodewords(version, errcorr(r.err_corr_level))
+	// Calculate total data capacity in bits
+	dataCapacityBits := getDataCapacityBits(version, errcorr(r.err_corr_level))
 
-	output = ApplyQRPadding(output, dataCapacityBytes)
+	output = ApplyQRPadding(output, version, dataCapacityBits)
 
 	// Initialize data structures
-	size := 21 + (version.Number-1)*4
-	matrix := make([][]module, size)
-	isFunctionPattern := make([][]bool, size)
-	for i := range size {
-		matrix[i] = make([]module, size)
-		isFunctionPattern[i] = make([]bool, size)
-	}
-	qr := &qr{
-		matrix:              matrix,
-		is_function_pattern: isFunctionPattern,
-		version:             version,
-		error_corr_level:    errcorr(r.err_corr_level),
-		size:                size,
-		data:                []byte(r.input_data),
-		encoded_data:        output,
-		mode:                mode,
-		logo:                r.logo,
-		debug:               r.debug_no_mask,
-	}
+	qr := newEmptyQRCode(version)
+	qr.error_corr_level = errcorr(r.err_corr_level)
+	qr.data = []byte(r.input_data)
+	qr.encoded_data = output
+	qr.segments = segments
+	qr.logo = r.logo
+	qr.debug = r.debug_no_mask
+	qr.reversed = r.reflectance_reversal
+	qr.mirrored = r.mirrored
 	qr.generate()
+	if qr.mirrored {
+		qr.mirror()
+	}
 	return qr
 }
 
+// withMask rebuilds a QR Code (Model 2) symbol from its data codewords with
+// the given data mask, as generate() would have placed them
+func (qr *qr) withMask(mask int) *qr {
+	masked := newEmptyQRCode(qr.version)
+	masked.error_corr_level = qr.error_corr_level
+	masked.data = qr.data
+	masked.encoded_data = qr.encoded_data
+	masked.segments = qr.segments
+	masked.logo = qr.logo
+	masked.finder_patterns()
+	masked.separators()
+	masked.timing_patterns()
+	masked.alignment_patterns()
+	masked.version_information()
+	masked.reserve_format_information_area()
+	masked.data_and_error_correction()
+	masked.matrix = masked.apply_mask(mask, masked.matrix)
+	masked.mask = mask
+	masked.place_format_information(mask)
+	return masked
+}
+
+// mirror flips the symbol horizontally, e.g. to be read through the back of glass
+func (qr *qr) mirror() {
+	for i := range qr.matrix {
+		slices.Reverse(qr.matrix[i])
+		slices.Reverse(qr.is_function_pattern[i])
+	}
+}
+
 type errcorr string
 
 const (
@@ -763,6 +990,8 @@ const (
 
 type module struct {
 	bit Bit
+	// 1 - the confidence of a sampled module, 0 when the bit is known
+	doubt float64
 }
 
 func (m *module) Color() color.Color {
@@ -778,12 +1007,20 @@ func (m *module) Color() color.Color {
 
 func (qr *qr) String() string {
 	b := strings.Builder{}
+	if qr.width != qr.height {
+		fmt.Fprintf(&b, "QR version %s (size %dx%d)", qr.FullVersion(), qr.width, qr.height)
+		return b.String()
+	}
 	fmt.Fprintf(&b, "QR version %s (size %d)", qr.FullVersion(), qr.size)
 	return b.String()
 }
 
 func (qr *qr) Draw(shape writer.Shape) {
-	pixs := make([][]color.Color, len(qr.matrix[0]))
+	writer.WriteSVG(qr.svgRequest(shape))
+}
+
+func (qr *qr) svgRequest(shape writer.Shape) writer.SVGRequest {
+	pixs := make([][]color.Color, len(qr.matrix))
 	for y, row := range qr.matrix {
 		imgRow := make([]color.Color, len(row))
 		for x, c := range row {
@@ -798,15 +1035,47 @@ func (qr *qr) Draw(shape writer.Shape) {
 		Shape: shape,
 		Logo:  qr.logo,
 	}
-	writer.WriteSVG(req)
+	finders := []image.Point{{X: 0, Y: 0}, {X: qr.width - 7, Y: 0}, {X: 0, Y: qr.height - 7}}
+	if qr.version.Format == version.FORMAT_MICRO_QR || qr.version.Format == version.FORMAT_RMQR {
+		req.QuietZone = 2
+		finders = finders[:1]
+	}
+	if qr.mirrored {
+		for i, p := range finders {
+			finders[i].X = qr.width - 7 - p.X
+		}
+	}
+	req.Finders = finders
+	if qr.reversed {
+		// Dark modules are drawn light on a dark background and quiet zone
+		req.Color, req.Background = color.White, color.Black
+	}
+	return req
 }
 
 type QRRequest struct {
 	input_data     string
-	is_micro       bool
 	err_corr_level string
 	logo           string
 	version        int
+	// optional, Micro QR M1 to M4 instead of QR Code
+	is_micro bool
+	// optional, rMQR instead of QR Code. version is then 1 (R7x43) to 32 (R17x139)
+	is_rmqr bool
+	// optional, rMQR height in modules, the smallest width that fits is selected
+	rmqr_height int
+	// optional, light modules on a dark background (e.g. laser etched metal)
+	reflectance_reversal bool
+	// optional, horizontally mirrored symbol (e.g. marked on the back of glass)
+	mirrored bool
+	// optional, byte mode charset name or ECI assignment number
+	charset string
+	// optional, overrides the automatic segmentation of input_data
+	segments []modes.Segment
+	// optional, structured append header placed before the data
+	structured_append *modes.Segment
+	// optional, FNC1 first or second position header, e.g. for GS1 data
+	fnc1 *modes.Segment
 	// TODO: Remove later
 	debug_no_mask bool
 }
@@ -814,6 +1083,36 @@ type QRRequest struct {
 func main() {
 	args := os.Args[1:]
 
+	if len(args) > 0 && args[0] == "append" {
+		structuredAppendCommand(args[1:])
+		return
+	}
+
+	if len(args) > 0 && args[0] == "decode" {
+		decodeCommand(args[1:])
+		return
+	}
+
+	if len(args) > 0 && args[0] == "grade" {
+		gradeCommand(args[1:])
+		return
+	}
+
+	if len(args) > 0 && args[0] == "robustness" {
+		robustnessCommand(args[1:])
+		return
+	}
+
+	if len(args) > 0 && args[0] == "wifi" {
+		wifiCommand(args[1:])
+		return
+	}
+
+	if len(args) > 0 && args[0] == "corpus" {
+		corpusCommand(args[1:])
+		return
+	}
+
 	if len(args) > 0 && (args[0] == "-h" || args[0] == "--help") {
 		fmt.Println("Usage: qr-decoder [Data] [ErrorCorrectionLevel] [Version] [IsMicro] [Shape]")
 		fmt.Println("")
@@ -822,16 +1121,68 @@ func main() {
 		fmt.Println("  Shape: square, circle, rounded, diamond (default: square)")
 		fmt.Println("  ErrorCorrectionLevel: L, M, Q, H (default: L)")
 		fmt.Println("  Logo: provide path to logo image to embed in the center (optional)")
-		fmt.Println("  Version: QR Code version 1-40 (optional, auto-detected if 0 or omitted)")
-		fmt.Println("  IsMicro: true/false (default: false)")
+		fmt.Println("  Version: QR Code version 1-40, or 1-4 with --micro (optional, auto-detected if 0 or omitted)")
+		fmt.Println("           with --rmqr, a size such as R11x59 or a height such as R11 (the narrowest width that fits)")
+		fmt.Println("  --micro: Generate a Micro QR symbol M1-M4, error correction level H is not available (optional)")
+		fmt.Println("  --rmqr: Generate a rectangular Micro QR (rMQR) symbol, error correction level M or H (optional)")
+		fmt.Println("  --charset=NAME: Byte mode charset, e.g. ISO-8859-1, Shift_JIS, UTF-8 or an ECI number (optional)")
+		fmt.Println("  --gs1: Data is a GS1 element string such as (01)09501101530003(17)260101(10)ABC (optional)")
+		fmt.Println("  --fnc1-app=AI: FNC1 in second position with the given application indicator (optional)")
+		fmt.Println("  --reverse: Reflectance reversal, light modules on a dark background and quiet zone (optional)")
+		fmt.Println("  --mirror: Mirror the symbol horizontally (optional)")
 		fmt.Println("  --debug-no-mask: Disable masking for debugging (optional)")
 		fmt.Println("")
 		fmt.Println("Examples:")
 		fmt.Println("  qr-decoder L \"Hello World\"")
 		fmt.Println("  qr-decoder M \"1234567890\" 5 false circle")
+		fmt.Println("")
+		fmt.Println("Structured append: qr-decoder append [Data] [Shape] [ErrorCorrectionLevel] [Symbols] [--sheet]")
+		fmt.Println("  Symbols: number of symbols 1-16 (optional, auto-detected if 0 or omitted)")
+		fmt.Println("  --sheet: Write all symbols to qr.svg instead of qr-1.svg, qr-2.svg, ... (optional)")
+		fmt.Println("")
+		fmt.Println("Decoding: qr-decoder decode [Image]")
+		fmt.Println("  Image: PNG, JPEG or GIF file with a QR Code symbol, the data is printed")
+		fmt.Println("  --channel=NAME: luminance, red, green or blue, e.g. green for red ink (default: luminance)")
+		fmt.Println("  --binarize=NAME: otsu (global), hybrid (local block means) or sauvola (default: hybrid)")
+		fmt.Println("  --debug-binarized=FILE: Write the binarized image to a PNG file (optional)")
+		fmt.Println("  --all: Decode every symbol of the image and join structured append sequences (optional)")
+		fmt.Println("  --payload: Print the kind of payload, e.g. url, wifi or vcard, and whether it is valid (optional)")
+		fmt.Println("")
+		fmt.Println("Frames: qr-decoder decode [Image] [Image]... or qr-decoder decode [Directory]")
+		fmt.Println("  Several images (or the PNG images of a directory) of the same symbol, fused module by module")
+		fmt.Println("  --fusion=NAME: majority (one vote per frame) or confidence (votes weighted by sampling confidence) (default: confidence)")
+		fmt.Println("")
+		fmt.Println("Grading: qr-decoder grade [Image]")
+		fmt.Println("  Image: PNG, JPEG or GIF file with a QR Code symbol, its ISO/IEC 15415 print quality grades are printed")
+		fmt.Println("")
+		fmt.Println("Wi-Fi: qr-decoder wifi --ssid NAME --pass PASSWORD")
+		fmt.Println("  A join card for a Wi-Fi network, flags take their value after a space or an equal sign")
+		fmt.Println("  --security: nopass, WEP, WPA (WPA/WPA2), SAE (WPA3) or WPA2-EAP (default: WPA with --pass, WPA2-EAP with --eap, nopass otherwise)")
+		fmt.Println("  --hidden: The network does not broadcast its SSID (optional)")
+		fmt.Println("  --wpa3-only: WPA or SAE network with transition disable, WPA2 is refused (optional)")
+		fmt.Println("  --eap, --identity, --anonymous-identity, --phase2: WPA2-EAP method (e.g. PEAP), identities and phase 2 method (optional)")
+		fmt.Println("  --level: Error correction level L, M, Q or H (default: L)")
+		fmt.Println("  --shape: square, circle, rounded, diamond (default: square)")
+		fmt.Println("")
+		fmt.Println("Robustness: qr-decoder robustness [Data] [Version]")
+		fmt.Println("  The highest damage severity, 0 to 1, that the symbol survives at each error correction level and mask")
+		fmt.Println("  --model=NAME: flips, scratches, blotch or blur (default: all of them)")
+		fmt.Println("  --trials=N: Damaged copies that must all decode at each severity (default: 5)")
+		fmt.Println("  --seed=N: Seed of the damage (default: 1)")
+		fmt.Println("  --shape=NAME, --logo=PATH, --reverse, --mirror: The design to damage, as drawn (default: square modules)")
+		fmt.Println("")
+		fmt.Println("Corpus: qr-decoder corpus [PayloadFile] [Directory] [ErrorCorrectionLevel]")
+		fmt.Println("  PayloadFile: text file with a payload per line, each rendered clean, rotated, in perspective,")
+		fmt.Println("               blurred, noisy, with low contrast and JPEG compressed as PNG images in Directory,")
+		fmt.Println("               with their ground truth in manifest.json")
+		fmt.Println("  ErrorCorrectionLevel: L, M, Q, H (default: M)")
+		fmt.Println("  --seed=N: Seed of the distortions (default: 1)")
 		return
 	}
 
+	// Flags ("--" prefixed) can go anywhere, the rest are positional arguments
+	flags, args := splitFlags(args)
+
 	// Data
 	data := "01234567"
 	if len(args) > 0 {
@@ -862,25 +1213,492 @@ func main() {
 	}
 
 	// Version
+	is_rmqr := slices.Contains(flags, "--rmqr")
 	var version int64
-	if len(args) > 4 {
+	var rmqr_height int
+	if len(args) > 4 && is_rmqr {
+		var err error
+		version, rmqr_height, err = parseRMQRVersion(args[4])
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	} else if len(args) > 4 {
 		version, _ = strconv.ParseInt(args[4], 10, 64)
 	}
 
 	var debug_no_mask bool
-	if slices.Contains(args, "--debug-no-mask") {
+	if slices.Contains(flags, "--debug-no-mask") {
 		debug_no_mask = true
 	}
 
+	var charset, fnc1_app string
+	for _, arg := range flags {
+		if name, ok := strings.CutPrefix(arg, "--charset="); ok {
+			charset = name
+		}
+		if app, ok := strings.CutPrefix(arg, "--fnc1-app="); ok {
+			fnc1_app = app
+		}
+	}
+
 	req := QRRequest{
 		input_data:     data,
-		logo:           logo_path,
 		err_corr_level: err_corr_level,
-		version:        int(version),
-		debug_no_mask:  debug_no_mask,
 	}
+	if slices.Contains(flags, "--gs1") {
+		var err error
+		req, err = NewGS1Request(data, err_corr_level)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	}
+	if fnc1_app != "" {
+		req.fnc1 = &modes.Segment{Mode: modes.FNC1SecondPosition, Data: fnc1_app}
+	}
+	req.logo = logo_path
+	req.version = int(version)
+	req.charset = charset
+	req.is_micro = slices.Contains(flags, "--micro")
+	req.is_rmqr = is_rmqr
+	req.reflectance_reversal = slices.Contains(flags, "--reverse")
+	req.mirrored = slices.Contains(flags, "--mirror")
+	req.rmqr_height = rmqr_height
+	req.debug_no_mask = debug_no_mask
 
 	qr := NewQRCode(req)
 	qr.DebugPrint()
 	qr.Draw(shape)
 }
+
+// parseRMQRVersion parses an rMQR version given as a size ("R11x59"), a
+// height ("R11") or a version number 1 to 32
+func parseRMQRVersion(arg string) (number int64, height int, err error) {
+	if arg == "" {
+		return 0, 0, nil // auto-detected
+	}
+	if strings.ContainsAny(arg, "xX") {
+		v, err := version.ParseRMQR(strings.ReplaceAll(arg, "X", "x"))
+		return int64(v.Number), 0, err
+	}
+	if h, ok := strings.CutPrefix(strings.ToUpper(arg), "R"); ok {
+		height, err := strconv.Atoi(h)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid rMQR height %q, expected e.g. R11", arg)
+		}
+		return 0, height, nil
+	}
+	number, err = strconv.ParseInt(arg, 10, 64)
+	return number, 0, err
+}
+
+// splitFlags separates "--" prefixed flags from positional arguments
+func splitFlags(args []string) (flags, positional []string) {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--") {
+			flags = append(flags, arg)
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+	return flags, positional
+}
+
+// structuredAppendCommand splits data across several symbols
+func structuredAppendCommand(args []string) {
+	flags, args := splitFlags(args)
+
+	data := "01234567"
+	if len(args) > 0 {
+		data = args[0]
+	}
+
+	var shape writer.Shape = writer.ShapeSquare
+	if len(args) > 1 {
+		shape = writer.Shape(args[1])
+	}
+
+	err_corr_level := ERR_CORR_L
+	if len(args) > 2 {
+		if !slices.Contains(errCorrLevels, errcorr(args[2])) {
+			panic("could not parse 'err_corr_level'")
+		}
+		err_corr_level = args[2]
+	}
+
+	var symbols int64
+	if len(args) > 3 {
+		symbols, _ = strconv.ParseInt(args[3], 10, 64)
+	}
+
+	req := QRRequest{
+		input_data:     data,
+		err_corr_level: err_corr_level,
+	}
+	sequence, err := NewStructuredAppend(req, int(symbols))
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	for _, qr := range sequence {
+		qr.DebugPrint()
+	}
+	DrawStructuredAppend(sequence, shape, slices.Contains(flags, "--sheet"))
+}
+
+// decodeCommand reads a QR Code symbol from an image file
+func decodeCommand(args []string) {
+	flags, args := splitFlags(args)
+	if len(args) == 0 {
+		fmt.Println("Error: missing image file")
+		os.Exit(1)
+	}
+
+	var binarizer images.Binarizer
+	var debug_binarized string
+	var fusion Fusion
+	all, parse := false, false
+	for _, arg := range flags {
+		if arg == "--all" {
+			all = true
+		}
+		if arg == "--payload" {
+			parse = true
+		}
+		if method, ok := strings.CutPrefix(arg, "--fusion="); ok {
+			fusion = Fusion(method)
+		}
+		if channel, ok := strings.CutPrefix(arg, "--channel="); ok {
+			binarizer.Channel = images.Channel(channel)
+		}
+		if method, ok := strings.CutPrefix(arg, "--binarize="); ok {
+			binarizer.Binarization = images.Binarization(method)
+		}
+		if path, ok := strings.CutPrefix(arg, "--debug-binarized="); ok {
+			debug_binarized = path
+		}
+	}
+
+	// Several frames of the same symbol, or a directory of them
+	if info, err := os.Stat(args[0]); len(args) > 1 || (err == nil && info.IsDir()) {
+		decodeFrames(args, binarizer, fusion)
+		return
+	}
+
+	img, err := images.Load(args[0])
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	b, err := binarizer.Binarize(img)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	if debug_binarized != "" {
+		if err := b.WritePNG(debug_binarized); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	}
+	if all {
+		decodeAll(b)
+		return
+	}
+	qr, err := DecodeBitImage(b)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	fmt.Println(readNotes(qr) + string(qr.data))
+	if parse {
+		text := qr.payloadText()
+		if _, err := payload.Parse(text); err != nil {
+			fmt.Printf("Payload: %s, invalid: %v\n", payload.Classify(text), err)
+		} else {
+			fmt.Println("Payload:", payload.Classify(text))
+		}
+	}
+}
+
+// decodeFrames prints the symbol decoded from several image files, or from
+// the PNG images of a directory
+func decodeFrames(paths []string, binarizer images.Binarizer, fusion Fusion) {
+	var qr *qr
+	var err error
+	if len(paths) == 1 {
+		qr, err = DecodeFrameDirectory(paths[0], binarizer, fusion)
+	} else {
+		frames := make([]image.Image, len(paths))
+		for i, path := range paths {
+			if frames[i], err = images.Load(path); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+		}
+		qr, err = DecodeFrames(frames, binarizer, fusion)
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	fmt.Println(readNotes(qr) + string(qr.data))
+}
+
+// readNotes returns "(inverted) " for a symbol read with light modules on a
+// dark background, followed by "(mirrored) " for a mirrored one
+func readNotes(qr *qr) string {
+	var notes string
+	if qr.reversed {
+		notes += "(inverted) "
+	}
+	if qr.mirrored {
+		notes += "(mirrored) "
+	}
+	return notes
+}
+
+// decodeAll prints every symbol found in b, then the messages of the
+// structured append sequences among them
+func decodeAll(b *images.BitImage) {
+	symbols := DecodeAllBitImage(b)
+	if len(symbols) == 0 {
+		fmt.Println("Error: no symbol found")
+		os.Exit(1)
+	}
+	var decoded []*qr
+	for i, symbol := range symbols {
+		c := symbol.Corners
+		fmt.Printf("Symbol %d at (%.0f,%.0f) (%.0f,%.0f) (%.0f,%.0f) (%.0f,%.0f): ",
+			i+1, c[0].X, c[0].Y, c[1].X, c[1].Y, c[2].X, c[2].Y, c[3].X, c[3].Y)
+		if symbol.Err != nil {
+			fmt.Println("Error:", symbol.Err)
+			continue
+		}
+		fmt.Println(readNotes(symbol.QR) + string(symbol.QR.data))
+		decoded = append(decoded, symbol.QR)
+	}
+
+	messages, _ := JoinStructuredAppend(decoded)
+	for _, m := range messages {
+		if missing := m.Missing(); len(missing) > 0 {
+			for i := range missing {
+				missing[i]++
+			}
+			fmt.Printf("Structured append %02X: missing symbols %v of %d\n", m.Parity, missing, len(m.Symbols))
+			continue
+		}
+		fmt.Printf("Structured append %02X: %s\n", m.Parity, m.Data)
+	}
+}
+
+// gradeCommand prints the print quality grades of the symbol of an image file
+func gradeCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Println("Error: missing image file")
+		os.Exit(1)
+	}
+	img, err := images.Load(args[0])
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	report, err := GradeImage(img)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	if report.Reversed {
+		fmt.Printf("Decode:                  %v (inverted)\n", report.Decode)
+	} else {
+		fmt.Printf("Decode:                  %v\n", report.Decode)
+	}
+	for _, m := range []struct {
+		name    string
+		measure Measure
+	}{
+		{"Symbol contrast", report.SymbolContrast},
+		{"Modulation", report.Modulation},
+		{"Reflectance margin", report.ReflectanceMargin},
+		{"Fixed pattern damage", report.FixedPatternDamage},
+		{"Axial non-uniformity", report.AxialNonuniformity},
+		{"Grid non-uniformity", report.GridNonuniformity},
+		{"Unused error correction", report.UnusedErrorCorrection},
+	} {
+		fmt.Printf("%-24s %v (%.2f)\n", m.name+":", m.measure.Grade, m.measure.Value)
+	}
+	fmt.Printf("Overall:                 %v\n", report.Overall)
+}
+
+// wifiCommand draws a Wi-Fi network configuration symbol. Unlike the other
+// commands, flags also take their value as the next argument, e.g.
+// --ssid "Room 4".
+func wifiCommand(args []string) {
+	valued := []string{"--ssid", "--pass", "--security", "--eap", "--identity", "--anonymous-identity", "--phase2", "--level", "--shape"}
+	values := map[string]string{}
+	var w payload.WiFi
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		switch {
+		case name == "--hidden":
+			w.Hidden = true
+		case name == "--wpa3-only":
+			w.TransitionDisable = true
+		case !slices.Contains(valued, name):
+			fmt.Println("Error: unexpected argument", args[i])
+			os.Exit(1)
+		case hasValue:
+			values[name] = value
+		case i+1 < len(args):
+			i++
+			values[name] = args[i]
+		default:
+			fmt.Println("Error: missing value for", name)
+			os.Exit(1)
+		}
+	}
+
+	w.SSID = values["--ssid"]
+	w.Password = values["--pass"]
+	w.EAPMethod = values["--eap"]
+	w.Identity = values["--identity"]
+	w.AnonymousIdentity = values["--anonymous-identity"]
+	w.Phase2Method = values["--phase2"]
+	switch {
+	case values["--security"] != "":
+		security, err := payload.ParseWiFiSecurity(values["--security"])
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		w.Security = security
+	case w.EAPMethod != "":
+		w.Security = payload.WiFiWPA2EAP
+	case w.Password != "":
+		w.Security = payload.WiFiWPA
+	default:
+		w.Security = payload.WiFiOpen
+	}
+
+	err_corr_level := cmp.Or(values["--level"], ERR_CORR_L)
+	if !slices.Contains(errCorrLevels, errcorr(err_corr_level)) {
+		fmt.Println("Error: could not parse error correction level", err_corr_level)
+		os.Exit(1)
+	}
+	req, err := NewWiFiRequest(w, err_corr_level)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	qr := NewQRCode(req)
+	qr.DebugPrint()
+	qr.Draw(writer.Shape(cmp.Or(values["--shape"], string(writer.ShapeSquare))))
+}
+
+// robustnessCommand prints the decode margins of the symbols of some data
+func robustnessCommand(args []string) {
+	flags, args := splitFlags(args)
+	if len(args) == 0 {
+		fmt.Println("Error: missing data")
+		os.Exit(1)
+	}
+	req := QRRequest{input_data: args[0]}
+	if len(args) > 1 {
+		v, err := strconv.Atoi(args[1])
+		if err != nil {
+			fmt.Println("Error: could not parse version", args[1])
+			os.Exit(1)
+		}
+		req.version = v
+	}
+
+	req.reflectance_reversal = slices.Contains(flags, "--reverse")
+	req.mirrored = slices.Contains(flags, "--mirror")
+
+	models := DamageModels
+	trials := 5
+	var seed uint64 = 1
+	var shape writer.Shape = writer.ShapeSquare
+	for _, arg := range flags {
+		var err error
+		if name, ok := strings.CutPrefix(arg, "--shape="); ok {
+			shape = writer.Shape(name)
+		}
+		if path, ok := strings.CutPrefix(arg, "--logo="); ok {
+			req.logo = path
+		}
+		if model, ok := strings.CutPrefix(arg, "--model="); ok {
+			models = []DamageModel{DamageModel(model)}
+		}
+		if n, ok := strings.CutPrefix(arg, "--trials="); ok {
+			trials, err = strconv.Atoi(n)
+			if err == nil && trials < 1 {
+				err = errors.New("at least 1 trial is needed")
+			}
+		}
+		if n, ok := strings.CutPrefix(arg, "--seed="); ok {
+			seed, err = strconv.ParseUint(n, 10, 64)
+		}
+		if err != nil {
+			fmt.Println("Error: could not parse", arg)
+			os.Exit(1)
+		}
+	}
+
+	results, err := Robustness(req, shape, models, trials, seed)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	fmt.Println("Level Mask Model      Threshold")
+	for _, r := range results {
+		fmt.Printf("%-5s %-4d %-10s %.2f\n", r.ECLevel, r.Mask, r.Model, r.Threshold)
+	}
+}
+
+// corpusCommand writes a regression corpus of distorted images of the
+// payloads of a file
+func corpusCommand(args []string) {
+	flags, args := splitFlags(args)
+	if len(args) < 2 {
+		fmt.Println("Error: missing payload file or directory")
+		os.Exit(1)
+	}
+	content, err := os.ReadFile(args[0])
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	var payloads []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			payloads = append(payloads, line)
+		}
+	}
+
+	var ecLevel errcorr = ERR_CORR_M
+	if len(args) > 2 {
+		ecLevel = errcorr(args[2])
+		if !slices.Contains(errCorrLevels, ecLevel) {
+			fmt.Println("Error: could not parse error correction level", args[2])
+			os.Exit(1)
+		}
+	}
+	var seed uint64 = 1
+	for _, arg := range flags {
+		if n, ok := strings.CutPrefix(arg, "--seed="); ok {
+			if seed, err = strconv.ParseUint(n, 10, 64); err != nil {
+				fmt.Println("Error: could not parse", arg)
+				os.Exit(1)
+			}
+		}
+	}
+
+	manifest, err := GenerateCorpus(args[1], payloads, ecLevel, seed)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%d images written to %s\n", len(manifest.Entries), args[1])
+}
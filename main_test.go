@@ -1,7 +1,25 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+
+	"github.com/harogaston/qr-decoder/bitseq"
+	"github.com/harogaston/qr-decoder/images"
+	"github.com/harogaston/qr-decoder/modes"
+	"github.com/harogaston/qr-decoder/version"
+	"github.com/harogaston/qr-decoder/writer"
 )
 
 func TestInterleaving(t *testing.T) {
@@ -63,3 +81,1246 @@ func TestAlphanumeric(t *testing.T) {
 		t.Errorf("Expected Version 1, got %d", qr.version.Number)
 	}
 }
+
+func TestKanji(t *testing.T) {
+	// Example from ISO/IEC 18004:2024 Section 7.4.6
+	// 点 (0x935F) -> 0x0D9F
+	// 茗 (0xE4AA) -> 0x1AAA
+	input := "点茗"
+
+	if mode := modes.GetMode(input); mode != modes.KanjiMode {
+		t.Fatalf("GetMode(%q) = %s, want Kanji", input, mode)
+	}
+
+	want := "0110110011111" + "1101010101010"
+	if got := modes.EncodeKanji(input).String(); got != want {
+		t.Errorf("EncodeKanji(%q) = %s, want %s", input, got, want)
+	}
+
+	// The same characters given as raw Shift JIS bytes
+	raw := string([]byte{0x93, 0x5F, 0xE4, 0xAA})
+	if got := modes.EncodeKanji(raw).String(); got != want {
+		t.Errorf("EncodeKanji(raw Shift JIS) = %s, want %s", got, want)
+	}
+
+	qr := NewQRCode(QRRequest{
+		input_data:     input,
+		err_corr_level: ERR_CORR_L,
+	})
+	if len(qr.segments) != 1 || qr.segments[0].Mode != modes.KanjiMode {
+		t.Errorf("Expected a single Kanji segment, got %v", qr.segments)
+	}
+
+	// Character count is the number of Kanji, not the number of bytes
+	if got := character_count(modes.KanjiMode, qr.version, input).String(); got != "00000010" {
+		t.Errorf("character_count = %s, want 00000010", got)
+	}
+}
+
+func TestHanzi(t *testing.T) {
+	// 啊 (0xB0A1) -> 0x0A00 -> 0x0A * 0x60 + 0x00 = 960
+	// 中 (0xD6D0) -> 0x302F -> 0x30 * 0x60 + 0x2F = 4655
+	want := "0001111000000" + "1001000101111"
+	if got := modes.EncodeHanzi("啊中").String(); got != want {
+		t.Errorf("EncodeHanzi = %s, want %s", got, want)
+	}
+	if got := modes.EncodeHanzi(string([]byte{0xB0, 0xA1, 0xD6, 0xD0})).String(); got != want {
+		t.Errorf("EncodeHanzi(raw GB 2312) = %s, want %s", got, want)
+	}
+	// GB 2312 ends at row 0xF7, 0xF8A1 is in the GBK user-defined area
+	if _, ok := modes.ToGB2312(string([]byte{0xF7, 0xFE, 0xF8, 0xA1})); ok {
+		t.Error("ToGB2312 accepted 0xF8A1")
+	}
+
+	// 们 and 这 are not in Shift JIS
+	input := "我们这里"
+	if mode := modes.GetMode(input); mode != modes.HanziMode {
+		t.Fatalf("GetMode(%q) = %s, want Hanzi", input, mode)
+	}
+	qr := NewQRCode(QRRequest{input_data: input, err_corr_level: ERR_CORR_L})
+	if len(qr.segments) != 1 || qr.segments[0].Mode != modes.HanziMode {
+		t.Fatalf("Expected a single Hanzi segment, got %v", qr.segments)
+	}
+	// Mode indicator, subset indicator and the number of characters
+	if got := qr.encoded_data.String()[:16]; got != "1101"+"0001"+"00000100" {
+		t.Errorf("Hanzi header = %s", got)
+	}
+
+	// Characters shared with Kanji mode stay in Kanji mode, with a shorter header
+	if segments, _ := GetSegments("点茗", qr.version, modes.DetectCharset("点茗"), false); segments[0].Mode != modes.KanjiMode {
+		t.Errorf("Expected Kanji mode, got %v", segments)
+	}
+
+	// Not available in Micro QR
+	if _, _, err := analyze(QRRequest{input_data: input, err_corr_level: ERR_CORR_L, is_micro: true}); err == nil {
+		t.Error("Expected an error for Hanzi in Micro QR")
+	}
+}
+
+func TestSegments(t *testing.T) {
+	v1 := version.QRVersion{Format: version.FORMAT_QR_MODEL_2, Number: 1}
+	v10 := version.QRVersion{Format: version.FORMAT_QR_MODEL_2, Number: 10}
+
+	tests := []struct {
+		name    string
+		input   string
+		version version.QRVersion
+		want    []modes.Segment
+	}{
+		{
+			name:    "Mixed input",
+			input:   "ORDER 12345678901234 for alice@example.com",
+			version: v1,
+			want: []modes.Segment{
+				{Mode: modes.AlphanumericMode, Data: "ORDER "},
+				{Mode: modes.NumericMode, Data: "12345678901234"},
+				{Mode: modes.ByteMode, Data: " for alice@example.com"},
+			},
+		},
+		{
+			// A short digit run is cheaper inside the alphanumeric segment
+			name:    "Short numeric run",
+			input:   "ABC123456789DEF",
+			version: v1,
+			want: []modes.Segment{
+				{Mode: modes.AlphanumericMode, Data: "ABC123456789DEF"},
+			},
+		},
+		{
+			// Byte segment headers: (4+8+8) + (4+10+20) + (4+8+8) = 74 < 4+8+64 = 76
+			name:    "Version 1 to 9 class",
+			input:   "a123456b",
+			version: v1,
+			want: []modes.Segment{
+				{Mode: modes.ByteMode, Data: "a"},
+				{Mode: modes.NumericMode, Data: "123456"},
+				{Mode: modes.ByteMode, Data: "b"},
+			},
+		},
+		{
+			// Byte segment headers: (4+16+8) + (4+12+20) + (4+16+8) = 92 > 4+16+64 = 84
+			name:    "Version 10 to 26 class",
+			input:   "a123456b",
+			version: v10,
+			want: []modes.Segment{
+				{Mode: modes.ByteMode, Data: "a123456b"},
+			},
+		},
+		{
+			name:    "Kanji",
+			input:   "点茗 0123456",
+			version: v1,
+			want: []modes.Segment{
+				{Mode: modes.KanjiMode, Data: "点茗"},
+				{Mode: modes.AlphanumericMode, Data: " "},
+				{Mode: modes.NumericMode, Data: "0123456"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetSegments(tt.input, tt.version, modes.DetectCharset(tt.input), false)
+			if err != nil {
+				t.Fatalf("GetSegments(%q) error = %v", tt.input, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("GetSegments(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	// NewQRCode emits one header per segment
+	qr := NewQRCode(QRRequest{
+		input_data:     "ORDER 12345678901234 for alice@example.com",
+		err_corr_level: ERR_CORR_L,
+	})
+	if len(qr.segments) != 3 {
+		t.Errorf("Expected 3 segments, got %v", qr.segments)
+	}
+	want := encodeSegments(qr.segments, qr.version).String()
+	if got := qr.encoded_data.String()[:len(want)]; got != want {
+		t.Errorf("encoded data does not start with the segment headers")
+	}
+	if !strings.HasPrefix(want, "0010000000110") {
+		t.Errorf("first segment header = %s, want alphanumeric with count 6", want[:13])
+	}
+}
+
+func TestECI(t *testing.T) {
+	designators := []struct {
+		assignment int
+		want       string
+	}{
+		{26, "00011010"},
+		{1000, "1000001111101000"},
+		{100000, "110000011000011010100000"},
+	}
+	for _, d := range designators {
+		if got := modes.EncodeECIDesignator(d.assignment).String(); got != d.want {
+			t.Errorf("EncodeECIDesignator(%d) = %s, want %s", d.assignment, got, d.want)
+		}
+	}
+	for _, assignment := range []int{-1, 1000000} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("EncodeECIDesignator(%d) did not panic", assignment)
+				}
+			}()
+			modes.EncodeECIDesignator(assignment)
+		}()
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		charset string
+		want    []modes.Segment
+	}{
+		{
+			// Latin-1 text is transcoded, no header needed
+			name:  "Latin-1",
+			input: "Zoë",
+			want: []modes.Segment{
+				{Mode: modes.ByteMode, Data: "Zo\xeb"},
+			},
+		},
+		{
+			// Latin Extended-A is outside ISO/IEC 8859-1, so UTF-8 is picked
+			// with ECI 26
+			name:  "Automatic UTF-8",
+			input: "Łódź",
+			want: []modes.Segment{
+				{Mode: modes.ECI, ECI: 26},
+				{Mode: modes.ByteMode, Data: "Łódź"},
+			},
+		},
+		{
+			name:    "Explicit ISO-8859-5",
+			input:   "Привет",
+			charset: "ISO-8859-5",
+			want: []modes.Segment{
+				{Mode: modes.ECI, ECI: 7},
+				{Mode: modes.ByteMode, Data: "\xbf\xe0\xd8\xd2\xd5\xe2"},
+			},
+		},
+		{
+			// Kanji mode does not depend on the byte mode charset
+			name:  "Kanji only",
+			input: "点茗",
+			want: []modes.Segment{
+				{Mode: modes.KanjiMode, Data: "点茗"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qr := NewQRCode(QRRequest{
+				input_data:     tt.input,
+				err_corr_level: ERR_CORR_L,
+				charset:        tt.charset,
+			})
+			if !slices.Equal(qr.segments, tt.want) {
+				t.Errorf("segments = %v, want %v", qr.segments, tt.want)
+			}
+		})
+	}
+
+	// GBK characters outside of GB 2312 are not encoded as GB 2312
+	gb2312, _ := modes.GetCharset("GB2312")
+	if data, err := gb2312.Encode("中文 abc"); err != nil || string(data) != "\xd6\xd0\xce\xc4 abc" {
+		t.Errorf("GB2312 Encode(中文 abc) = %q, %v", data, err)
+	}
+	if _, err := gb2312.Encode("丂"); err == nil {
+		t.Error("GB2312 encoded the GBK only character 丂")
+	}
+
+	// The ECI header counts towards the symbol size: 17 bytes of UTF-8 fit
+	// version 1-L without it (4 + 8 + 136 = 148 <= 152 bits)
+	input := "őabcdefghijklmno"
+	if qr := NewQRCode(QRRequest{input_data: input, err_corr_level: ERR_CORR_L}); qr.version.Number != 2 {
+		t.Errorf("Expected version 2 with the ECI header, got %d (%v)", qr.version.Number, qr.segments)
+	}
+}
+
+func TestStructuredAppend(t *testing.T) {
+	if got := modes.EncodeStructuredAppend(2, 4, 0x5A).String(); got != "0010"+"0011"+"01011010" {
+		t.Errorf("EncodeStructuredAppend(2, 4, 0x5A) = %s", got)
+	}
+
+	// 4000 bytes do not fit in version 40-L (2953 bytes)
+	input := strings.Repeat("structured append 0123456789 ", 138)
+	if _, _, err := analyze(QRRequest{input_data: input, err_corr_level: ERR_CORR_L}); err == nil {
+		t.Fatalf("Expected input of %d bytes not to fit in a single symbol", len(input))
+	}
+
+	sequence, err := NewStructuredAppend(QRRequest{input_data: input, err_corr_level: ERR_CORR_L}, 0)
+	if err != nil {
+		t.Fatalf("NewStructuredAppend() error = %v", err)
+	}
+	if len(sequence) != 2 {
+		t.Errorf("Expected 2 symbols, got %d", len(sequence))
+	}
+
+	parity := modes.StructuredAppendParity([]byte(input))
+	var reassembled strings.Builder
+	for i, qr := range sequence {
+		header := qr.segments[0]
+		if header.Mode != modes.StructuredAppend || header.Position != i || header.Total != len(sequence) || header.Parity != parity {
+			t.Errorf("symbol %d: unexpected header %v", i+1, header)
+		}
+		for _, s := range qr.segments[1:] {
+			reassembled.WriteString(s.Data)
+		}
+	}
+	if reassembled.String() != input {
+		t.Errorf("Reassembled data does not match input")
+	}
+
+	// Small parts leave room for a higher error correction level
+	sequence, err = NewStructuredAppend(QRRequest{input_data: "HELLO WORLD", err_corr_level: ERR_CORR_L}, 3)
+	if err != nil {
+		t.Fatalf("NewStructuredAppend() error = %v", err)
+	}
+	for i, qr := range sequence {
+		if qr.version.Number != 1 || qr.error_corr_level != ERR_CORR_H {
+			t.Errorf("symbol %d: got %s, want 1-H", i+1, qr.FullVersion())
+		}
+	}
+
+	if _, err := NewStructuredAppend(QRRequest{input_data: input, err_corr_level: ERR_CORR_L}, 17); err == nil {
+		t.Errorf("Expected an error for 17 symbols")
+	}
+
+	// Kanji mode parity is computed over the Shift JIS bytes, not UTF-8
+	sequence, err = NewStructuredAppend(QRRequest{input_data: "点茗", err_corr_level: ERR_CORR_L}, 2)
+	if err != nil {
+		t.Fatalf("NewStructuredAppend() error = %v", err)
+	}
+	sjis, _ := modes.ToShiftJIS("点茗")
+	for i, qr := range sequence {
+		if header := qr.segments[0]; header.Parity != modes.StructuredAppendParity(sjis) || qr.segments[len(qr.segments)-1].Mode != modes.KanjiMode {
+			t.Errorf("symbol %d: segments %v, want Kanji with parity 0x%02X", i+1, qr.segments, modes.StructuredAppendParity(sjis))
+		}
+	}
+}
+
+func TestMicroQR(t *testing.T) {
+	// Example from ISO/IEC 18004 Annex I: "01234567" in M2-L
+	qr := NewQRCode(QRRequest{input_data: "01234567", err_corr_level: ERR_CORR_L, is_micro: true})
+	if qr.FullVersion() != "M2-L" || qr.size != 13 {
+		t.Fatalf("got %s (size %d), want M2-L (size 13)", qr.FullVersion(), qr.size)
+	}
+	data := qr.encoded_data.Bytes(bitseq.MSBFirst)
+	if want := []byte{0x40, 0x18, 0xAC, 0xC3, 0x00}; !slices.Equal(data, want) {
+		t.Errorf("data codewords = %X, want %X", data, want)
+	}
+	if ec, want := reedSolomonEncode(data, 5), []byte{0x86, 0x0D, 0x22, 0xAE, 0x30}; !slices.Equal(ec, want) {
+		t.Errorf("EC codewords = %X, want %X", ec, want)
+	}
+
+	// Timing patterns run along row and column 0
+	for k := 8; k < qr.size; k++ {
+		want := Zero
+		if k%2 == 0 {
+			want = One
+		}
+		if qr.matrix[0][k].bit != want || qr.matrix[k][0].bit != want {
+			t.Errorf("timing pattern module %d is not %v", k, want)
+		}
+	}
+
+	tests := []struct {
+		input   string
+		ecLevel string
+		want    string
+	}{
+		{"12345", ERR_CORR_L, "M1-L"},
+		{"HELLO", ERR_CORR_L, "M2-L"},
+		{"hello", ERR_CORR_L, "M3-L"},
+		{"点茗", ERR_CORR_M, "M3-M"},
+		{"123456789012345678901", ERR_CORR_Q, "M4-Q"},
+	}
+	for _, tt := range tests {
+		qr := NewQRCode(QRRequest{input_data: tt.input, err_corr_level: tt.ecLevel, is_micro: true})
+		if qr.FullVersion() != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.input, qr.FullVersion(), tt.want)
+		}
+		// M1 and M3 end with a 4-bit data codeword
+		if qr.encoded_data.Len() != getDataCapacityBits(qr.version, qr.error_corr_level) {
+			t.Errorf("%q: %d data bits, want %d", tt.input, qr.encoded_data.Len(), getDataCapacityBits(qr.version, qr.error_corr_level))
+		}
+	}
+
+	// M1 has a 3-bit terminator and a 0000 final codeword
+	qr = NewQRCode(QRRequest{input_data: "12", err_corr_level: ERR_CORR_L, is_micro: true})
+	if got := qr.encoded_data.String(); got != "010"+"0001100"+"000"+"000"+"0000" {
+		t.Errorf("M1 bit stream = %s", got)
+	}
+
+	invalid := []QRRequest{
+		{input_data: "1", err_corr_level: ERR_CORR_H, is_micro: true},
+		{input_data: "Łódź", err_corr_level: ERR_CORR_L, is_micro: true},
+		{input_data: strings.Repeat("1", 36), err_corr_level: ERR_CORR_L, is_micro: true},
+		{input_data: "1", err_corr_level: ERR_CORR_L, is_micro: true, version: 5},
+	}
+	for _, r := range invalid {
+		if _, _, err := analyze(r); err == nil {
+			t.Errorf("Expected an error for %q", r.input_data)
+		}
+	}
+}
+
+func TestRMQR(t *testing.T) {
+	// Every module outside of the function patterns holds a codeword bit,
+	// except for fewer than 8 remainder bits
+	for n := 1; n <= version.RMQRVersionCount; n++ {
+		qr := NewQRCode(QRRequest{input_data: "1", err_corr_level: ERR_CORR_M, is_rmqr: true, version: n})
+		free := 0
+		for i := range qr.height {
+			for j := range qr.width {
+				if !qr.is_function_pattern[i][j] {
+					free++
+				}
+			}
+		}
+		if remainder := free - 8*getTotalCodewords(qr.version); remainder < 0 || remainder > 7 {
+			t.Errorf("%s: %d free modules for %d codewords", qr.version, free, getTotalCodewords(qr.version))
+		}
+	}
+
+	qr := NewQRCode(QRRequest{input_data: "HELLO", err_corr_level: ERR_CORR_M, is_rmqr: true, rmqr_height: 7})
+	if qr.FullVersion() != "R7x43-M" || qr.width != 43 || qr.height != 7 {
+		t.Fatalf("got %s (size %dx%d), want R7x43-M (size 43x7)", qr.FullVersion(), qr.width, qr.height)
+	}
+	// 3-bit mode indicator and 3-bit character count in R7x43
+	if got := qr.encoded_data.String()[:6]; got != "010"+"101" {
+		t.Errorf("header = %s, want 010101", got)
+	}
+
+	tests := []struct {
+		input   string
+		ecLevel string
+		height  int
+		want    string
+	}{
+		// Smallest area first: R11x27 has fewer modules than R7x43
+		{"HELLO", ERR_CORR_M, 0, "R11x27-M"},
+		{"HELLO WORLD", ERR_CORR_H, 0, "R11x43-H"},
+		{strings.Repeat("1", 100), ERR_CORR_M, 0, "R11x77-M"},
+		{strings.Repeat("1", 100), ERR_CORR_M, 17, "R17x59-M"},
+	}
+	for _, tt := range tests {
+		qr := NewQRCode(QRRequest{input_data: tt.input, err_corr_level: tt.ecLevel, is_rmqr: true, rmqr_height: tt.height})
+		if qr.FullVersion() != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.input, qr.FullVersion(), tt.want)
+		}
+	}
+
+	if v, err := version.ParseRMQR("R13x99"); err != nil || v.Number != 21 {
+		t.Errorf("ParseRMQR(R13x99) = %v, %v, want version 21", v, err)
+	}
+	if _, err := version.ParseRMQR("R7x27"); err == nil {
+		t.Error("Expected an error for R7x27")
+	}
+
+	invalid := []QRRequest{
+		{input_data: "1", err_corr_level: ERR_CORR_L, is_rmqr: true},
+		{input_data: "1", err_corr_level: ERR_CORR_M, is_rmqr: true, version: 33},
+		{input_data: "1", err_corr_level: ERR_CORR_M, is_rmqr: true, rmqr_height: 8},
+		{input_data: "1", err_corr_level: ERR_CORR_M, is_rmqr: true, version: 1, rmqr_height: 9},
+		{input_data: strings.Repeat("1", 400), err_corr_level: ERR_CORR_H, is_rmqr: true},
+	}
+	for _, r := range invalid {
+		if _, _, err := analyze(r); err == nil {
+			t.Errorf("Expected an error for %+v", r)
+		}
+	}
+}
+
+func TestReversalAndMirroring(t *testing.T) {
+	plain := NewQRCode(QRRequest{input_data: "HELLO", err_corr_level: ERR_CORR_M})
+	qr := NewQRCode(QRRequest{input_data: "HELLO", err_corr_level: ERR_CORR_M, mirrored: true, reflectance_reversal: true})
+	for i := range qr.size {
+		for j := range qr.size {
+			if qr.matrix[i][j] != plain.matrix[i][qr.size-1-j] {
+				t.Fatalf("module (%d, %d) is not mirrored", i, j)
+			}
+		}
+	}
+
+	req := qr.svgRequest(writer.ShapeSquare)
+	want := []image.Point{{X: qr.size - 7, Y: 0}, {X: 0, Y: 0}, {X: qr.size - 7, Y: qr.size - 7}}
+	if !slices.Equal(req.Finders, want) {
+		t.Errorf("finders = %v, want %v", req.Finders, want)
+	}
+	if req.Color != color.White || req.Background != color.Black {
+		t.Errorf("colors = %v on %v, want white on black", req.Color, req.Background)
+	}
+
+	// The single Micro QR finder pattern moves to the upper right corner
+	micro := NewQRCode(QRRequest{input_data: "1", err_corr_level: ERR_CORR_L, is_micro: true, mirrored: true})
+	if finders := micro.svgRequest(writer.ShapeSquare).Finders; !slices.Equal(finders, []image.Point{{X: micro.size - 7, Y: 0}}) {
+		t.Errorf("Micro QR finders = %v", finders)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     QRRequest
+		version int
+	}{
+		{"Numeric", QRRequest{input_data: "01234567", err_corr_level: ERR_CORR_H}, 1},
+		{"Alphanumeric", QRRequest{input_data: "HELLO WORLD", err_corr_level: ERR_CORR_Q}, 1},
+		{"Latin-1", QRRequest{input_data: "Zoë", err_corr_level: ERR_CORR_M}, 1},
+		{"UTF-8 with ECI", QRRequest{input_data: "Łódź", err_corr_level: ERR_CORR_L}, 1},
+		{"Kanji", QRRequest{input_data: "点茗", err_corr_level: ERR_CORR_L}, 1},
+		{"Hanzi", QRRequest{input_data: "汉字编码", err_corr_level: ERR_CORR_L}, 1},
+		{"Mixed", QRRequest{input_data: "Order 123456789012345 ABCDEFGHIJ", err_corr_level: ERR_CORR_M}, 2},
+		{
+			"GS1",
+			QRRequest{
+				input_data:     "01095011010209171719050810ABCD1234\x1d2110",
+				err_corr_level: ERR_CORR_L,
+				fnc1:           &modes.Segment{Mode: modes.FNC1FirstPosition},
+			},
+			2,
+		},
+		// Version information and blocks of two lengths
+		{"Multiple blocks", QRRequest{input_data: strings.Repeat("lorem ipsum ", 25), err_corr_level: ERR_CORR_Q}, 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := NewQRCode(tt.req)
+			if encoded.version.Number != tt.version {
+				t.Fatalf("encoded in version %d, want %d", encoded.version.Number, tt.version)
+			}
+			decoded, err := DecodeMatrix(encoded.matrix)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(decoded.data) != tt.req.input_data {
+				t.Errorf("data = %q, want %q", decoded.data, tt.req.input_data)
+			}
+			if decoded.version != encoded.version || decoded.error_corr_level != encoded.error_corr_level || decoded.mask != encoded.mask {
+				t.Errorf("decoded %v-%s mask %d, want %v-%s mask %d",
+					decoded.version, decoded.error_corr_level, decoded.mask,
+					encoded.version, encoded.error_corr_level, encoded.mask)
+			}
+			if !slices.Equal(decoded.segments, encoded.segments) {
+				t.Errorf("segments = %v, want %v", decoded.segments, encoded.segments)
+			}
+		})
+	}
+
+	// Errors in the format information and the data are corrected
+	qr := NewQRCode(QRRequest{input_data: "HELLO WORLD", err_corr_level: ERR_CORR_Q})
+	grid := make([][]bool, qr.size)
+	for i := range grid {
+		grid[i] = make([]bool, qr.size)
+		for j := range grid[i] {
+			grid[i][j] = qr.matrix[i][j].bit == One
+		}
+	}
+	for _, pos := range [][2]int{{8, 0}, {8, 2}, {0, 8}, {qr.size - 1, 8}, {8, qr.size - 1}, {20, 20}, {15, 18}, {9, 12}} {
+		grid[pos[0]][pos[1]] = !grid[pos[0]][pos[1]]
+	}
+	decoded, err := DecodeGrid(grid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded.data) != "HELLO WORLD" || decoded.mask != qr.mask {
+		t.Errorf("DecodeGrid = %q mask %d, want %q mask %d", decoded.data, decoded.mask, "HELLO WORLD", qr.mask)
+	}
+
+	// Unreadable format information
+	for j := range 6 {
+		grid[8][j] = !grid[8][j]
+		grid[qr.size-1-j][8] = !grid[qr.size-1-j][8]
+	}
+	if _, err := DecodeGrid(grid); err == nil {
+		t.Error("expected an error for unreadable format information")
+	}
+
+	// No QR Code version has 22 modules
+	grid = make([][]bool, 22)
+	for i := range grid {
+		grid[i] = make([]bool, 22)
+	}
+	if _, err := DecodeGrid(grid); err == nil {
+		t.Error("expected an error for a 22 x 22 grid")
+	}
+}
+
+// rasterize renders the symbol with the given module size in pixels, rotated
+// by angle degrees around the center of the image, which leaves at least 4
+// modules of quiet zone at any angle
+func rasterize(qr *qr, moduleSize int, angle float64) *image.Gray {
+	side := int(math.Ceil(float64((qr.size+8)*moduleSize) * math.Sqrt2))
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	return render(qr, side, func(dx, dy float64) (float64, float64) {
+		return (cos*dx + sin*dy) / float64(moduleSize), (-sin*dx + cos*dy) / float64(moduleSize)
+	})
+}
+
+// render draws the symbol in a side x side image. module maps the offset of
+// a pixel from the center of the image to the offset in modules from the
+// center of the symbol.
+func render(qr *qr, side int, module func(dx, dy float64) (float64, float64)) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	c := float64(side) / 2
+	for y := range side {
+		for x := range side {
+			u, v := module(float64(x)+0.5-c, float64(y)+0.5-c)
+			i := int(math.Floor(v + float64(qr.size)/2))
+			j := int(math.Floor(u + float64(qr.size)/2))
+			img.SetGray(x, y, color.Gray{Y: 255})
+			if i >= 0 && j >= 0 && i < qr.size && j < qr.size && qr.matrix[i][j].bit == One {
+				img.SetGray(x, y, color.Gray{Y: 0})
+			}
+		}
+	}
+	return img
+}
+
+func TestDecodeImage(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		ecLevel    string
+		moduleSize int
+		angle      float64
+	}{
+		{"Upright", "HELLO WORLD", ERR_CORR_Q, 4, 0},
+		{"Upside down", "https://example.com/", ERR_CORR_M, 5, 180},
+		{"Rotated", "01234567890123456789", ERR_CORR_H, 6, 30},
+		{"Version 10", strings.Repeat("Lorem ipsum ", 18), ERR_CORR_L, 4, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qr := NewQRCode(QRRequest{input_data: tt.input, err_corr_level: tt.ecLevel})
+			decoded, err := DecodeImage(rasterize(qr, tt.moduleSize, tt.angle), images.Binarizer{})
+			if err != nil {
+				t.Fatalf("version %d: %v", qr.version.Number, err)
+			}
+			if string(decoded.data) != tt.input {
+				t.Errorf("data = %q, want %q", decoded.data, tt.input)
+			}
+		})
+	}
+
+	// A blank image has no finder patterns
+	blank := image.NewGray(image.Rect(0, 0, 100, 100))
+	if _, err := DecodeImage(blank, images.Binarizer{}); err == nil {
+		t.Error("expected an error for a blank image")
+	}
+}
+
+func TestBinarization(t *testing.T) {
+	qr := NewQRCode(QRRequest{input_data: "https://example.com/shadow", err_corr_level: ERR_CORR_M})
+	img := rasterize(qr, 4, 10)
+
+	// A shadow darkening the image from left to right, the light modules
+	// on the right are darker than the dark modules on the left
+	shadow := image.NewRGBA(img.Bounds())
+	for y := range img.Bounds().Dy() {
+		for x := range img.Bounds().Dx() {
+			level := 40 + float64(img.GrayAt(x, y).Y)
+			level *= 1 - 0.85*float64(x)/float64(img.Bounds().Dx())
+			shadow.Set(x, y, color.Gray{Y: uint8(level)})
+		}
+	}
+	for _, tt := range []struct {
+		binarization images.Binarization
+		wantErr      bool
+	}{
+		{images.BinarizationOtsu, true},
+		{images.BinarizationHybrid, false},
+		{images.BinarizationSauvola, false},
+	} {
+		decoded, err := DecodeImage(shadow, images.Binarizer{Binarization: tt.binarization})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.binarization, err, tt.wantErr)
+		}
+		if err == nil && string(decoded.data) != "https://example.com/shadow" {
+			t.Errorf("%s: data = %q", tt.binarization, decoded.data)
+		}
+	}
+
+	// Red ink on a yellow label disappears in the red channel
+	red, yellow := color.RGBA{R: 230, G: 40, B: 40, A: 255}, color.RGBA{R: 230, G: 230, B: 40, A: 255}
+	label := image.NewRGBA(img.Bounds())
+	for y := range img.Bounds().Dy() {
+		for x := range img.Bounds().Dx() {
+			label.Set(x, y, yellow)
+			if img.GrayAt(x, y).Y == 0 {
+				label.Set(x, y, red)
+			}
+		}
+	}
+	if _, err := DecodeImage(label, images.Binarizer{Channel: images.ChannelGreen}); err != nil {
+		t.Errorf("green channel: %v", err)
+	}
+	if _, err := DecodeImage(label, images.Binarizer{Channel: images.ChannelRed}); err == nil {
+		t.Error("expected an error for the red channel")
+	}
+	if _, err := DecodeImage(label, images.Binarizer{Channel: "infrared"}); err == nil {
+		t.Error("expected an error for an unknown channel")
+	}
+
+	// Transparent pixels are composited over white
+	transparent := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	transparent.SetNRGBA(0, 0, color.NRGBA{R: 255, G: 255, B: 255, A: 128})
+	transparent.SetNRGBA(1, 0, color.NRGBA{A: 128})
+	gray, err := images.Grayscale(transparent, images.ChannelLuminance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if white, black := gray.GrayAt(0, 0).Y, gray.GrayAt(1, 0).Y; white != 255 || black != 127 {
+		t.Errorf("Grayscale() = %d and %d, want 255 and 127", white, black)
+	}
+}
+
+func TestAlignmentSampling(t *testing.T) {
+	// Version 26 wrapped around a bottle: modules get narrower toward the
+	// sides, which a single perspective transform cannot follow
+	input := strings.Repeat("a", 1000)
+	qr := NewQRCode(QRRequest{input_data: input, err_corr_level: ERR_CORR_M})
+	const moduleSize = 4.0
+	radius := 0.8 * float64(qr.size) * moduleSize
+	img := render(qr, (qr.size+10)*moduleSize, func(dx, dy float64) (float64, float64) {
+		if math.Abs(dx) >= radius {
+			return math.Inf(1), 0
+		}
+		return radius * math.Asin(dx/radius) / moduleSize, dy / moduleSize
+	})
+
+	decoded, err := DecodeImage(img, images.Binarizer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded.data) != input {
+		t.Errorf("data = %q, want %q", decoded.data, input)
+	}
+
+	// Without the alignment patterns, even at the right dimension
+	b, _ := images.Binarizer{}.Binarize(img)
+	symbol, err := images.Locate(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	symbol.Dimension = qr.size
+	grid, err := symbol.Sample(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeGrid(grid); err == nil {
+		t.Error("expected an error without alignment patterns")
+	}
+}
+
+func TestDecodeAllImage(t *testing.T) {
+	input := strings.Repeat("Structured append across a label sheet. ", 4)
+	sequence, err := NewStructuredAppend(QRRequest{input_data: input, err_corr_level: ERR_CORR_M}, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	single := NewQRCode(QRRequest{input_data: "HELLO WORLD", err_corr_level: ERR_CORR_Q})
+
+	// Symbols rasterized side by side in rows of 3, out of order
+	sheet := func(symbols []*qr) *image.Gray {
+		var tiles []*image.Gray
+		for i, qr := range symbols {
+			tiles = append(tiles, rasterize(qr, 4, float64(i*25)))
+		}
+		side := 0
+		for _, tile := range tiles {
+			side = max(side, tile.Bounds().Dx())
+		}
+		img := image.NewGray(image.Rect(0, 0, 3*side, (len(tiles)+2)/3*side))
+		draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+		for i, tile := range tiles {
+			at := image.Pt(i%3*side, i/3*side)
+			draw.Draw(img, tile.Bounds().Add(at), tile, image.Point{}, draw.Src)
+		}
+		return img
+	}
+
+	img := sheet([]*qr{sequence[2], single, sequence[0], sequence[3], sequence[1]})
+	symbols, err := DecodeAllImage(img, images.Binarizer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(symbols) != 5 {
+		t.Fatalf("found %d symbols, want 5", len(symbols))
+	}
+	var decoded []*qr
+	for i, symbol := range symbols {
+		if symbol.Err != nil {
+			t.Fatalf("symbol %d: %v", i+1, symbol.Err)
+		}
+		// The quadrilateral holds the symbol, about 4 pixels per module
+		if side := math.Hypot(symbol.Corners[1].X-symbol.Corners[0].X, symbol.Corners[1].Y-symbol.Corners[0].Y); math.Abs(side-float64(4*symbol.QR.size)) > 4 {
+			t.Errorf("symbol %d: side %.1f, want %d", i+1, side, 4*symbol.QR.size)
+		}
+		decoded = append(decoded, symbol.QR)
+	}
+
+	messages, others := JoinStructuredAppend(decoded)
+	if len(messages) != 1 || len(others) != 1 {
+		t.Fatalf("got %d messages and %d other symbols, want 1 and 1", len(messages), len(others))
+	}
+	if string(messages[0].Data) != input {
+		t.Errorf("message = %q, want %q", messages[0].Data, input)
+	}
+	if string(others[0].data) != "HELLO WORLD" {
+		t.Errorf("other symbol = %q, want %q", others[0].data, "HELLO WORLD")
+	}
+
+	// Without the third symbol the sequence is incomplete
+	symbols, _ = DecodeAllImage(sheet([]*qr{sequence[3], sequence[0], sequence[1]}), images.Binarizer{})
+	decoded = nil
+	for _, symbol := range symbols {
+		if symbol.Err == nil {
+			decoded = append(decoded, symbol.QR)
+		}
+	}
+	messages, _ = JoinStructuredAppend(decoded)
+	if len(messages) != 1 || messages[0].Complete() || messages[0].Data != nil {
+		t.Fatalf("got %d messages, want 1 incomplete", len(messages))
+	}
+	if missing := messages[0].Missing(); !slices.Equal(missing, []int{2}) {
+		t.Errorf("missing = %v, want [2]", missing)
+	}
+
+	// A header whose position is past the end of the sequence
+	invalid := &qr{segments: []modes.Segment{{Mode: modes.StructuredAppend, Position: 5, Total: 3}}}
+	messages, others = JoinStructuredAppend([]*qr{invalid})
+	if len(messages) != 0 || len(others) != 0 {
+		t.Errorf("got %d messages and %d other symbols, want none", len(messages), len(others))
+	}
+	r := bitseq.NewReader(bitseq.FromInt(0x52A5, 16))
+	if _, _, _, err := modes.DecodeStructuredAppend(r); err == nil {
+		t.Error("DecodeStructuredAppend() of position 5 of 3 symbols succeeded")
+	}
+}
+
+func TestDecodeReversedAndMirrored(t *testing.T) {
+	tests := []struct {
+		name               string
+		input              string
+		reversed, mirrored bool
+	}{
+		{"Plain", "HELLO WORLD", false, false},
+		{"Reversed", "HELLO WORLD", true, false},
+		{"Mirrored", "HELLO WORLD", false, true},
+		{"Both", "https://example.com/", true, true},
+		// The version information is transposed too
+		{"Mirrored version 8", strings.Repeat("through the glass ", 8), false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qr := NewQRCode(QRRequest{input_data: tt.input, err_corr_level: ERR_CORR_M, reflectance_reversal: tt.reversed, mirrored: tt.mirrored})
+			img := rasterize(qr, 4, 20)
+			if tt.reversed {
+				for i, v := range img.Pix {
+					img.Pix[i] = 255 - v
+				}
+			}
+			decoded, err := DecodeImage(img, images.Binarizer{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(decoded.data) != tt.input {
+				t.Errorf("data = %q, want %q", decoded.data, tt.input)
+			}
+			if decoded.reversed != tt.reversed || decoded.mirrored != tt.mirrored {
+				t.Errorf("reversed, mirrored = %v, %v, want %v, %v", decoded.reversed, decoded.mirrored, tt.reversed, tt.mirrored)
+			}
+		})
+	}
+
+	// The format information alone tells a transposed matrix apart
+	qr := NewQRCode(QRRequest{input_data: "HELLO WORLD", err_corr_level: ERR_CORR_Q})
+	decoded, err := DecodeMatrix(transpose(qr.matrix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.mirrored || string(decoded.data) != "HELLO WORLD" {
+		t.Errorf("got %q, mirrored %v", decoded.data, decoded.mirrored)
+	}
+}
+
+func TestSoftDecision(t *testing.T) {
+	// Version 1-M, a single block with 10 error correction codewords: 5
+	// errors at unknown positions, 8 erasures leaving 2 to check
+	input := "HELLO WORLD"
+	qr := NewQRCode(QRRequest{input_data: input, err_corr_level: ERR_CORR_M, version: 1})
+	damaged := map[[2]int]bool{}
+	for _, modules := range qr.codeword_module_positions()[3:11] {
+		for _, pos := range modules {
+			damaged[pos] = true
+		}
+	}
+
+	grid := make([][]bool, qr.size)
+	confidence := make([][]float64, qr.size)
+	for i := range grid {
+		grid[i] = make([]bool, qr.size)
+		confidence[i] = make([]float64, qr.size)
+		for j := range grid[i] {
+			grid[i][j] = qr.matrix[i][j].bit == One
+			confidence[i][j] = 1
+			if damaged[[2]int{i, j}] {
+				grid[i][j] = !grid[i][j]
+				confidence[i][j] = 0.5
+			}
+		}
+	}
+	if _, err := DecodeGrid(grid); err == nil {
+		t.Fatal("expected an error for 8 damaged codewords without confidence")
+	}
+	decoded, err := DecodeSoftGrid(grid, confidence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded.data) != input {
+		t.Errorf("data = %q, want %q", decoded.data, input)
+	}
+
+	// Worn modules dithered in the image sample with low confidence
+	const moduleSize = 4
+	img := rasterize(qr, moduleSize, 0)
+	c := float64(img.Bounds().Dx()) / 2
+	for y := range img.Bounds().Dy() {
+		for x := range img.Bounds().Dx() {
+			i := int(math.Floor((float64(y)+0.5-c)/moduleSize + float64(qr.size)/2))
+			j := int(math.Floor((float64(x)+0.5-c)/moduleSize + float64(qr.size)/2))
+			if damaged[[2]int{i, j}] {
+				img.SetGray(x, y, color.Gray{Y: uint8((x + y) % 2 * 255)})
+			}
+		}
+	}
+	decoded, err = DecodeImage(img, images.Binarizer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded.data) != input {
+		t.Errorf("data = %q, want %q", decoded.data, input)
+	}
+	b, _ := images.Binarizer{}.Binarize(img)
+	symbol, err := images.Locate(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grid, err = symbol.Sample(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeGrid(grid); err == nil {
+		t.Error("expected an error for the worn image without confidence")
+	}
+}
+
+func TestDecodeFrames(t *testing.T) {
+	input := "https://example.com/label/0042"
+	qr := NewQRCode(QRRequest{input_data: input, err_corr_level: ERR_CORR_M})
+
+	// Noise flips 15% of the data modules of each frame, a different 15% each
+	// time: no frame can be read alone
+	rng := rand.New(rand.NewPCG(1, 2))
+	var frames []image.Image
+	for i := range 7 {
+		noisy := *qr
+		noisy.matrix = make([][]module, qr.size)
+		for r, row := range qr.matrix {
+			noisy.matrix[r] = slices.Clone(row)
+		}
+		for _, pos := range qr.data_module_positions() {
+			if rng.Float64() < 0.15 {
+				if noisy.matrix[pos[0]][pos[1]].bit == One {
+					noisy.matrix[pos[0]][pos[1]].bit = Zero
+				} else {
+					noisy.matrix[pos[0]][pos[1]].bit = One
+				}
+			}
+		}
+		img := rasterize(&noisy, 4, float64(i*7))
+		if _, err := DecodeImage(img, images.Binarizer{}); err == nil {
+			t.Fatalf("frame %d decoded alone", i+1)
+		}
+		frames = append(frames, img)
+	}
+
+	for _, fusion := range []Fusion{FusionMajority, FusionConfidence} {
+		decoded, err := DecodeFrames(frames, images.Binarizer{}, fusion)
+		if err != nil {
+			t.Fatalf("%s: %v", fusion, err)
+		}
+		if string(decoded.data) != input {
+			t.Errorf("%s: data = %q, want %q", fusion, decoded.data, input)
+		}
+	}
+	if _, err := DecodeFrames(frames, images.Binarizer{}, "median"); err == nil {
+		t.Error("expected an error for an unknown fusion")
+	}
+
+	// Light on dark, e.g. a laser marked part
+	var negatives []image.Image
+	for _, img := range frames {
+		negatives = append(negatives, invertGray(img.(*image.Gray)))
+	}
+	decoded, err := DecodeFrames(negatives, images.Binarizer{}, "")
+	if err != nil {
+		t.Fatalf("inverted: %v", err)
+	}
+	if string(decoded.data) != input || !decoded.reversed {
+		t.Errorf("inverted: data = %q, reversed %v", decoded.data, decoded.reversed)
+	}
+
+	// The same frames from a directory, among other files
+	dir := t.TempDir()
+	for i, img := range frames {
+		file, err := os.Create(filepath.Join(dir, fmt.Sprintf("frame-%d.png", i+1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := png.Encode(file, img); err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("burst"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err = DecodeFrameDirectory(dir, images.Binarizer{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded.data) != input || decoded.reversed {
+		t.Errorf("directory: data = %q, want %q", decoded.data, input)
+	}
+}
+
+func TestGrading(t *testing.T) {
+	// Version 2-M: a single block with 16 error correction codewords
+	qr := NewQRCode(QRRequest{input_data: "https://example.com/grade", err_corr_level: ERR_CORR_M, version: 2})
+	if qr.version.Number != 2 {
+		t.Fatalf("version %d, want 2", qr.version.Number)
+	}
+
+	report, err := GradeImage(rasterize(qr, 6, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Overall != GradeA || report.SymbolContrast.Value < 0.99 || report.UnusedErrorCorrection.Value != 1 || report.Reversed {
+		t.Errorf("clean symbol: %+v", report)
+	}
+	report, err = GradeImage(invertGray(rasterize(qr, 6, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Overall != GradeA || !report.Reversed {
+		t.Errorf("light on dark symbol: %+v", report)
+	}
+
+	// Grey on grey: 31% contrast
+	img := rasterize(qr, 6, 0)
+	for i, v := range img.Pix {
+		img.Pix[i] = 90 + uint8(int(v)*80/255)
+	}
+	report, err = GradeImage(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.SymbolContrast.Grade != GradeD || report.Modulation.Grade != GradeA || report.Overall != GradeD {
+		t.Errorf("low contrast: symbol contrast %v, modulation %v, overall %v",
+			report.SymbolContrast, report.Modulation.Grade, report.Overall)
+	}
+
+	// 2 modules of the upper left finder pattern ring and 4 codewords wrong
+	damaged := *qr
+	damaged.matrix = make([][]module, qr.size)
+	for i, row := range qr.matrix {
+		damaged.matrix[i] = slices.Clone(row)
+	}
+	flip := func(pos [2]int) {
+		m := &damaged.matrix[pos[0]][pos[1]]
+		if m.bit == One {
+			m.bit = Zero
+		} else {
+			m.bit = One
+		}
+	}
+	flip([2]int{0, 2})
+	flip([2]int{6, 4})
+	for _, modules := range qr.codeword_module_positions()[10:14] {
+		for _, pos := range modules {
+			flip(pos)
+		}
+	}
+	report, err = GradeImage(rasterize(&damaged, 6, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.FixedPatternDamage.Value != 2 || report.FixedPatternDamage.Grade != GradeC {
+		t.Errorf("fixed pattern damage = %v, want 2 modules, C", report.FixedPatternDamage)
+	}
+	if report.UnusedErrorCorrection.Value != 0.5 || report.UnusedErrorCorrection.Grade != GradeB {
+		t.Errorf("unused error correction = %v, want 0.5, B", report.UnusedErrorCorrection)
+	}
+	if report.Overall != GradeC {
+		t.Errorf("overall = %v, want C", report.Overall)
+	}
+}
+
+func TestRobustness(t *testing.T) {
+	qr := NewQRCode(QRRequest{input_data: "HELLO", err_corr_level: ERR_CORR_L, version: 1})
+	rebuilt := qr.withMask(qr.mask)
+	for i := range qr.matrix {
+		for j := range qr.matrix[i] {
+			if rebuilt.matrix[i][j].bit != qr.matrix[i][j].bit {
+				t.Fatalf("withMask(%d) differs at (%d, %d)", qr.mask, i, j)
+			}
+		}
+	}
+
+	results, err := Robustness(QRRequest{input_data: "HELLO", version: 1}, writer.ShapeSquare, []DamageModel{DamageFlips}, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4*8 {
+		t.Fatalf("%d results, want 32", len(results))
+	}
+	threshold := map[errcorr]float64{}
+	for _, r := range results {
+		if r.Mask == 0 {
+			threshold[r.ECLevel] = r.Threshold
+		}
+	}
+	if threshold[ERR_CORR_H] <= threshold[ERR_CORR_L] {
+		t.Errorf("flips threshold H %.2f, want above L %.2f", threshold[ERR_CORR_H], threshold[ERR_CORR_L])
+	}
+
+	// The same seed damages alike
+	again, err := DecodeMargin(qr, writer.ShapeSquare, DamageBlotch, 2, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if margin, _ := DecodeMargin(qr, writer.ShapeSquare, DamageBlotch, 2, 7); margin != again {
+		t.Errorf("blotch margin %.2f then %.2f", again, margin)
+	}
+
+	if margin, err := DecodeMargin(qr, writer.ShapeSquare, DamageBlurNoise, 1, 1); err != nil || margin < 0.05 {
+		t.Errorf("blur and noise margin %.2f, %v", margin, err)
+	}
+
+	if _, err := DecodeMargin(qr, writer.ShapeSquare, "smudge", 1, 1); err == nil {
+		t.Error("unknown damage model accepted")
+	}
+	// Without trials every severity would pass
+	if _, err := DecodeMargin(qr, writer.ShapeSquare, DamageFlips, 0, 1); err == nil {
+		t.Error("0 trials accepted")
+	}
+	if _, err := Robustness(QRRequest{input_data: "HELLO", version: 1}, writer.ShapeSquare, DamageModels, -1, 1); err == nil {
+		t.Error("-1 trials accepted")
+	}
+
+	// The logo clears modules of the design before the damage
+	logo := filepath.Join(t.TempDir(), "logo.png")
+	file, err := os.Create(logo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(file, image.NewGray(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	bare := NewQRCode(QRRequest{input_data: "HELLO", err_corr_level: ERR_CORR_H, version: 7})
+	designed := NewQRCode(QRRequest{input_data: "HELLO", err_corr_level: ERR_CORR_H, version: 7, logo: logo})
+	without, err := DecodeMargin(bare, writer.ShapeSquare, DamageFlips, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	with, err := DecodeMargin(designed, writer.ShapeSquare, DamageFlips, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if with >= without {
+		t.Errorf("flips margin %.2f with a logo, want below %.2f without", with, without)
+	}
+	for _, shape := range []writer.Shape{writer.ShapeSquare, writer.ShapeCircle} {
+		if margin, err := DecodeMargin(designed, shape, DamageBlurNoise, 1, 1); err != nil || margin < 0.05 {
+			t.Errorf("%s blur and noise margin %.2f with a logo, %v", shape, margin, err)
+		}
+	}
+	designed.logo = filepath.Join(t.TempDir(), "missing.png")
+	if _, err := DecodeMargin(designed, writer.ShapeSquare, DamageBlurNoise, 1, 1); err == nil {
+		t.Error("missing logo rendered")
+	}
+}
+
+func TestGenerateCorpus(t *testing.T) {
+	payloads := []string{"HELLO WORLD"}
+	first, second := t.TempDir(), t.TempDir()
+	manifest, err := GenerateCorpus(first, payloads, ERR_CORR_M, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GenerateCorpus(second, payloads, ERR_CORR_M, 5); err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Entries) != len(Distortions) {
+		t.Fatalf("%d entries, want %d", len(manifest.Entries), len(Distortions))
+	}
+
+	// Reproducible
+	for _, name := range []string{"manifest.json", manifest.Entries[2].File, manifest.Entries[4].File} {
+		a, err := os.ReadFile(filepath.Join(first, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(filepath.Join(second, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(a, b) {
+			t.Errorf("%s differs between runs with the same seed", name)
+		}
+	}
+
+	encoded, err := os.ReadFile(filepath.Join(first, "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var read CorpusManifest
+	if err := json.Unmarshal(encoded, &read); err != nil {
+		t.Fatal(err)
+	}
+	if read.Seed != 5 || len(read.Entries) != len(Distortions) || read.Entries[1] != manifest.Entries[1] {
+		t.Errorf("manifest.json = %+v", read)
+	}
+
+	// The clean and rotated symbols decode, where the manifest says
+	for _, entry := range manifest.Entries[:2] {
+		img, err := images.Load(filepath.Join(first, entry.File))
+		if err != nil {
+			t.Fatal(err)
+		}
+		symbols, err := DecodeAllImage(img, images.Binarizer{})
+		if err != nil || len(symbols) != 1 || symbols[0].Err != nil {
+			t.Fatalf("%s: %v %+v", entry.File, err, symbols)
+		}
+		if string(symbols[0].QR.data) != entry.Data {
+			t.Errorf("%s: data %q, want %q", entry.File, symbols[0].QR.data, entry.Data)
+		}
+		for i, c := range symbols[0].Corners {
+			if distance(c, entry.Corners[i]) > corpusModuleSize {
+				t.Errorf("%s: corner %d at %v, manifest %v", entry.File, i, c, entry.Corners[i])
+			}
+		}
+	}
+}
@@ -37,3 +37,18 @@ var mask_patterns []mask = []mask{
 func get_mask_pattern_for_mask(mask int) mask {
 	return mask_patterns[mask]
 }
+
+// Micro QR only uses four of the masks, referenced by 2 bits
+//
+// | Micro QR | QR Code |
+// | :------- | :------ |
+// | 00       | 001     |
+// | 01       | 100     |
+// | 10       | 110     |
+// | 11       | 111     |
+var micro_mask_patterns []int = []int{0b001, 0b100, 0b110, 0b111}
+
+// returns the QR Code mask pattern of the given Micro QR mask
+func get_mask_pattern_for_micro_mask(microMask int) int {
+	return micro_mask_patterns[microMask]
+}
@@ -10,17 +10,16 @@ import (
 // Usually, we mark them or keep a separate "is_function" map.
 func (qr *qr) apply_mask(maskIndex int, matrix [][]module) [][]module {
 	// Create a copy of the matrix
-	size := len(matrix)
-	maskedMatrix := make([][]module, size)
-	for i := range size {
-		maskedMatrix[i] = make([]module, size)
+	maskedMatrix := make([][]module, len(matrix))
+	for i := range matrix {
+		maskedMatrix[i] = make([]module, len(matrix[i]))
 		copy(maskedMatrix[i], matrix[i])
 	}
 
 	mask := get_mask_pattern_for_mask(maskIndex)
 
-	for i := range size {
-		for j := range size {
+	for i := range maskedMatrix {
+		for j := range maskedMatrix[i] {
 			// Skip function patterns
 			if qr.isFunctionPattern(i, j) {
 				continue
@@ -145,3 +144,25 @@ func calculatePenalty(matrix [][]module) int {
 
 	return penalty
 }
+
+// Micro QR mask evaluation. Only the dark modules along the right and lower
+// edges are counted, the higher the score the better.
+func calculateMicroScore(matrix [][]module) int {
+	size := len(matrix)
+
+	// SUM1: right edge, SUM2: lower edge (timing patterns excluded)
+	sum1, sum2 := 0, 0
+	for i := 1; i < size; i++ {
+		if matrix[i][size-1].bit == One {
+			sum1++
+		}
+		if matrix[size-1][i].bit == One {
+			sum2++
+		}
+	}
+
+	if sum1 <= sum2 {
+		return sum1*16 + sum2
+	}
+	return sum2*16 + sum1
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Micro QR symbols have a single finder pattern in the upper left corner,
+// timing patterns along row and column 0 and no alignment patterns nor
+// version information.
+func (qr *qr) generate_micro() {
+	// Functions patterns
+	qr.micro_finder_pattern()
+	qr.micro_separators()
+	qr.micro_timing_patterns()
+
+	// Encoding region
+	qr.reserve_micro_format_information_area()
+	qr.data_and_error_correction()
+
+	// Masking
+	// Only 4 masks are available. The selected mask is the one with the
+	// highest score (see calculateMicroScore).
+	originalMatrix := make([][]module, qr.size)
+	for i := range qr.size {
+		originalMatrix[i] = make([]module, qr.size)
+		copy(originalMatrix[i], qr.matrix[i])
+	}
+
+	if qr.debug {
+		fmt.Println("DEBUG: Masking disabled")
+		qr.mask = 0
+		qr.place_micro_format_information(0)
+		return
+	}
+
+	maxScore := -1
+	var bestMatrix [][]module
+	var bestMatrixMask int
+	for mask := range len(micro_mask_patterns) {
+		qr.matrix = qr.apply_mask(get_mask_pattern_for_micro_mask(mask), originalMatrix)
+		qr.place_micro_format_information(mask)
+
+		score := calculateMicroScore(qr.matrix)
+		if score > maxScore {
+			maxScore = score
+			bestMatrix = qr.matrix
+			bestMatrixMask = mask
+		}
+	}
+
+	qr.matrix = bestMatrix
+	qr.mask = bestMatrixMask
+}
+
+// places the finder pattern modules in the upper left corner
+func (qr *qr) micro_finder_pattern() {
+	for i := range 7 {
+		for j := range 7 {
+			// 7 x 7 dark, 5 x 5 light and 3 x 3 dark concentric squares
+			ring := min(i, j, 6-i, 6-j)
+			if ring == 1 {
+				qr.matrix[i][j] = module{bit: Zero}
+			} else {
+				qr.matrix[i][j] = module{bit: One}
+			}
+			qr.is_function_pattern[i][j] = true
+		}
+	}
+}
+
+// places the separator modules on the right and lower sides of the finder pattern
+func (qr *qr) micro_separators() {
+	for i := range 8 {
+		qr.matrix[i][7] = module{bit: Zero}
+		qr.is_function_pattern[i][7] = true
+		qr.matrix[7][i] = module{bit: Zero}
+		qr.is_function_pattern[7][i] = true
+	}
+}
+
+// places timing pattern modules along row 0 and column 0
+func (qr *qr) micro_timing_patterns() {
+	for k := 8; k < qr.size; k++ {
+		bit := Zero
+		if k%2 == 0 {
+			bit = One
+		}
+		qr.matrix[0][k] = module{bit: bit}
+		qr.is_function_pattern[0][k] = true
+		qr.matrix[k][0] = module{bit: bit}
+		qr.is_function_pattern[k][0] = true
+	}
+}
+
+// marks the format information area (row 8 and column 8 next to the finder
+// pattern) as reserved
+func (qr *qr) reserve_micro_format_information_area() {
+	for k := 1; k <= 8; k++ {
+		qr.is_function_pattern[8][k] = true
+		qr.is_function_pattern[k][8] = true
+	}
+}
+
+// places the micro format information with the given mask
+func (qr *qr) place_micro_format_information(mask int) {
+	formatInfo, err := GenerateMicroFormatInformation(qr.version.Number, qr.error_corr_level, mask)
+	if err != nil {
+		panic(err)
+	}
+
+	// Bits 0-7 at (1-8, 8), bit 7 being shared with the row
+	for i := range 8 {
+		qr.matrix[i+1][8] = format_bit_module(uint32(formatInfo), i)
+	}
+	// Bits 14-8 at (8, 1-7)
+	for i := range 7 {
+		qr.matrix[8][i+1] = format_bit_module(uint32(formatInfo), 14-i)
+	}
+}
+
+// returns the module for bit i (0 being the least significant) of formatInfo
+func format_bit_module(formatInfo uint32, i int) module {
+	if (formatInfo>>i)&1 == 1 {
+		return module{bit: One}
+	}
+	return module{bit: Zero}
+}
@@ -1,6 +1,14 @@
 package modes
 
-import "github.com/harogaston/qr-decoder/bitseq"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/harogaston/qr-decoder/bitseq"
+)
+
+// Alphanumeric mode characters, indexed by value
+const alphanumericChars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
 
 var alphanumericValues = map[rune]int{
 	'0': 0, '1': 1, '2': 2, '3': 3, '4': 4, '5': 5, '6': 6, '7': 7, '8': 8, '9': 9,
@@ -29,3 +37,30 @@ func EncodeAlphanumeric(input string) bitseq.BitSeq {
 	}
 	return output
 }
+
+// DecodeAlphanumeric reads count characters packed by EncodeAlphanumeric:
+// 11 bits per pair, 6 bits for a final single character.
+func DecodeAlphanumeric(r *bitseq.Reader, count int) (string, error) {
+	output := make([]byte, 0, count)
+	for ; count >= 2; count -= 2 {
+		val, ok := r.Read(11)
+		if !ok {
+			return "", errors.New("alphanumeric segment: unexpected end of data")
+		}
+		if val >= 45*45 {
+			return "", fmt.Errorf("alphanumeric segment: invalid pair value %d", val)
+		}
+		output = append(output, alphanumericChars[val/45], alphanumericChars[val%45])
+	}
+	if count == 1 {
+		val, ok := r.Read(6)
+		if !ok {
+			return "", errors.New("alphanumeric segment: unexpected end of data")
+		}
+		if val >= 45 {
+			return "", fmt.Errorf("alphanumeric segment: invalid value %d", val)
+		}
+		output = append(output, alphanumericChars[val])
+	}
+	return string(output), nil
+}
@@ -0,0 +1,143 @@
+package modes
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/harogaston/qr-decoder/bitseq"
+	"golang.org/x/text/encoding"
+)
+
+// Default ECI assignment of QR Code symbols (ISO/IEC 8859-1)
+const DefaultECI = 3
+
+// ECI assignment of GB 2312, encoded with GBK which is a superset of it
+const gb2312ECI = 29
+
+// Charset is a character set designated by an ECI assignment number.
+// Byte mode segments carry data transcoded to the active charset.
+type Charset struct {
+	Name     string
+	ECI      int
+	encoding encoding.Encoding
+}
+
+// EncodeECIDesignator encodes an ECI assignment number in 8, 16 or 24 bits.
+//
+// | Assignment number | Codeword values            |
+// | :---------------- | :------------------------- |
+// | 0 to 127          | 0bbbbbbb                   |
+// | 128 to 16383      | 10bbbbbb bbbbbbbb          |
+// | 16384 to 999999   | 110bbbbb bbbbbbbb bbbbbbbb |
+func EncodeECIDesignator(assignment int) bitseq.BitSeq {
+	switch {
+	case assignment < 0 || assignment > 999999:
+		panic(fmt.Sprintf("invalid ECI assignment number %d", assignment))
+	case assignment <= 127:
+		return bitseq.FromInt(uint64(assignment), 8)
+	case assignment <= 16383:
+		return bitseq.ConcatMany(bitseq.FromInt(0b10, 2), bitseq.FromInt(uint64(assignment), 14))
+	default:
+		return bitseq.ConcatMany(bitseq.FromInt(0b110, 3), bitseq.FromInt(uint64(assignment), 21))
+	}
+}
+
+// DecodeECIDesignator reads an ECI assignment number written by EncodeECIDesignator.
+func DecodeECIDesignator(r *bitseq.Reader) (int, error) {
+	first, ok := r.Read(8)
+	if !ok {
+		return 0, errors.New("ECI designator: unexpected end of data")
+	}
+	// The leading 1 bits give the number of codewords that follow
+	var prefixBits, restBits int
+	switch {
+	case first&0x80 == 0:
+		return int(first), nil
+	case first&0xC0 == 0x80:
+		prefixBits, restBits = 2, 8
+	case first&0xE0 == 0xC0:
+		prefixBits, restBits = 3, 16
+	default:
+		return 0, fmt.Errorf("ECI designator: invalid first codeword 0x%02X", first)
+	}
+	rest, ok := r.Read(restBits)
+	if !ok {
+		return 0, errors.New("ECI designator: unexpected end of data")
+	}
+	first &= 0xFF >> prefixBits
+	return int(first<<restBits | rest), nil
+}
+
+// GetCharset looks up a charset by name (case insensitive) or by its ECI
+// assignment number written in decimal.
+func GetCharset(name string) (Charset, bool) {
+	for _, c := range eciCharsets {
+		if strings.EqualFold(c.Name, name) || fmt.Sprint(c.ECI) == name {
+			return c, true
+		}
+		for _, alias := range eciAliases[c.ECI] {
+			if strings.EqualFold(alias, name) {
+				return c, true
+			}
+		}
+	}
+	return Charset{}, false
+}
+
+// DetectCharset returns ISO/IEC 8859-1 when every character of input is
+// representable in it and UTF-8 otherwise.
+func DetectCharset(input string) Charset {
+	latin1, _ := GetCharset("ISO-8859-1")
+	if !utf8.ValidString(input) {
+		return latin1
+	}
+	for _, r := range input {
+		if r > 0xFF {
+			utf, _ := GetCharset("UTF-8")
+			return utf
+		}
+	}
+	return latin1
+}
+
+// Encode transcodes UTF-8 text to the charset. Input that is not valid UTF-8
+// is assumed to be in the target charset already and returned unchanged.
+func (c Charset) Encode(input string) ([]byte, error) {
+	if !utf8.ValidString(input) || c.encoding == nil {
+		return []byte(input), nil
+	}
+	if c.ECI == gb2312ECI {
+		// GBK maps characters outside of the GB 2312 rows 0xA1 to 0xF7 that
+		// a GB 2312 reader cannot
+		for _, r := range input {
+			if r >= utf8.RuneSelf && !IsHanzi(r) {
+				return nil, fmt.Errorf("cannot encode %q in %s: %q is not in GB 2312", input, c.Name, r)
+			}
+		}
+	}
+	out, err := c.encoding.NewEncoder().Bytes([]byte(input))
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode %q in %s: %w", input, c.Name, err)
+	}
+	return out, nil
+}
+
+// Decode transcodes bytes in the charset back to UTF-8 text.
+func (c Charset) Decode(data []byte) (string, error) {
+	if c.encoding == nil {
+		return string(data), nil
+	}
+	out, err := c.encoding.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", fmt.Errorf("cannot decode bytes as %s: %w", c.Name, err)
+	}
+	return string(out), nil
+}
+
+// IsDefault reports whether the charset is the default interpretation of
+// byte mode data, which needs no ECI header.
+func (c Charset) IsDefault() bool {
+	return c.ECI == DefaultECI
+}
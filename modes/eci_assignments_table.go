@@ -0,0 +1,62 @@
+package modes
+
+import (
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// | ECI | Character set              |
+// | :-- | :------------------------- |
+// | 3   | ISO/IEC 8859-1 (default)   |
+// | 4   | ISO/IEC 8859-2             |
+// | ... | ...                        |
+// | 18  | ISO/IEC 8859-16            |
+// | 20  | Shift JIS                  |
+// | 21  | Windows-1250               |
+// | 22  | Windows-1251               |
+// | 23  | Windows-1252               |
+// | 24  | Windows-1256               |
+// | 25  | UTF-16 (big endian)        |
+// | 26  | UTF-8                      |
+// | 28  | Big5                       |
+// | 29  | GB 2312                    |
+// | 30  | EUC-KR                     |
+// | 32  | GB 18030                   |
+var eciCharsets = []Charset{
+	{Name: "ISO-8859-1", ECI: 3, encoding: charmap.ISO8859_1},
+	{Name: "ISO-8859-2", ECI: 4, encoding: charmap.ISO8859_2},
+	{Name: "ISO-8859-3", ECI: 5, encoding: charmap.ISO8859_3},
+	{Name: "ISO-8859-4", ECI: 6, encoding: charmap.ISO8859_4},
+	{Name: "ISO-8859-5", ECI: 7, encoding: charmap.ISO8859_5},
+	{Name: "ISO-8859-6", ECI: 8, encoding: charmap.ISO8859_6},
+	{Name: "ISO-8859-7", ECI: 9, encoding: charmap.ISO8859_7},
+	{Name: "ISO-8859-8", ECI: 10, encoding: charmap.ISO8859_8},
+	{Name: "ISO-8859-9", ECI: 11, encoding: charmap.ISO8859_9},
+	{Name: "ISO-8859-10", ECI: 12, encoding: charmap.ISO8859_10},
+	{Name: "ISO-8859-13", ECI: 15, encoding: charmap.ISO8859_13},
+	{Name: "ISO-8859-14", ECI: 16, encoding: charmap.ISO8859_14},
+	{Name: "ISO-8859-15", ECI: 17, encoding: charmap.ISO8859_15},
+	{Name: "ISO-8859-16", ECI: 18, encoding: charmap.ISO8859_16},
+	{Name: "Shift_JIS", ECI: 20, encoding: japanese.ShiftJIS},
+	{Name: "Windows-1250", ECI: 21, encoding: charmap.Windows1250},
+	{Name: "Windows-1251", ECI: 22, encoding: charmap.Windows1251},
+	{Name: "Windows-1252", ECI: 23, encoding: charmap.Windows1252},
+	{Name: "Windows-1256", ECI: 24, encoding: charmap.Windows1256},
+	{Name: "UTF-16BE", ECI: 25, encoding: unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)},
+	{Name: "UTF-8", ECI: 26, encoding: unicode.UTF8},
+	{Name: "Big5", ECI: 28, encoding: traditionalchinese.Big5},
+	{Name: "GB2312", ECI: 29, encoding: simplifiedchinese.GBK}, // restricted to GB 2312 by Encode
+	{Name: "EUC-KR", ECI: 30, encoding: korean.EUCKR},
+	{Name: "GB18030", ECI: 32, encoding: simplifiedchinese.GB18030},
+}
+
+var eciAliases = map[int][]string{
+	3:  {"latin1", "ISO8859-1"},
+	20: {"SJIS", "Shift-JIS"},
+	26: {"UTF8"},
+	29: {"GB-2312", "EUC-CN"},
+}
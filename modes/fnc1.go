@@ -0,0 +1,72 @@
+package modes
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/harogaston/qr-decoder/bitseq"
+)
+
+// Group separator (FNC1 used as a field separator). In byte mode it is
+// written as is, in alphanumeric mode it is represented by '%'.
+const GroupSeparator = '\x1D'
+
+// EncodeApplicationIndicator encodes the 8-bit application indicator that
+// follows the FNC1 second position mode indicator: a two digit number 00 to
+// 99 is written as is, a single letter as its ASCII value plus 100.
+func EncodeApplicationIndicator(indicator string) bitseq.BitSeq {
+	if len(indicator) == 2 {
+		if n, err := strconv.ParseUint(indicator, 10, 8); err == nil {
+			return bitseq.FromInt(n, 8)
+		}
+	}
+	if len(indicator) == 1 {
+		c := indicator[0]
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+			return bitseq.FromInt(uint64(c)+100, 8)
+		}
+	}
+	panic(fmt.Sprintf("invalid FNC1 application indicator %q", indicator))
+}
+
+// EscapeFNC1Alphanumeric rewrites alphanumeric data for the FNC1 modes:
+// literal '%' becomes "%%" and group separators become '%'.
+func EscapeFNC1Alphanumeric(data string) string {
+	data = strings.ReplaceAll(data, "%", "%%")
+	return strings.ReplaceAll(data, string(GroupSeparator), "%")
+}
+
+// UnescapeFNC1Alphanumeric reverses EscapeFNC1Alphanumeric.
+func UnescapeFNC1Alphanumeric(data string) string {
+	var b strings.Builder
+	for i := 0; i < len(data); i++ {
+		if data[i] == '%' {
+			if i+1 < len(data) && data[i+1] == '%' {
+				b.WriteByte('%')
+				i++
+			} else {
+				b.WriteByte(GroupSeparator)
+			}
+			continue
+		}
+		b.WriteByte(data[i])
+	}
+	return b.String()
+}
+
+// DecodeApplicationIndicator reads the indicator written by EncodeApplicationIndicator.
+func DecodeApplicationIndicator(r *bitseq.Reader) (string, error) {
+	val, ok := r.Read(8)
+	if !ok {
+		return "", errors.New("FNC1 application indicator: unexpected end of data")
+	}
+	switch {
+	case val <= 99:
+		return fmt.Sprintf("%02d", val), nil
+	case (val >= 'a'+100 && val <= 'z'+100) || (val >= 'A'+100 && val <= 'Z'+100):
+		return string(rune(val - 100)), nil
+	}
+	return "", fmt.Errorf("FNC1 application indicator: invalid value %d", val)
+}
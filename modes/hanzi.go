@@ -0,0 +1,144 @@
+package modes
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/harogaston/qr-decoder/bitseq"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// Hanzi mode character subsets (GB/T 18284), only GB 2312 is supported
+const HanziSubsetGB2312 = 1
+
+// GB 2312 double-byte ranges covered by Hanzi mode. GB 2312 ends at row 0xF7,
+// the rows after it are the GBK user-defined area.
+const (
+	hanziLowRangeStart  = 0xA1A1
+	hanziLowRangeEnd    = 0xAAFE
+	hanziHighRangeStart = 0xB0A1
+	hanziHighRangeEnd   = 0xF7FE
+)
+
+// EncodeHanziSubset returns the 4-bit subset indicator that follows the Hanzi
+// mode indicator.
+func EncodeHanziSubset() bitseq.BitSeq {
+	return bitseq.FromInt(HanziSubsetGB2312, 4)
+}
+
+// EncodeHanzi packs each GB 2312 character of input into 13 bits.
+// input may be UTF-8 text or raw GB 2312 bytes (see ToGB2312).
+func EncodeHanzi(input string) bitseq.BitSeq {
+	gb, ok := ToGB2312(input)
+	if !ok {
+		panic("EncodeHanzi: input contains characters outside of Hanzi mode")
+	}
+
+	var output bitseq.BitSeq
+	for i := 0; i < len(gb); i += 2 {
+		code := int(gb[i])<<8 | int(gb[i+1])
+
+		// 1. Subtract the range base
+		if code <= hanziLowRangeEnd {
+			code -= 0xA1A1
+		} else {
+			code -= 0xA6A1
+		}
+
+		// 2. Most significant byte times 0x60 plus least significant byte
+		val := (code>>8)*0x60 + code&0xFF
+
+		output = output.Append(bitseq.FromInt(uint64(val), 13))
+	}
+	return output
+}
+
+// ToGB2312 returns the GB 2312 representation of input if every character
+// can be encoded in Hanzi mode. Valid UTF-8 text is converted to GB 2312,
+// anything else is interpreted as raw GB 2312 bytes.
+func ToGB2312(input string) ([]byte, bool) {
+	if len(input) == 0 {
+		return nil, false
+	}
+
+	gb := []byte(input)
+	if utf8.ValidString(input) {
+		var err error
+		// GB 2312 is the double-byte subset of GBK checked below
+		gb, err = simplifiedchinese.GBK.NewEncoder().Bytes([]byte(input))
+		if err != nil {
+			return nil, false
+		}
+	}
+
+	if len(gb)%2 != 0 {
+		return nil, false
+	}
+	for i := 0; i < len(gb); i += 2 {
+		if !isHanziCode(int(gb[i])<<8 | int(gb[i+1])) {
+			return nil, false
+		}
+	}
+	return gb, true
+}
+
+// IsHanzi reports whether r maps to a GB 2312 character covered by Hanzi mode.
+func IsHanzi(r rune) bool {
+	gb, err := simplifiedchinese.GBK.NewEncoder().String(string(r))
+	if err != nil || len(gb) != 2 {
+		return false
+	}
+	return isHanziCode(int(gb[0])<<8 | int(gb[1]))
+}
+
+func isHanziCode(code int) bool {
+	if code&0xFF < 0xA1 || code&0xFF > 0xFE {
+		return false
+	}
+	return (code >= hanziLowRangeStart && code <= hanziLowRangeEnd) ||
+		(code >= hanziHighRangeStart && code <= hanziHighRangeEnd)
+}
+
+// DecodeHanziSubset reads the subset indicator that follows the Hanzi mode
+// indicator. Only GB 2312 is supported.
+func DecodeHanziSubset(r *bitseq.Reader) error {
+	subset, ok := r.Read(4)
+	if !ok {
+		return errors.New("hanzi segment: unexpected end of data")
+	}
+	if subset != HanziSubsetGB2312 {
+		return fmt.Errorf("hanzi segment: unsupported subset %d", subset)
+	}
+	return nil
+}
+
+// DecodeHanzi reads count characters packed by EncodeHanzi and returns them
+// as UTF-8 text.
+func DecodeHanzi(r *bitseq.Reader, count int) (string, error) {
+	gb := make([]byte, 0, 2*count)
+	for range count {
+		val, ok := r.Read(13)
+		if !ok {
+			return "", errors.New("hanzi segment: unexpected end of data")
+		}
+
+		// Reverse the packing, then add the range base back
+		code := int(val/0x60)<<8 | int(val%0x60)
+		if code < 0x0A00 {
+			code += 0xA1A1
+		} else {
+			code += 0xA6A1
+		}
+		if !isHanziCode(code) {
+			return "", fmt.Errorf("hanzi segment: value %d is outside of GB 2312", val)
+		}
+		gb = append(gb, byte(code>>8), byte(code))
+	}
+
+	text, err := simplifiedchinese.GBK.NewDecoder().Bytes(gb)
+	if err != nil {
+		return "", errors.New("hanzi segment: invalid GB 2312 data")
+	}
+	return string(text), nil
+}
@@ -0,0 +1,130 @@
+package modes
+
+import (
+	"errors"
+	"unicode/utf8"
+
+	"github.com/harogaston/qr-decoder/bitseq"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// Shift JIS double-byte ranges covered by Kanji mode (JIS X 0208)
+const (
+	kanjiLowRangeStart  = 0x8140
+	kanjiLowRangeEnd    = 0x9FFC
+	kanjiHighRangeStart = 0xE040
+	kanjiHighRangeEnd   = 0xEBBF
+)
+
+// EncodeKanji packs each Shift JIS character of input into 13 bits.
+// input may be UTF-8 text or raw Shift JIS bytes (see ToShiftJIS).
+func EncodeKanji(input string) bitseq.BitSeq {
+	sjis, ok := ToShiftJIS(input)
+	if !ok {
+		panic("EncodeKanji: input contains characters outside of Kanji mode")
+	}
+
+	var output bitseq.BitSeq
+	for i := 0; i < len(sjis); i += 2 {
+		code := int(sjis[i])<<8 | int(sjis[i+1])
+
+		// 1. Subtract the range base
+		if code <= kanjiLowRangeEnd {
+			code -= 0x8140
+		} else {
+			code -= 0xC140
+		}
+
+		// 2. Most significant byte times 0xC0 plus least significant byte
+		val := (code>>8)*0xC0 + code&0xFF
+
+		output = output.Append(bitseq.FromInt(uint64(val), 13))
+	}
+	return output
+}
+
+// ToShiftJIS returns the Shift JIS representation of input if every character
+// can be encoded in Kanji mode. Valid UTF-8 text is converted to Shift JIS,
+// anything else is interpreted as raw Shift JIS bytes.
+func ToShiftJIS(input string) ([]byte, bool) {
+	if len(input) == 0 {
+		return nil, false
+	}
+
+	sjis := []byte(input)
+	if utf8.ValidString(input) {
+		var err error
+		sjis, err = japanese.ShiftJIS.NewEncoder().Bytes([]byte(input))
+		if err != nil {
+			return nil, false
+		}
+	}
+
+	if len(sjis)%2 != 0 {
+		return nil, false
+	}
+	for i := 0; i < len(sjis); i += 2 {
+		if !isKanjiCode(int(sjis[i])<<8 | int(sjis[i+1])) {
+			return nil, false
+		}
+	}
+	return sjis, true
+}
+
+// IsKanji reports whether r maps to a Shift JIS character covered by Kanji mode.
+func IsKanji(r rune) bool {
+	sjis, err := japanese.ShiftJIS.NewEncoder().String(string(r))
+	if err != nil || len(sjis) != 2 {
+		return false
+	}
+	return isKanjiCode(int(sjis[0])<<8 | int(sjis[1]))
+}
+
+func isKanjiCode(code int) bool {
+	if code&0xFF < 0x40 || code&0xFF == 0x7F || code&0xFF > 0xFC {
+		return false
+	}
+	return (code >= kanjiLowRangeStart && code <= kanjiLowRangeEnd) ||
+		(code >= kanjiHighRangeStart && code <= kanjiHighRangeEnd)
+}
+
+// CharCount returns the number of characters of input as counted by the
+// character count indicator of the given mode.
+func CharCount(mode QRMode, input string) int {
+	if mode == KanjiMode {
+		sjis, _ := ToShiftJIS(input)
+		return len(sjis) / 2
+	}
+	if mode == HanziMode {
+		gb, _ := ToGB2312(input)
+		return len(gb) / 2
+	}
+	return len(input)
+}
+
+// DecodeKanji reads count characters packed by EncodeKanji and returns them
+// as UTF-8 text.
+func DecodeKanji(r *bitseq.Reader, count int) (string, error) {
+	sjis := make([]byte, 0, 2*count)
+	for range count {
+		val, ok := r.Read(13)
+		if !ok {
+			return "", errors.New("kanji segment: unexpected end of data")
+		}
+
+		// Reverse the packing, then add the range base back
+		code := int(val/0xC0)<<8 | int(val%0xC0)
+		if code < 0x1F00 {
+			code += 0x8140
+		} else {
+			code += 0xC140
+		}
+		sjis = append(sjis, byte(code>>8), byte(code))
+	}
+
+	text, err := japanese.ShiftJIS.NewDecoder().Bytes(sjis)
+	if err != nil {
+		return "", errors.New("kanji segment: invalid Shift JIS data")
+	}
+	return string(text), nil
+}
@@ -14,6 +14,7 @@ const (
 	M3         modeIndicatorVersionClass = "M3"
 	M4         modeIndicatorVersionClass = "M4"
 	AllQRCodes modeIndicatorVersionClass = "all"
+	RMQR       modeIndicatorVersionClass = "rMQR"
 )
 
 type modeIndicatorMap map[modeIndicatorVersionClass]bitseq.BitSeq
@@ -34,6 +35,7 @@ var modeIndicatorData = map[QRMode]modeIndicatorMap{
 		M3:         bitseq.BitSeq{},
 		M4:         bitseq.BitSeq{},
 		AllQRCodes: bitseq.FromInt(7, 4),
+		RMQR:       bitseq.FromInt(7, 3),
 	},
 	NumericMode: {
 		M1:         bitseq.BitSeq{},
@@ -41,6 +43,7 @@ var modeIndicatorData = map[QRMode]modeIndicatorMap{
 		M3:         bitseq.FromInt(0, 2),
 		M4:         bitseq.FromInt(0, 3),
 		AllQRCodes: bitseq.FromInt(1, 4),
+		RMQR:       bitseq.FromInt(1, 3),
 	},
 	AlphanumericMode: {
 		M1:         bitseq.BitSeq{},
@@ -48,6 +51,7 @@ var modeIndicatorData = map[QRMode]modeIndicatorMap{
 		M3:         bitseq.FromInt(1, 2),
 		M4:         bitseq.FromInt(1, 3),
 		AllQRCodes: bitseq.FromInt(2, 4),
+		RMQR:       bitseq.FromInt(2, 3),
 	},
 	ByteMode: {
 		M1:         bitseq.BitSeq{},
@@ -55,6 +59,7 @@ var modeIndicatorData = map[QRMode]modeIndicatorMap{
 		M3:         bitseq.FromInt(2, 2),
 		M4:         bitseq.FromInt(2, 3),
 		AllQRCodes: bitseq.FromInt(4, 4),
+		RMQR:       bitseq.FromInt(3, 3),
 	},
 	KanjiMode: {
 		M1:         bitseq.BitSeq{},
@@ -62,6 +67,7 @@ var modeIndicatorData = map[QRMode]modeIndicatorMap{
 		M3:         bitseq.FromInt(3, 2),
 		M4:         bitseq.FromInt(3, 3),
 		AllQRCodes: bitseq.FromInt(8, 4),
+		RMQR:       bitseq.FromInt(4, 3),
 	},
 	StructuredAppend: {
 		M1:         bitseq.BitSeq{},
@@ -69,7 +75,48 @@ var modeIndicatorData = map[QRMode]modeIndicatorMap{
 		M3:         bitseq.BitSeq{},
 		M4:         bitseq.BitSeq{},
 		AllQRCodes: bitseq.FromInt(3, 4),
+		RMQR:       bitseq.BitSeq{},
 	},
+	FNC1FirstPosition: {
+		M1:         bitseq.BitSeq{},
+		M2:         bitseq.BitSeq{},
+		M3:         bitseq.BitSeq{},
+		M4:         bitseq.BitSeq{},
+		AllQRCodes: bitseq.FromInt(5, 4),
+		RMQR:       bitseq.FromInt(5, 3),
+	},
+	FNC1SecondPosition: {
+		M1:         bitseq.BitSeq{},
+		M2:         bitseq.BitSeq{},
+		M3:         bitseq.BitSeq{},
+		M4:         bitseq.BitSeq{},
+		AllQRCodes: bitseq.FromInt(9, 4),
+		RMQR:       bitseq.FromInt(6, 3),
+	},
+	// Chinese QR Code (GB/T 18284), followed by a 4-bit subset indicator
+	HanziMode: {
+		M1:         bitseq.BitSeq{},
+		M2:         bitseq.BitSeq{},
+		M3:         bitseq.BitSeq{},
+		M4:         bitseq.BitSeq{},
+		AllQRCodes: bitseq.FromInt(13, 4),
+		RMQR:       bitseq.BitSeq{},
+	},
+}
+
+// ModeForIndicator returns the mode whose indicator in qrversion has the
+// given value, read on as many bits as the indicators of qrversion.
+func ModeForIndicator(qrversion version.QRVersion, indicator uint64) (QRMode, bool) {
+	for mode := range modeIndicatorData {
+		bits := GetModeIndicatorBits(qrversion, mode)
+		if bits.Len() == 0 {
+			continue
+		}
+		if val, _ := bitseq.NewReader(bits).Read(bits.Len()); val == indicator {
+			return mode, true
+		}
+	}
+	return UnknownMode, false
 }
 
 // GetModeIndicatorBits returns the mode indicator bits for a given QR version and mode.
@@ -90,6 +137,9 @@ func GetModeIndicatorBits(qrversion version.QRVersion, mode QRMode) bitseq.BitSe
 	if qrversion.Format == version.FORMAT_QR || qrversion.Format == version.FORMAT_QR_MODEL_2 {
 		return modeIndicatorData[mode][AllQRCodes]
 	}
+	if qrversion.Format == version.FORMAT_RMQR {
+		return modeIndicatorData[mode][RMQR]
+	}
 
 	return bitseq.BitSeq{}
 }
@@ -10,6 +10,9 @@ const (
 	ByteMode
 	KanjiMode
 	StructuredAppend
+	FNC1FirstPosition
+	FNC1SecondPosition
+	HanziMode   // GB/T 18284 Chinese QR Code
 	UnknownMode // Default or error case
 )
 
@@ -28,6 +31,12 @@ func (m QRMode) String() string {
 		return "Kanji"
 	case StructuredAppend:
 		return "StructuredAppend"
+	case FNC1FirstPosition:
+		return "FNC1FirstPosition"
+	case FNC1SecondPosition:
+		return "FNC1SecondPosition"
+	case HanziMode:
+		return "Hanzi"
 	default:
 		return "Unknown"
 	}
@@ -35,7 +44,7 @@ func (m QRMode) String() string {
 
 // getMode follows a simple hierarchy. It checks input_data against
 // the character sets of each mode in order of most to least "compressed."
-// TODO: Add Kanji mode detection and mode switching
+// Mixed input is better served by splitting it into segments of different modes.
 func GetMode(data string) QRMode {
 	isNumeric := true
 	isAlphanumeric := true
@@ -55,5 +64,24 @@ func GetMode(data string) QRMode {
 	if isAlphanumeric {
 		return AlphanumericMode
 	}
+	// Kanji characters take 13 bits in Kanji mode against 16 in byte mode
+	if _, ok := ToShiftJIS(data); ok {
+		return KanjiMode
+	}
+	// Likewise for GB 2312 characters in Hanzi mode
+	if _, ok := ToGB2312(data); ok {
+		return HanziMode
+	}
 	return ByteMode
 }
+
+// IsNumeric reports whether r belongs to the numeric mode character set.
+func IsNumeric(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// IsAlphanumeric reports whether r belongs to the alphanumeric mode character set.
+func IsAlphanumeric(r rune) bool {
+	_, ok := alphanumericValues[r]
+	return ok
+}
@@ -1,8 +1,12 @@
 package modes
 
 import (
-	"github.com/harogaston/qr-decoder/bitseq"
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
+
+	"github.com/harogaston/qr-decoder/bitseq"
 )
 
 func EncodeNumeric(input string) bitseq.BitSeq {
@@ -41,3 +45,23 @@ func EncodeNumeric(input string) bitseq.BitSeq {
 	}
 	return output
 }
+
+// DecodeNumeric reads count digits packed by EncodeNumeric: 10 bits per
+// group of 3 digits, 7 or 4 bits for the 2 or 1 remaining digits.
+func DecodeNumeric(r *bitseq.Reader, count int) (string, error) {
+	var b strings.Builder
+	for count > 0 {
+		digits := min(count, 3)
+		size := []int{0, 4, 7, 10}[digits]
+		val, ok := r.Read(size)
+		if !ok {
+			return "", errors.New("numeric segment: unexpected end of data")
+		}
+		if val >= []uint64{0, 10, 100, 1000}[digits] {
+			return "", fmt.Errorf("numeric segment: invalid %d digit group %d", digits, val)
+		}
+		fmt.Fprintf(&b, "%0*d", digits, val)
+		count -= digits
+	}
+	return b.String(), nil
+}
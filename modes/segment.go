@@ -0,0 +1,29 @@
+package modes
+
+import "fmt"
+
+// Segment is a run of input data encoded with a single mode. A symbol
+// carries one mode indicator and character count indicator per segment.
+//
+// Byte mode segments hold data already transcoded to the active charset.
+// ECI and structured append segments are headers and carry no data.
+type Segment struct {
+	Mode QRMode
+	Data string
+	ECI  int
+
+	// Structured append header
+	Position int  // 0 based position of the symbol in the sequence
+	Total    int  // number of symbols in the sequence, up to 16
+	Parity   byte // XOR of every byte of the whole message
+}
+
+func (s Segment) String() string {
+	switch s.Mode {
+	case ECI:
+		return fmt.Sprintf("%s(%d)", s.Mode, s.ECI)
+	case StructuredAppend:
+		return fmt.Sprintf("%s(%d/%d, parity 0x%02X)", s.Mode, s.Position+1, s.Total, s.Parity)
+	}
+	return fmt.Sprintf("%s(%q)", s.Mode, s.Data)
+}
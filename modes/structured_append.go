@@ -0,0 +1,49 @@
+package modes
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/harogaston/qr-decoder/bitseq"
+)
+
+// Maximum number of symbols in a structured append sequence
+const MaxStructuredAppendSymbols = 16
+
+// EncodeStructuredAppend encodes the structured append header that follows
+// the mode indicator: 4-bit symbol position (0 based), 4-bit total number of
+// symbols minus one and the parity byte.
+func EncodeStructuredAppend(position, total int, parity byte) bitseq.BitSeq {
+	if total < 1 || total > MaxStructuredAppendSymbols || position < 0 || position >= total {
+		panic(fmt.Sprintf("invalid structured append position %d of %d", position, total))
+	}
+	return bitseq.ConcatMany(
+		bitseq.FromInt(uint64(position), 4),
+		bitseq.FromInt(uint64(total-1), 4),
+		bitseq.FromInt(uint64(parity), 8),
+	)
+}
+
+// StructuredAppendParity returns the parity byte of a message: the XOR of
+// all its bytes.
+func StructuredAppendParity(message []byte) byte {
+	var parity byte
+	for _, b := range message {
+		parity ^= b
+	}
+	return parity
+}
+
+// DecodeStructuredAppend reads the header written by EncodeStructuredAppend.
+// A position past the last symbol of the sequence is an error.
+func DecodeStructuredAppend(r *bitseq.Reader) (position, total int, parity byte, err error) {
+	header, ok := r.Read(16)
+	if !ok {
+		return 0, 0, 0, errors.New("structured append: unexpected end of data")
+	}
+	position, total = int(header>>12), int(header>>8&0xF)+1
+	if position >= total {
+		return 0, 0, 0, fmt.Errorf("structured append: position %d of %d symbols", position, total)
+	}
+	return position, total, byte(header), nil
+}
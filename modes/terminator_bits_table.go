@@ -5,7 +5,39 @@ import (
 	"github.com/harogaston/qr-decoder/version"
 )
 
-func GetTerminatorBits(qrversion version.QRVersion, mode QRMode) bitseq.BitSeq {
-	// TODO: Implement different terminator bits for Micro QR Codes
-	return bitseq.FromInt(0, 4)
+// | Version   | Terminator |
+// | :-------- | :--------- |
+// | M1        | 000        |
+// | M2        | 00000      |
+// | M3        | 0000000    |
+// | M4        | 000000000  |
+// | 1 to 40   | 0000       |
+// | rMQR      | 000        |
+var terminatorData = map[modeIndicatorVersionClass]bitseq.BitSeq{
+	M1:         bitseq.ZeroSequence(3),
+	M2:         bitseq.ZeroSequence(5),
+	M3:         bitseq.ZeroSequence(7),
+	M4:         bitseq.ZeroSequence(9),
+	AllQRCodes: bitseq.ZeroSequence(4),
+	RMQR:       bitseq.ZeroSequence(3),
+}
+
+// GetTerminatorBits returns the end of message bits for a given QR version.
+func GetTerminatorBits(qrversion version.QRVersion) bitseq.BitSeq {
+	if qrversion.Format == version.FORMAT_MICRO_QR {
+		switch qrversion.Number {
+		case 1:
+			return terminatorData[M1]
+		case 2:
+			return terminatorData[M2]
+		case 3:
+			return terminatorData[M3]
+		case 4:
+			return terminatorData[M4]
+		}
+	}
+	if qrversion.Format == version.FORMAT_RMQR {
+		return terminatorData[RMQR]
+	}
+	return terminatorData[AllQRCodes]
 }
@@ -0,0 +1,200 @@
+package payload
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Contact is a contact card, from a vCard or a MECARD
+type Contact struct {
+	// Full name as displayed
+	FormattedName string
+	Name          Name
+	Nickname      string
+	Organization  string
+	Title         string
+	Phones        []TypedValue
+	Emails        []TypedValue
+	URLs          []string
+	Addresses     []Address
+	// As written, e.g. 1990-01-31, 19900131 or --0131
+	Birthday string
+	Note     string
+}
+
+// Name is the structured name of a contact
+type Name struct {
+	Family     string
+	Given      string
+	Additional string
+	Prefix     string
+	Suffix     string
+}
+
+// TypedValue is a value with its lower case types, e.g. a phone number of
+// types cell and work
+type TypedValue struct {
+	Value string
+	Types []string
+}
+
+// Address is a postal address
+type Address struct {
+	POBox      string
+	Extended   string
+	Street     string
+	Locality   string
+	Region     string
+	PostalCode string
+	Country    string
+	Types      []string
+}
+
+// ParseVCard parses a vCard 3.0 or 4.0 (RFC 2426 and RFC 6350). It must
+// hold a single card with a formatted name (FN), and a structured name (N)
+// in version 3.0. Properties not in Contact are ignored.
+func ParseVCard(data string) (*Contact, error) {
+	lines, err := contentLines("vCard", data)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) < 2 || lines[0].name != "BEGIN" || !strings.EqualFold(lines[0].value, "VCARD") {
+		return nil, errors.New("payload: vCard must start with BEGIN:VCARD")
+	}
+	last := lines[len(lines)-1]
+	if last.name != "END" || !strings.EqualFold(last.value, "VCARD") {
+		return nil, errors.New("payload: vCard must end with END:VCARD")
+	}
+
+	c := &Contact{}
+	var version string
+	hasName := false
+	for _, l := range lines[1 : len(lines)-1] {
+		switch l.name {
+		case "BEGIN", "END":
+			return nil, errors.New("payload: vCard holds more than one card")
+		case "VERSION":
+			if version != "" {
+				return nil, errors.New("payload: vCard VERSION is repeated")
+			}
+			version = l.value
+		case "FN":
+			c.FormattedName = l.text()
+		case "N":
+			n := append(l.components(), "", "", "", "")
+			c.Name = Name{Family: n[0], Given: n[1], Additional: n[2], Prefix: n[3], Suffix: n[4]}
+			hasName = true
+		case "NICKNAME":
+			c.Nickname = l.text()
+		case "ORG":
+			c.Organization = strings.Join(nonEmpty(l.components()), ", ")
+		case "TITLE":
+			c.Title = l.text()
+		case "TEL":
+			c.Phones = append(c.Phones, TypedValue{Value: l.text(), Types: l.types()})
+		case "EMAIL":
+			c.Emails = append(c.Emails, TypedValue{Value: l.text(), Types: l.types()})
+		case "URL":
+			c.URLs = append(c.URLs, l.text())
+		case "ADR":
+			a := append(l.components(), "", "", "", "", "", "", "")
+			c.Addresses = append(c.Addresses, Address{
+				POBox: a[0], Extended: a[1], Street: a[2], Locality: a[3], Region: a[4], PostalCode: a[5], Country: a[6],
+				Types: l.types(),
+			})
+		case "BDAY":
+			c.Birthday = l.text()
+		case "NOTE":
+			c.Note = l.text()
+		}
+	}
+
+	switch version {
+	case "":
+		return nil, errors.New("payload: vCard has no VERSION")
+	case "3.0":
+		if !hasName {
+			return nil, errors.New("payload: vCard 3.0 has no N")
+		}
+	case "4.0":
+	default:
+		return nil, fmt.Errorf("payload: unsupported vCard version %q, 3.0 or 4.0 needed", version)
+	}
+	if c.FormattedName == "" {
+		return nil, errors.New("payload: vCard has no FN")
+	}
+	return c, nil
+}
+
+// ParseMeCard parses a MECARD, e.g. MECARD:N:Doe,John;TEL:5550100;; It must
+// have a name, and a birthday must be a valid YYYYMMDD date. The formatted
+// name is the given name followed by the family name.
+func ParseMeCard(data string) (*Contact, error) {
+	body, ok := cutPrefixFold(data, "MECARD:")
+	if !ok {
+		return nil, errors.New("payload: MECARD must start with MECARD:")
+	}
+	fields, err := splitFields("MECARD", body)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Contact{}
+	for _, f := range fields {
+		switch f.key {
+		case "N":
+			family, given, _ := strings.Cut(f.value, ",")
+			c.Name = Name{Family: family, Given: given}
+			c.FormattedName = strings.TrimSpace(given + " " + family)
+		case "SOUND":
+		case "TEL", "TEL-AV":
+			if err := validatePhoneNumber(f.value); err != nil {
+				return nil, err
+			}
+			c.Phones = append(c.Phones, TypedValue{Value: f.value})
+			if f.key == "TEL-AV" {
+				c.Phones[len(c.Phones)-1].Types = []string{"video"}
+			}
+		case "EMAIL":
+			c.Emails = append(c.Emails, TypedValue{Value: f.value})
+		case "NOTE":
+			c.Note = f.value
+		case "BDAY":
+			if _, err := time.Parse("20060102", f.value); err != nil {
+				return nil, fmt.Errorf("payload: MECARD birthday %q is not a YYYYMMDD date", f.value)
+			}
+			c.Birthday = f.value
+		case "ADR":
+			a := append(strings.Split(f.value, ","), "", "", "", "", "", "", "")
+			c.Addresses = append(c.Addresses, Address{
+				POBox: a[0], Extended: a[1], Street: a[2], Locality: a[3], Region: a[4], PostalCode: a[5], Country: a[6],
+			})
+		case "URL":
+			c.URLs = append(c.URLs, f.value)
+		case "NICKNAME":
+			c.Nickname = f.value
+		case "ORG":
+			c.Organization = f.value
+		case "TITLE":
+			c.Title = f.value
+		default:
+			return nil, fmt.Errorf("payload: unknown MECARD field %s", f.key)
+		}
+	}
+	if c.FormattedName == "" {
+		return nil, errors.New("payload: MECARD has no name")
+	}
+	return c, nil
+}
+
+func nonEmpty(values []string) []string {
+	var kept []string
+	for _, v := range values {
+		if v != "" {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
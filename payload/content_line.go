@@ -0,0 +1,151 @@
+package payload
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contentLine is a property of a vCard (RFC 6350) or an iCalendar object
+// (RFC 5545), e.g. TEL;TYPE=cell:+1 555 0100
+type contentLine struct {
+	// Upper case, without group
+	name string
+	// By upper case name. A parameter without a value, as in vCard 2.1
+	// TEL;CELL:..., is a TYPE.
+	params map[string][]string
+	// Still escaped
+	value string
+}
+
+// contentLines unfolds text and splits it into content lines, blank lines
+// skipped
+func contentLines(kind, text string) ([]contentLine, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var unfolded []string
+	for _, line := range strings.Split(text, "\n") {
+		if len(unfolded) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			unfolded[len(unfolded)-1] += line[1:]
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			unfolded = append(unfolded, line)
+		}
+	}
+
+	var lines []contentLine
+	for _, line := range unfolded {
+		// The value starts at the first colon outside quotes
+		quoted := false
+		colon := -1
+		for i := 0; i < len(line) && colon < 0; i++ {
+			switch line[i] {
+			case '"':
+				quoted = !quoted
+			case ':':
+				if !quoted {
+					colon = i
+				}
+			}
+		}
+		if colon <= 0 {
+			return nil, fmt.Errorf("payload: %s line %q has no name and value", kind, line)
+		}
+
+		parts := splitQuoted(line[:colon], ';')
+		name := parts[0]
+		if dot := strings.LastIndexByte(name, '.'); dot >= 0 {
+			name = name[dot+1:]
+		}
+		if name == "" {
+			return nil, fmt.Errorf("payload: %s line %q has no name", kind, line)
+		}
+		l := contentLine{name: strings.ToUpper(name), params: map[string][]string{}, value: line[colon+1:]}
+		for _, param := range parts[1:] {
+			key, values, ok := strings.Cut(param, "=")
+			if !ok {
+				key, values = "TYPE", param
+			}
+			for _, v := range splitQuoted(values, ',') {
+				l.params[strings.ToUpper(key)] = append(l.params[strings.ToUpper(key)], strings.Trim(v, `"`))
+			}
+		}
+		lines = append(lines, l)
+	}
+	return lines, nil
+}
+
+// splitQuoted splits s around sep outside double quotes
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted := false
+	start := 0
+	for i := range len(s) {
+		switch {
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// param returns the first value of a parameter, upper case
+func (l contentLine) param(name string) string {
+	if values := l.params[name]; len(values) > 0 {
+		return strings.ToUpper(values[0])
+	}
+	return ""
+}
+
+// types returns the lower case TYPE parameters of the line
+func (l contentLine) types() []string {
+	var types []string
+	for _, t := range l.params["TYPE"] {
+		types = append(types, strings.ToLower(t))
+	}
+	return types
+}
+
+// text returns the unescaped value of a text property
+func (l contentLine) text() string {
+	return unescapeText(l.value)
+}
+
+// components splits a structured value at its unescaped semicolons, and
+// unescapes the components
+func (l contentLine) components() []string {
+	var components []string
+	start := 0
+	for i := 0; i < len(l.value); i++ {
+		switch l.value[i] {
+		case '\\':
+			i++
+		case ';':
+			components = append(components, unescapeText(l.value[start:i]))
+			start = i + 1
+		}
+	}
+	return append(components, unescapeText(l.value[start:]))
+}
+
+// unescapeText undoes the escapes of text values: \n or \N is a line break,
+// any other escaped character stands for itself
+func unescapeText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == 'n' || s[i] == 'N' {
+				b.WriteByte('\n')
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,198 @@
+package payload
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// EMVCoField is a data object of an EMVCo payload: a 2 digit ID and a value
+type EMVCoField struct {
+	ID    string
+	Value string
+}
+
+// EMVCo is an EMV merchant-presented payment payload (EMV QR Code
+// Specification for Payment Systems, Merchant-Presented Mode)
+type EMVCo struct {
+	// 01
+	PayloadFormat string
+	// Point of initiation 12, the payload is for a single transaction
+	Dynamic bool
+	// IDs 02 to 51, templates from 26 on
+	MerchantAccounts []EMVCoField
+	// ISO 18245
+	MerchantCategoryCode string
+	// ISO 4217 numeric code, e.g. 978 for euro
+	Currency string
+	// e.g. 12.30, empty if the payer enters it
+	Amount string
+	// ISO 3166-1 alpha 2
+	CountryCode  string
+	MerchantName string
+	MerchantCity string
+	PostalCode   string
+	// Template 62, e.g. bill number and terminal label
+	AdditionalData []EMVCoField
+	// Every data object, in order
+	Fields []EMVCoField
+}
+
+var (
+	amountEMVCoPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]*)?$`)
+	countryPattern     = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+// ParseEMVCo parses an EMVCo merchant payload and checks its CRC. The
+// payload format indicator 01 must come first, the CRC last, and the
+// merchant category code, currency, country code, merchant name and city
+// are required along with a merchant account.
+func ParseEMVCo(data string) (*EMVCo, error) {
+	fields, err := ParseEMVCoFields(data)
+	if err != nil {
+		return nil, err
+	}
+	if fields[0].ID != "00" || fields[0].Value != "01" {
+		return nil, errors.New("payload: EMVCo payload must start with payload format indicator 01")
+	}
+	crc := fields[len(fields)-1]
+	if crc.ID != "63" || len(crc.Value) != 4 {
+		return nil, errors.New("payload: EMVCo payload must end with a CRC of 4 hexadecimal digits")
+	}
+	// Over the payload up to the CRC value
+	if want := fmt.Sprintf("%04X", crc16(data[:len(data)-4])); !strings.EqualFold(crc.Value, want) {
+		return nil, fmt.Errorf("payload: EMVCo CRC is %s, %s computed", crc.Value, want)
+	}
+
+	e := &EMVCo{PayloadFormat: fields[0].Value, Fields: fields}
+	seen := map[string]bool{}
+	for _, f := range fields {
+		if seen[f.ID] {
+			return nil, fmt.Errorf("payload: EMVCo data object %s is repeated", f.ID)
+		}
+		seen[f.ID] = true
+		id, _ := strconv.Atoi(f.ID)
+		switch {
+		case id == 1:
+			if f.Value != "11" && f.Value != "12" {
+				return nil, fmt.Errorf("payload: EMVCo point of initiation %q is not 11 or 12", f.Value)
+			}
+			e.Dynamic = f.Value == "12"
+		case id >= 2 && id <= 51:
+			if id >= 26 {
+				if _, err := ParseEMVCoFields(f.Value); err != nil {
+					return nil, fmt.Errorf("payload: EMVCo merchant account %s: %w", f.ID, err)
+				}
+			}
+			e.MerchantAccounts = append(e.MerchantAccounts, f)
+		case id == 52:
+			if len(f.Value) != 4 || !digits(f.Value) {
+				return nil, fmt.Errorf("payload: EMVCo merchant category code %q is not 4 digits", f.Value)
+			}
+			e.MerchantCategoryCode = f.Value
+		case id == 53:
+			if len(f.Value) != 3 || !digits(f.Value) {
+				return nil, fmt.Errorf("payload: EMVCo currency %q is not 3 digits", f.Value)
+			}
+			e.Currency = f.Value
+		case id == 54:
+			if len(f.Value) > 13 || !amountEMVCoPattern.MatchString(f.Value) {
+				return nil, fmt.Errorf("payload: EMVCo amount %q is not a number of up to 13 characters", f.Value)
+			}
+			e.Amount = f.Value
+		case id == 58:
+			if !countryPattern.MatchString(f.Value) {
+				return nil, fmt.Errorf("payload: EMVCo country code %q is not 2 letters", f.Value)
+			}
+			e.CountryCode = f.Value
+		case id == 59:
+			if n := utf8.RuneCountInString(f.Value); n > 25 {
+				return nil, fmt.Errorf("payload: EMVCo merchant name is %d characters long, 25 max", n)
+			}
+			e.MerchantName = f.Value
+		case id == 60:
+			if n := utf8.RuneCountInString(f.Value); n > 15 {
+				return nil, fmt.Errorf("payload: EMVCo merchant city is %d characters long, 15 max", n)
+			}
+			e.MerchantCity = f.Value
+		case id == 61:
+			e.PostalCode = f.Value
+		case id == 62:
+			if e.AdditionalData, err = ParseEMVCoFields(f.Value); err != nil {
+				return nil, fmt.Errorf("payload: EMVCo additional data: %w", err)
+			}
+		}
+	}
+
+	for _, required := range []struct {
+		value string
+		name  string
+	}{
+		{e.MerchantCategoryCode, "merchant category code"},
+		{e.Currency, "currency"},
+		{e.CountryCode, "country code"},
+		{e.MerchantName, "merchant name"},
+		{e.MerchantCity, "merchant city"},
+	} {
+		if required.value == "" {
+			return nil, fmt.Errorf("payload: EMVCo payload has no %s", required.name)
+		}
+	}
+	if len(e.MerchantAccounts) == 0 {
+		return nil, errors.New("payload: EMVCo payload has no merchant account")
+	}
+	return e, nil
+}
+
+// ParseEMVCoFields splits data into data objects, each a 2 digit ID, a 2
+// digit length in characters and a value of that length. It also splits
+// templates, such as merchant accounts.
+func ParseEMVCoFields(data string) ([]EMVCoField, error) {
+	var fields []EMVCoField
+	rest := []rune(data)
+	for len(rest) > 0 {
+		if len(rest) < 4 || !digits(string(rest[:4])) {
+			return nil, fmt.Errorf("payload: EMVCo data object %q has no ID and length", string(rest))
+		}
+		id := string(rest[:2])
+		length, _ := strconv.Atoi(string(rest[2:4]))
+		if length == 0 || len(rest) < 4+length {
+			return nil, fmt.Errorf("payload: EMVCo data object %s has length %d, %d characters left", id, length, len(rest)-4)
+		}
+		fields = append(fields, EMVCoField{ID: id, Value: string(rest[4 : 4+length])})
+		rest = rest[4+length:]
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("payload: EMVCo data is empty")
+	}
+	return fields, nil
+}
+
+// crc16 is the CRC-16/CCITT-FALSE of the UTF-8 bytes of data: polynomial
+// 0x1021, initial value 0xFFFF
+func crc16(data string) uint16 {
+	crc := uint16(0xFFFF)
+	for i := range len(data) {
+		crc ^= uint16(data[i]) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func digits(s string) bool {
+	for i := range len(s) {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
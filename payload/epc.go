@@ -0,0 +1,145 @@
+package payload
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SEPATransfer is a SEPA credit transfer of the European Payments Council
+// quick response code (EPC069-12)
+type SEPATransfer struct {
+	// 001 or 002
+	Version string
+	// 1 (UTF-8) to 8 (ISO 8859-15)
+	Charset int
+	// Of the beneficiary bank, optional in version 002
+	BIC  string
+	Name string
+	IBAN string
+	// In euro cents, 0 if the amount is left to the payer
+	AmountCents int64
+	// ISO 20022 purpose code, e.g. CHAR
+	Purpose string
+	// Structured creditor reference, or unstructured remittance text
+	Reference string
+	Text      string
+	// Beneficiary to originator information
+	Information string
+}
+
+var (
+	bicPattern     = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+	ibanPattern    = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+	amountPattern  = regexp.MustCompile(`^EUR([0-9]{1,9})(?:\.([0-9]{1,2}))?$`)
+	purposePattern = regexp.MustCompile(`^[A-Z]{4}$`)
+)
+
+// Largest payload, in bytes
+const maxEPCLength = 331
+
+// ParseSEPATransfer parses an EPC069-12 payload: lines with the service tag
+// BCD, the version, the charset, the identification SCT, the BIC, the name
+// and IBAN of the beneficiary, the amount (e.g. EUR12.30), the purpose, the
+// reference or the text, and the information. Trailing empty lines can be
+// left out. Lengths, the IBAN check digits and the amount range (EUR0.01 to
+// EUR999999999.99) are validated.
+func ParseSEPATransfer(data string) (*SEPATransfer, error) {
+	if len(data) > maxEPCLength {
+		return nil, fmt.Errorf("payload: EPC payload is %d bytes long, %d max", len(data), maxEPCLength)
+	}
+	lines := strings.Split(strings.TrimSuffix(strings.ReplaceAll(data, "\r\n", "\n"), "\n"), "\n")
+	if len(lines) > 12 {
+		return nil, fmt.Errorf("payload: EPC payload has %d lines, 12 max", len(lines))
+	}
+	if len(lines) < 7 {
+		return nil, errors.New("payload: EPC payload ends before the IBAN")
+	}
+	lines = append(lines, make([]string, 12-len(lines))...)
+	if lines[0] != "BCD" {
+		return nil, errors.New("payload: EPC payload must start with BCD")
+	}
+	if lines[3] != "SCT" {
+		return nil, fmt.Errorf("payload: EPC identification %q is not SCT", lines[3])
+	}
+
+	t := &SEPATransfer{
+		Version: lines[1], BIC: lines[4], Name: lines[5], IBAN: lines[6],
+		Purpose: lines[8], Reference: lines[9], Text: lines[10], Information: lines[11],
+	}
+	if t.Version != "001" && t.Version != "002" {
+		return nil, fmt.Errorf("payload: EPC version %q is not 001 or 002", t.Version)
+	}
+	if len(lines[2]) != 1 || lines[2][0] < '1' || lines[2][0] > '8' {
+		return nil, fmt.Errorf("payload: EPC charset %q is not 1 to 8", lines[2])
+	}
+	t.Charset = int(lines[2][0] - '0')
+
+	if t.BIC == "" && t.Version == "001" {
+		return nil, errors.New("payload: EPC version 001 needs a BIC")
+	}
+	if t.BIC != "" && !bicPattern.MatchString(t.BIC) {
+		return nil, fmt.Errorf("payload: invalid BIC %q", t.BIC)
+	}
+	if t.Name == "" {
+		return nil, errors.New("payload: EPC payload has no beneficiary name")
+	}
+	if !ValidIBAN(t.IBAN) {
+		return nil, fmt.Errorf("payload: invalid IBAN %q", t.IBAN)
+	}
+
+	if lines[7] != "" {
+		m := amountPattern.FindStringSubmatch(lines[7])
+		if m == nil {
+			return nil, fmt.Errorf("payload: EPC amount %q is not EUR and up to 2 decimals", lines[7])
+		}
+		cents, _ := strconv.ParseInt(m[1]+(m[2] + "00")[:2], 10, 64)
+		if cents == 0 {
+			return nil, errors.New("payload: EPC amount is 0, EUR0.01 min")
+		}
+		t.AmountCents = cents
+	}
+	if t.Purpose != "" && !purposePattern.MatchString(t.Purpose) {
+		return nil, fmt.Errorf("payload: EPC purpose %q is not 4 letters", t.Purpose)
+	}
+	if t.Reference != "" && t.Text != "" {
+		return nil, errors.New("payload: EPC payload has both a reference and a text")
+	}
+
+	for _, f := range []struct {
+		name  string
+		value string
+		max   int
+	}{
+		{"beneficiary name", t.Name, 70},
+		{"reference", t.Reference, 35},
+		{"text", t.Text, 140},
+		{"information", t.Information, 70},
+	} {
+		if n := utf8.RuneCountInString(f.value); n > f.max {
+			return nil, fmt.Errorf("payload: EPC %s is %d characters long, %d max", f.name, n, f.max)
+		}
+	}
+	return t, nil
+}
+
+// ValidIBAN reports whether iban, without spaces, has the format of an IBAN
+// and valid check digits (ISO 13616, modulo 97)
+func ValidIBAN(iban string) bool {
+	if !ibanPattern.MatchString(iban) {
+		return false
+	}
+	// Country code and check digits last, letters as the numbers 10 to 35
+	remainder := 0
+	for _, r := range iban[4:] + iban[:4] {
+		if r >= 'A' && r <= 'Z' {
+			remainder = (remainder*100 + int(r-'A') + 10) % 97
+		} else {
+			remainder = (remainder*10 + int(r-'0')) % 97
+		}
+	}
+	return remainder == 1
+}
@@ -0,0 +1,180 @@
+package payload
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a calendar event
+type Event struct {
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	// Exclusive. Without DTEND or DURATION, the day after Start for all day
+	// events and Start otherwise.
+	End time.Time
+	// Start and End are dates
+	AllDay bool
+	// Start and End have no time zone, they are read as UTC
+	Floating bool
+}
+
+// ParseEvent parses an iCalendar VEVENT (RFC 5545), alone or as the single
+// event of a VCALENDAR. It must have a DTSTART, and at most one of DTEND and
+// DURATION, of the same kind (date or date and time) and not before it. Time
+// zones of TZID parameters are looked up in the IANA database. Other
+// components, such as alarms, are skipped.
+func ParseEvent(data string) (*Event, error) {
+	lines, err := contentLines("iCalendar", data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Components open, outermost first
+	var open []string
+	events := 0
+	e := &Event{}
+	// DTSTART, DTEND and DURATION
+	times := map[string]*contentLine{}
+	for i, l := range lines {
+		switch l.name {
+		case "BEGIN":
+			component := strings.ToUpper(l.value)
+			if i == 0 && component != "VEVENT" && component != "VCALENDAR" {
+				return nil, errors.New("payload: iCalendar must start with BEGIN:VEVENT or BEGIN:VCALENDAR")
+			}
+			if component == "VEVENT" {
+				events++
+			}
+			open = append(open, component)
+			continue
+		case "END":
+			if len(open) == 0 || !strings.EqualFold(l.value, open[len(open)-1]) {
+				return nil, fmt.Errorf("payload: iCalendar END:%s does not match", l.value)
+			}
+			open = open[:len(open)-1]
+			if len(open) == 0 && i != len(lines)-1 {
+				return nil, errors.New("payload: iCalendar data after the last END")
+			}
+			continue
+		}
+		if len(open) == 0 || open[len(open)-1] != "VEVENT" {
+			continue
+		}
+
+		switch l.name {
+		case "SUMMARY":
+			e.Summary = l.text()
+		case "DESCRIPTION":
+			e.Description = l.text()
+		case "LOCATION":
+			e.Location = l.text()
+		case "DTSTART", "DTEND", "DURATION":
+			if times[l.name] != nil {
+				return nil, fmt.Errorf("payload: iCalendar %s is repeated", l.name)
+			}
+			times[l.name] = &lines[i]
+		}
+	}
+	if len(open) > 0 {
+		return nil, fmt.Errorf("payload: iCalendar %s has no END", open[len(open)-1])
+	}
+	if events != 1 {
+		return nil, fmt.Errorf("payload: iCalendar holds %d events, 1 needed", events)
+	}
+
+	start, end, duration := times["DTSTART"], times["DTEND"], times["DURATION"]
+	if start == nil {
+		return nil, errors.New("payload: iCalendar event has no DTSTART")
+	}
+	e.Start, e.AllDay, e.Floating, err = start.dateTime()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case end != nil && duration != nil:
+		return nil, errors.New("payload: iCalendar event has both DTEND and DURATION")
+	case end != nil:
+		var allDay, floating bool
+		e.End, allDay, floating, err = end.dateTime()
+		if err != nil {
+			return nil, err
+		}
+		if allDay != e.AllDay || floating != e.Floating {
+			return nil, errors.New("payload: iCalendar DTEND is not of the same kind as DTSTART")
+		}
+	case duration != nil:
+		d, err := parseDuration(duration.value)
+		if err != nil {
+			return nil, err
+		}
+		e.End = e.Start.Add(d)
+	case e.AllDay:
+		e.End = e.Start.AddDate(0, 0, 1)
+	default:
+		e.End = e.Start
+	}
+	if e.End.Before(e.Start) {
+		return nil, errors.New("payload: iCalendar event ends before it starts")
+	}
+	return e, nil
+}
+
+// dateTime parses a DATE or DATE-TIME value, in UTC (trailing Z), in the
+// time zone of the TZID parameter or floating
+// returns the time and whether it is a date or floating
+func (l contentLine) dateTime() (time.Time, bool, bool, error) {
+	value := l.value
+	if l.param("VALUE") == "DATE" || (len(value) == 8 && l.param("VALUE") == "") {
+		t, err := time.Parse("20060102", value)
+		if err != nil {
+			return time.Time{}, false, false, fmt.Errorf("payload: iCalendar %s %q is not a YYYYMMDD date", l.name, value)
+		}
+		return t, true, false, nil
+	}
+
+	location := time.UTC
+	floating := true
+	if utc, ok := strings.CutSuffix(value, "Z"); ok {
+		value, floating = utc, false
+	} else if tzid := l.params["TZID"]; len(tzid) > 0 {
+		var err error
+		if location, err = time.LoadLocation(tzid[0]); err != nil {
+			return time.Time{}, false, false, fmt.Errorf("payload: iCalendar unknown time zone %q", tzid[0])
+		}
+		floating = false
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, location)
+	if err != nil {
+		return time.Time{}, false, false, fmt.Errorf("payload: iCalendar %s %q is not a date and time", l.name, l.value)
+	}
+	return t, false, floating, nil
+}
+
+// Durations of RFC 5545: weeks, or days and a time
+var durationPattern = regexp.MustCompile(`^\+?P(?:(\d+)W|(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?)$`)
+
+// parseDuration parses a non-negative iCalendar duration, e.g. PT1H30M
+func parseDuration(value string) (time.Duration, error) {
+	m := durationPattern.FindStringSubmatch(value)
+	if m == nil || strings.TrimPrefix(value, "+") == "P" || strings.HasSuffix(value, "T") {
+		return 0, fmt.Errorf("payload: iCalendar DURATION %q is not a non-negative duration", value)
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+1] != "" {
+			n, err := strconv.Atoi(m[i+1])
+			if err != nil {
+				return 0, fmt.Errorf("payload: iCalendar DURATION %q is too long", value)
+			}
+			d += time.Duration(n) * unit
+		}
+	}
+	return d, nil
+}
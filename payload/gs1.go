@@ -0,0 +1,22 @@
+package payload
+
+import (
+	"strings"
+
+	"github.com/harogaston/qr-decoder/gs1"
+)
+
+// Symbology identifier of GS1 QR Code, which readers may prefix to the data
+const gs1SymbologyIdentifier = "]Q3"
+
+// ParseGS1 parses GS1 element strings, either as decoded from a symbol with
+// FNC1 in first position, group separators included, or in their human
+// readable interpretation, e.g. (01)09501101530003(17)260101. A leading ]Q3
+// symbology identifier is skipped.
+func ParseGS1(data string) ([]gs1.Element, error) {
+	data = strings.TrimPrefix(data, gs1SymbologyIdentifier)
+	if strings.HasPrefix(data, "(") {
+		return gs1.Parse(data)
+	}
+	return gs1.ParseElementString(data)
+}
@@ -0,0 +1,108 @@
+package payload
+
+import (
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// OTP is a one-time password generator to provision, following the Key Uri
+// Format of Google Authenticator
+type OTP struct {
+	// totp (time based) or hotp (counter based)
+	Type    string
+	Issuer  string
+	Account string
+	Secret  []byte
+	// SHA1, SHA256 or SHA512
+	Algorithm string
+	// 6 or 8
+	Digits int
+	// Seconds a totp password lasts
+	Period int
+	// Initial counter of hotp
+	Counter uint64
+}
+
+// ParseOTP parses an otpauth:// URI, e.g.
+// otpauth://totp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Example
+// The secret must be valid base 32, padding optional, and an issuer both in
+// the label and as a parameter must be the same. The algorithm defaults to
+// SHA1, the digits to 6 and the period to 30 seconds, and hotp needs a
+// counter.
+func ParseOTP(data string) (*OTP, error) {
+	u, err := url.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("payload: %w", err)
+	}
+	if !strings.EqualFold(u.Scheme, "otpauth") {
+		return nil, errors.New("payload: OTP URI must start with otpauth://")
+	}
+	o := &OTP{Type: strings.ToLower(u.Host), Algorithm: "SHA1", Digits: 6}
+	if o.Type != "totp" && o.Type != "hotp" {
+		return nil, fmt.Errorf("payload: OTP type %q is not totp or hotp", u.Host)
+	}
+
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		o.Issuer, o.Account = strings.TrimSpace(issuer), strings.TrimSpace(account)
+	} else {
+		o.Account = strings.TrimSpace(label)
+	}
+	if o.Account == "" {
+		return nil, errors.New("payload: OTP label has no account name")
+	}
+
+	params, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("payload: OTP parameters: %w", err)
+	}
+	if issuer := params.Get("issuer"); issuer != "" {
+		if o.Issuer != "" && o.Issuer != issuer {
+			return nil, fmt.Errorf("payload: OTP issuer %q differs from the label issuer %q", issuer, o.Issuer)
+		}
+		o.Issuer = issuer
+	}
+
+	secret := strings.ToUpper(strings.TrimRight(params.Get("secret"), "="))
+	if secret == "" {
+		return nil, errors.New("payload: OTP has no secret")
+	}
+	if o.Secret, err = base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret); err != nil {
+		return nil, errors.New("payload: OTP secret is not base 32")
+	}
+
+	if algorithm := params.Get("algorithm"); algorithm != "" {
+		o.Algorithm = strings.ToUpper(algorithm)
+		if o.Algorithm != "SHA1" && o.Algorithm != "SHA256" && o.Algorithm != "SHA512" {
+			return nil, fmt.Errorf("payload: OTP algorithm %q is not SHA1, SHA256 or SHA512", algorithm)
+		}
+	}
+	if digits := params.Get("digits"); digits != "" {
+		if o.Digits, err = strconv.Atoi(digits); err != nil || (o.Digits != 6 && o.Digits != 8) {
+			return nil, fmt.Errorf("payload: OTP digits %q is not 6 or 8", digits)
+		}
+	}
+
+	switch o.Type {
+	case "totp":
+		o.Period = 30
+		if period := params.Get("period"); period != "" {
+			if o.Period, err = strconv.Atoi(period); err != nil || o.Period <= 0 {
+				return nil, fmt.Errorf("payload: OTP period %q is not a number of seconds", period)
+			}
+		}
+	case "hotp":
+		counter := params.Get("counter")
+		if counter == "" {
+			return nil, errors.New("payload: hotp has no counter")
+		}
+		if o.Counter, err = strconv.ParseUint(counter, 10, 64); err != nil {
+			return nil, fmt.Errorf("payload: OTP counter %q is not a number", counter)
+		}
+	}
+	return o, nil
+}
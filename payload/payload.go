@@ -0,0 +1,143 @@
+// Package payload recognises the common grammars of QR Code payloads, e.g. a
+// Wi-Fi network or a contact card, and parses them into typed structs.
+// Parsers validate their input and return an error rather than guess what
+// a malformed payload meant.
+package payload
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/harogaston/qr-decoder/gs1"
+)
+
+// Kind is the content type of a payload
+type Kind string
+
+const (
+	KindText   Kind = "text"
+	KindURL    Kind = "url"
+	KindWiFi   Kind = "wifi"
+	KindVCard  Kind = "vcard"
+	KindMeCard Kind = "mecard"
+	KindEmail  Kind = "email"
+	KindPhone  Kind = "tel"
+	KindSMS    Kind = "sms"
+	KindGeo    Kind = "geo"
+	KindEvent  Kind = "event"
+	KindOTP    Kind = "otpauth"
+	KindSEPA   Kind = "epc"
+	KindEMVCo  Kind = "emvco"
+	KindGS1    Kind = "gs1"
+)
+
+// Payload is a parsed payload. Text always holds the payload, and the field
+// of its Kind its parsed form.
+type Payload struct {
+	Kind Kind
+	Text string
+
+	URL  *url.URL
+	WiFi *WiFi
+	// KindVCard and KindMeCard
+	Contact *Contact
+	Email   *Email
+	Phone   *Phone
+	SMS     *SMS
+	Geo     *Geo
+	Event   *Event
+	OTP     *OTP
+	SEPA    *SEPATransfer
+	EMVCo   *EMVCo
+	GS1     []gs1.Element
+}
+
+// Prefixes of the payload kinds, compared without case
+var prefixes = []struct {
+	prefix string
+	kind   Kind
+}{
+	{"http://", KindURL},
+	{"https://", KindURL},
+	{"WIFI:", KindWiFi},
+	{"BEGIN:VCARD", KindVCard},
+	{"MECARD:", KindMeCard},
+	{"mailto:", KindEmail},
+	{"tel:", KindPhone},
+	{"sms:", KindSMS},
+	{"smsto:", KindSMS},
+	{"geo:", KindGeo},
+	{"BEGIN:VEVENT", KindEvent},
+	{"BEGIN:VCALENDAR", KindEvent},
+	{"otpauth://", KindOTP},
+	{"BCD\n", KindSEPA},
+	{"BCD\r\n", KindSEPA},
+	{"000201", KindEMVCo},
+	{gs1SymbologyIdentifier, KindGS1},
+}
+
+// Bracketed AI starting the human readable interpretation of GS1 element
+// strings
+var gs1HRIPattern = regexp.MustCompile(`^\(\d{2,4}\)`)
+
+// Classify returns the kind of data from its prefix, without parsing it.
+// GS1 element strings are recognised by the ]Q3 symbology identifier, a
+// leading bracketed AI or a group separator. Anything else is KindText.
+func Classify(data string) Kind {
+	for _, p := range prefixes {
+		if len(data) >= len(p.prefix) && strings.EqualFold(data[:len(p.prefix)], p.prefix) {
+			return p.kind
+		}
+	}
+	if gs1HRIPattern.MatchString(data) || strings.Contains(data, gs1.GroupSeparator) {
+		return KindGS1
+	}
+	return KindText
+}
+
+// Parse classifies data and parses it with the parser of its kind
+func Parse(data string) (*Payload, error) {
+	p := &Payload{Kind: Classify(data), Text: data}
+	var err error
+	switch p.Kind {
+	case KindURL:
+		p.URL, err = ParseURL(data)
+	case KindWiFi:
+		p.WiFi, err = ParseWiFi(data)
+	case KindVCard:
+		p.Contact, err = ParseVCard(data)
+	case KindMeCard:
+		p.Contact, err = ParseMeCard(data)
+	case KindEmail:
+		p.Email, err = ParseMailto(data)
+	case KindPhone:
+		p.Phone, err = ParseTel(data)
+	case KindSMS:
+		p.SMS, err = ParseSMS(data)
+	case KindGeo:
+		p.Geo, err = ParseGeo(data)
+	case KindEvent:
+		p.Event, err = ParseEvent(data)
+	case KindOTP:
+		p.OTP, err = ParseOTP(data)
+	case KindSEPA:
+		p.SEPA, err = ParseSEPATransfer(data)
+	case KindEMVCo:
+		p.EMVCo, err = ParseEMVCo(data)
+	case KindGS1:
+		p.GS1, err = ParseGS1(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// cutPrefixFold removes prefix from s, compared without case
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
@@ -0,0 +1,188 @@
+package payload
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harogaston/qr-decoder/gs1"
+)
+
+// EMV QR Code Specification for Payment Systems, Merchant-Presented Mode,
+// example payload
+const emvcoExample = "00020101021229300012D156000000000510A93FO3230Q31280012D15600000001030812345678520441115802CN" +
+	"5914BEST TRANSPORT6007BEIJING64200002ZH0104最佳运输0202北京540523.7253031565502016233030412340603***0708A6008667" +
+	"0902ME91320016A0112233449988770708123456786304A13A"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		kind Kind
+	}{
+		{"Text", "Hello, World", KindText},
+		{"URL", "HTTPS://example.com/path?q=1", KindURL},
+		{"WiFi", `WIFI:T:WPA;S:My\;Network;P:pass\:word;H:true;;`, KindWiFi},
+		{"vCard", "BEGIN:VCARD\r\nVERSION:4.0\r\nFN:Jane Doe\r\nEND:VCARD\r\n", KindVCard},
+		{"MECARD", "MECARD:N:Doe,John;TEL:+15550100;;", KindMeCard},
+		{"Email", "mailto:info@example.com", KindEmail},
+		{"Phone", "tel:+1-201-555-0123", KindPhone},
+		{"SMS", "SMSTO:+15550100:Hi", KindSMS},
+		{"Geo", "geo:37.786971,-122.399677", KindGeo},
+		{"Event", "BEGIN:VEVENT\nDTSTART:20260301\nEND:VEVENT", KindEvent},
+		{"OTP", "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP", KindOTP},
+		{"EPC", "BCD\n002\n1\nSCT\n\nRed Cross\nDE89370400440532013000", KindSEPA},
+		{"EMVCo", emvcoExample, KindEMVCo},
+		{"GS1", "]Q301095011015300031726010110ABC", KindGS1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(tt.data)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.data, err)
+			}
+			if p.Kind != tt.kind || p.Text != tt.data {
+				t.Errorf("Parse(%q) = %s %q, want %s", tt.data, p.Kind, p.Text, tt.kind)
+			}
+		})
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	w, err := ParseWiFi(`WIFI:T:WPA;S:My\;Network;P:pass\:word;H:true;;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *w != (WiFi{Security: WiFiWPA, SSID: "My;Network", Password: "pass:word", Hidden: true}) {
+		t.Errorf("ParseWiFi() = %+v", w)
+	}
+	for name, want := range map[string]WiFiSecurity{"wpa": WiFiWPA, "WPA2": WiFiWPA, "WPA3": WiFiSAE, "nopass": WiFiOpen, "wpa2-eap": WiFiWPA2EAP} {
+		if security, err := ParseWiFiSecurity(name); err != nil || security != want {
+			t.Errorf("ParseWiFiSecurity(%q) = %q, %v, want %q", name, security, err, want)
+		}
+	}
+	if _, err := ParseWiFiSecurity("WPA4"); err == nil {
+		t.Error("ParseWiFiSecurity(WPA4) accepted")
+	}
+
+	c, err := ParseVCard("BEGIN:VCARD\nVERSION:3.0\nN:Doe;Jane;;Dr.;\nFN:Dr. Jane Doe\n" +
+		"item1.TEL;TYPE=cell,voice:+1 555 0100\nADR;TYPE=work:;;1 Main St\\, Suite 2;Springfield;;12345;USA\n" +
+		"NOTE:First line\\nsecond\n line\nEND:VCARD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.FormattedName != "Dr. Jane Doe" || c.Name.Given != "Jane" || c.Name.Prefix != "Dr." ||
+		len(c.Phones) != 1 || c.Phones[0].Value != "+1 555 0100" || strings.Join(c.Phones[0].Types, ",") != "cell,voice" ||
+		len(c.Addresses) != 1 || c.Addresses[0].Street != "1 Main St, Suite 2" || c.Addresses[0].Country != "USA" ||
+		c.Note != "First line\nsecondline" {
+		t.Errorf("ParseVCard() = %+v", c)
+	}
+
+	e, err := ParseEvent("BEGIN:VCALENDAR\nVERSION:2.0\nBEGIN:VEVENT\nSUMMARY:Launch\n" +
+		"DTSTART;TZID=Europe/Paris:20260301T090000\nDURATION:PT1H30M\n" +
+		"BEGIN:VALARM\nACTION:DISPLAY\nSUMMARY:Alarm\nEND:VALARM\nEND:VEVENT\nEND:VCALENDAR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Summary != "Launch" || !e.Start.Equal(time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)) ||
+		e.End.Sub(e.Start) != 90*time.Minute || e.AllDay || e.Floating {
+		t.Errorf("ParseEvent() = %+v", e)
+	}
+
+	o, err := ParseOTP("otpauth://hotp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Example&counter=7&digits=8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Type != "hotp" || o.Issuer != "Example" || o.Account != "alice@example.com" ||
+		string(o.Secret) != "Hello!\xde\xad\xbe\xef" || o.Digits != 8 || o.Counter != 7 {
+		t.Errorf("ParseOTP() = %+v", o)
+	}
+
+	s, err := ParseSEPATransfer("BCD\r\n001\r\n1\r\nSCT\r\nCOBADEFFXXX\r\nRed Cross\r\nDE89370400440532013000\r\nEUR12.3\r\nCHAR\r\n\r\nDonation\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.AmountCents != 1230 || s.Purpose != "CHAR" || s.Text != "Donation" || s.BIC != "COBADEFFXXX" {
+		t.Errorf("ParseSEPATransfer() = %+v", s)
+	}
+
+	m, err := ParseEMVCo(emvcoExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Dynamic || m.MerchantName != "BEST TRANSPORT" || m.Amount != "23.72" || m.Currency != "156" ||
+		len(m.MerchantAccounts) != 2 || len(m.AdditionalData) != 4 {
+		t.Errorf("ParseEMVCo() = %+v", m)
+	}
+
+	g, err := ParseGeo("geo:-33.8688,151.2093,58;u=12?q=Opera%20House")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Latitude != -33.8688 || g.Altitude == nil || *g.Altitude != 58 || g.Uncertainty == nil || *g.Uncertainty != 12 || g.Query != "Opera House" {
+		t.Errorf("ParseGeo() = %+v", g)
+	}
+
+	// Decoded element strings, group separator after the variable length
+	// batch, and their human readable interpretation
+	want := []gs1.Element{{AI: "10", Value: "ABC"}, {AI: "01", Value: "09501101530003"}, {AI: "17", Value: "260101"}}
+	for _, data := range []string{"10ABC\x1D010950110153000317260101", "(10)ABC(01)09501101530003(17)260101"} {
+		elements, err := ParseGS1(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(elements) != len(want) || elements[0] != want[0] || elements[1] != want[1] || elements[2] != want[2] {
+			t.Errorf("ParseGS1(%q) = %v, want %v", data, elements, want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	invalid := []struct {
+		name string
+		data string
+	}{
+		{"URL without host", "https:///path"},
+		{"WiFi without SSID", "WIFI:T:WPA;P:password;;"},
+		{"WiFi short passphrase", "WIFI:T:WPA;S:Net;P:short;;"},
+		{"WiFi open with password", "WIFI:T:nopass;S:Net;P:password;;"},
+		{"WiFi unknown security", "WIFI:T:WPA4;S:Net;P:password;;"},
+		{"WiFi repeated field", "WIFI:S:Net;S:Other;;"},
+		{"WiFi EAP without method", "WIFI:T:WPA2-EAP;S:Net;I:alice;;"},
+		{"WiFi lone backslash", `WIFI:S:Net\`},
+		{"vCard version", "BEGIN:VCARD\nVERSION:2.1\nFN:Jane\nEND:VCARD"},
+		{"vCard without FN", "BEGIN:VCARD\nVERSION:4.0\nN:Doe;Jane\nEND:VCARD"},
+		{"vCard unterminated", "BEGIN:VCARD\nVERSION:4.0\nFN:Jane"},
+		{"MECARD birthday", "MECARD:N:Doe,John;BDAY:19901340;;"},
+		{"MECARD without name", "MECARD:TEL:5550100;;"},
+		{"Email address", "mailto:not an address"},
+		{"Email without recipient", "mailto:?subject=Hi"},
+		{"Phone letters", "tel:+1-800-FLOWERS"},
+		{"SMS number", "sms:hello?body=Hi"},
+		{"Geo latitude", "geo:91,0"},
+		{"Geo reference system", "geo:1,2;crs=nad27"},
+		{"Event without start", "BEGIN:VEVENT\nSUMMARY:Launch\nEND:VEVENT"},
+		{"Event ends before start", "BEGIN:VEVENT\nDTSTART:20260302\nDTEND:20260301\nEND:VEVENT"},
+		{"Event end and duration", "BEGIN:VEVENT\nDTSTART:20260301T090000Z\nDTEND:20260301T100000Z\nDURATION:PT1H\nEND:VEVENT"},
+		{"Event mixed kinds", "BEGIN:VEVENT\nDTSTART:20260301\nDTEND:20260301T100000Z\nEND:VEVENT"},
+		{"OTP secret", "otpauth://totp/alice?secret=NOT-BASE32"},
+		{"OTP issuer mismatch", "otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP&issuer=Other"},
+		{"OTP without counter", "otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP"},
+		{"OTP digits", "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&digits=5"},
+		{"EPC IBAN check digits", "BCD\n002\n1\nSCT\n\nRed Cross\nDE88370400440532013000"},
+		{"EPC amount", "BCD\n002\n1\nSCT\n\nRed Cross\nDE89370400440532013000\nEUR1.234"},
+		{"EPC BIC in version 001", "BCD\n001\n1\nSCT\n\nRed Cross\nDE89370400440532013000"},
+		{"EPC reference and text", "BCD\n002\n1\nSCT\n\nRed Cross\nDE89370400440532013000\n\n\nRF18539007547034\nDonation"},
+		{"EMVCo CRC", emvcoExample[:len(emvcoExample)-4] + "A13B"},
+		{"EMVCo without CRC", "000201010211"},
+		{"GS1 check digit", "]Q30109501101530004"},
+		{"GS1 unknown AI", "\x1D2312345"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if p, err := Parse(tt.data); err == nil {
+				t.Errorf("Parse(%q) = %+v, expected an error", tt.data, p)
+			}
+		})
+	}
+}
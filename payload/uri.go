@@ -0,0 +1,273 @@
+package payload
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ParseURL parses an http or https URL, which must have a host
+func ParseURL(data string) (*url.URL, error) {
+	if strings.ContainsAny(data, " \t\r\n") {
+		return nil, errors.New("payload: URL contains white space")
+	}
+	u, err := url.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("payload: %w", err)
+	}
+	if scheme := strings.ToLower(u.Scheme); scheme != "http" && scheme != "https" {
+		return nil, fmt.Errorf("payload: URL scheme %q is not http or https", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, errors.New("payload: URL has no host")
+	}
+	return u, nil
+}
+
+// Email is a message to write (RFC 6068)
+type Email struct {
+	To      []string
+	Cc      []string
+	Bcc     []string
+	Subject string
+	Body    string
+}
+
+// ParseMailto parses a mailto: URI, e.g.
+// mailto:info@example.com?subject=Hello. Every address must be valid and
+// there must be one recipient at least. Header fields other than to, cc,
+// bcc, subject and body are ignored.
+func ParseMailto(data string) (*Email, error) {
+	rest, ok := cutPrefixFold(data, "mailto:")
+	if !ok {
+		return nil, errors.New("payload: mailto URI must start with mailto:")
+	}
+	to, query, _ := strings.Cut(rest, "?")
+
+	e := &Email{}
+	var err error
+	if e.To, err = addresses(to); err != nil {
+		return nil, err
+	}
+	fields, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("payload: mailto header fields: %w", err)
+	}
+	for name, values := range fields {
+		for _, value := range values {
+			var list []string
+			switch strings.ToLower(name) {
+			case "to":
+				list, err = addresses(value)
+				e.To = append(e.To, list...)
+			case "cc":
+				list, err = addresses(value)
+				e.Cc = append(e.Cc, list...)
+			case "bcc":
+				list, err = addresses(value)
+				e.Bcc = append(e.Bcc, list...)
+			case "subject":
+				e.Subject = value
+			case "body":
+				e.Body = value
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(e.To)+len(e.Cc)+len(e.Bcc) == 0 {
+		return nil, errors.New("payload: mailto URI has no recipient")
+	}
+	return e, nil
+}
+
+// addresses splits a percent-encoded, comma separated list of addresses and
+// validates them
+func addresses(list string) ([]string, error) {
+	decoded, err := url.PathUnescape(list)
+	if err != nil {
+		return nil, fmt.Errorf("payload: mailto address: %w", err)
+	}
+	if decoded == "" {
+		return nil, nil
+	}
+	var valid []string
+	for _, a := range strings.Split(decoded, ",") {
+		address, err := mail.ParseAddress(a)
+		if err != nil || address.Name != "" || address.Address != strings.TrimSpace(a) {
+			return nil, fmt.Errorf("payload: invalid email address %q", a)
+		}
+		valid = append(valid, address.Address)
+	}
+	return valid, nil
+}
+
+// Phone is a telephone number (RFC 3966)
+type Phone struct {
+	// As written, visual separators included, e.g. +1-201-555-0123
+	Number string
+	// The ext parameter, if any
+	Extension string
+}
+
+// ParseTel parses a tel: URI, e.g. tel:+1-201-555-0123;ext=1234. Parameters
+// other than ext are ignored.
+func ParseTel(data string) (*Phone, error) {
+	rest, ok := cutPrefixFold(data, "tel:")
+	if !ok {
+		return nil, errors.New("payload: tel URI must start with tel:")
+	}
+	parts := strings.Split(rest, ";")
+	p := &Phone{Number: parts[0]}
+	if err := validatePhoneNumber(p.Number); err != nil {
+		return nil, err
+	}
+	for _, param := range parts[1:] {
+		if ext, ok := cutPrefixFold(param, "ext="); ok {
+			if err := validatePhoneNumber(ext); err != nil || strings.HasPrefix(ext, "+") {
+				return nil, fmt.Errorf("payload: invalid phone extension %q", ext)
+			}
+			p.Extension = ext
+		}
+	}
+	return p, nil
+}
+
+// validatePhoneNumber checks a global number (+ and digits) or a local one
+// (digits, * and #), with visual separators - . ( and )
+func validatePhoneNumber(number string) error {
+	digits := 0
+	for i, r := range number {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == '+' && i == 0:
+		case r == '*' || r == '#':
+			if strings.HasPrefix(number, "+") {
+				return fmt.Errorf("payload: global phone number %q contains %q", number, r)
+			}
+		case r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return fmt.Errorf("payload: phone number %q contains %q", number, r)
+		}
+	}
+	if digits == 0 {
+		return fmt.Errorf("payload: phone number %q has no digits", number)
+	}
+	return nil
+}
+
+// SMS is a text message to send
+type SMS struct {
+	Numbers []string
+	Body    string
+}
+
+// ParseSMS parses an sms: URI (RFC 5724), e.g. sms:+15105550101?body=Hello,
+// or the common SMSTO:+15105550101:Hello
+func ParseSMS(data string) (*SMS, error) {
+	if rest, ok := cutPrefixFold(data, "smsto:"); ok {
+		number, body, _ := strings.Cut(rest, ":")
+		if err := validatePhoneNumber(number); err != nil {
+			return nil, err
+		}
+		return &SMS{Numbers: []string{number}, Body: body}, nil
+	}
+
+	rest, ok := cutPrefixFold(data, "sms:")
+	if !ok {
+		return nil, errors.New("payload: sms URI must start with sms: or SMSTO:")
+	}
+	numbers, query, _ := strings.Cut(rest, "?")
+	s := &SMS{}
+	for _, number := range strings.Split(numbers, ",") {
+		// Parameters of the number are ignored
+		number, _, _ = strings.Cut(number, ";")
+		decoded, err := url.PathUnescape(number)
+		if err != nil {
+			return nil, fmt.Errorf("payload: sms number: %w", err)
+		}
+		if err := validatePhoneNumber(decoded); err != nil {
+			return nil, err
+		}
+		s.Numbers = append(s.Numbers, decoded)
+	}
+	fields, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("payload: sms fields: %w", err)
+	}
+	s.Body = fields.Get("body")
+	return s, nil
+}
+
+// Geo is a location in WGS 84 (RFC 5870)
+type Geo struct {
+	// In degrees
+	Latitude  float64
+	Longitude float64
+	// In meters, if given
+	Altitude *float64
+	// The u parameter in meters, if given
+	Uncertainty *float64
+	// The q field of the query, a search for maps applications
+	Query string
+}
+
+// ParseGeo parses a geo: URI, e.g. geo:37.786971,-122.399677;u=35. The
+// coordinate reference system can only be WGS 84.
+func ParseGeo(data string) (*Geo, error) {
+	rest, ok := cutPrefixFold(data, "geo:")
+	if !ok {
+		return nil, errors.New("payload: geo URI must start with geo:")
+	}
+	rest, query, _ := strings.Cut(rest, "?")
+	params := strings.Split(rest, ";")
+	coordinates := strings.Split(params[0], ",")
+	if len(coordinates) != 2 && len(coordinates) != 3 {
+		return nil, fmt.Errorf("payload: geo URI needs 2 or 3 coordinates, got %q", params[0])
+	}
+	var values [3]float64
+	for i, c := range coordinates {
+		v, err := strconv.ParseFloat(c, 64)
+		if err != nil {
+			return nil, fmt.Errorf("payload: invalid geo coordinate %q", c)
+		}
+		values[i] = v
+	}
+	g := &Geo{Latitude: values[0], Longitude: values[1]}
+	if g.Latitude < -90 || g.Latitude > 90 {
+		return nil, fmt.Errorf("payload: latitude %v out of range", g.Latitude)
+	}
+	if g.Longitude < -180 || g.Longitude > 180 {
+		return nil, fmt.Errorf("payload: longitude %v out of range", g.Longitude)
+	}
+	if len(coordinates) == 3 {
+		g.Altitude = &values[2]
+	}
+
+	for _, param := range params[1:] {
+		name, value, _ := strings.Cut(param, "=")
+		switch strings.ToLower(name) {
+		case "crs":
+			if !strings.EqualFold(value, "wgs84") {
+				return nil, fmt.Errorf("payload: unsupported geo coordinate reference system %q", value)
+			}
+		case "u":
+			u, err := strconv.ParseFloat(value, 64)
+			if err != nil || u < 0 {
+				return nil, fmt.Errorf("payload: invalid geo uncertainty %q", value)
+			}
+			g.Uncertainty = &u
+		}
+	}
+	fields, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("payload: geo query: %w", err)
+	}
+	g.Query = fields.Get("q")
+	return g, nil
+}
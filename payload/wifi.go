@@ -0,0 +1,311 @@
+package payload
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// field is a KEY:value field of a MECARD or WIFI payload
+type field struct {
+	key   string
+	value string
+}
+
+// splitFields splits the fields of the body of a MECARD or WIFI payload,
+// e.g. S:My network;P:pass\;word;; into keys and unescaped values. A
+// backslash escapes the next character, and an empty field ends the body.
+func splitFields(kind, body string) ([]field, error) {
+	var fields []field
+	var raw strings.Builder
+	escaped, ended := false, false
+	for _, r := range body {
+		switch {
+		case ended:
+			return nil, fmt.Errorf("payload: %s data after the terminating ;;", kind)
+		case escaped:
+			raw.WriteRune('\\')
+			raw.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ';':
+			if raw.Len() == 0 {
+				ended = true
+				continue
+			}
+			f, err := splitField(kind, raw.String())
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, f)
+			raw.Reset()
+		default:
+			raw.WriteRune(r)
+		}
+	}
+	if escaped {
+		return nil, fmt.Errorf("payload: %s ends with a lone backslash", kind)
+	}
+	// The terminating ;; is often left out
+	if raw.Len() > 0 {
+		f, err := splitField(kind, raw.String())
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// splitField splits an escaped KEY:value field
+func splitField(kind, raw string) (field, error) {
+	key, value, ok := strings.Cut(raw, ":")
+	if !ok || key == "" || strings.Contains(key, "\\") {
+		return field{}, fmt.Errorf("payload: %s field %q is not KEY:value", kind, raw)
+	}
+	var b strings.Builder
+	escaped := false
+	for _, r := range value {
+		if r == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		b.WriteRune(r)
+		escaped = false
+	}
+	return field{key: strings.ToUpper(key), value: b.String()}, nil
+}
+
+// WiFiSecurity is the authentication type of a Wi-Fi network
+type WiFiSecurity string
+
+const (
+	WiFiOpen WiFiSecurity = "nopass"
+	WiFiWEP  WiFiSecurity = "WEP"
+	// WPA or WPA2 personal
+	WiFiWPA WiFiSecurity = "WPA"
+	// WPA3 personal
+	WiFiSAE WiFiSecurity = "SAE"
+	// WPA2 enterprise
+	WiFiWPA2EAP WiFiSecurity = "WPA2-EAP"
+)
+
+// WiFi is the configuration of a Wi-Fi network
+type WiFi struct {
+	// The zero Security is WiFiOpen
+	Security WiFiSecurity
+	SSID     string
+	Password string
+	Hidden   bool
+	// The network only accepts WPA3: a WPA network whose transition disable
+	// indication (R) has bit 0 set
+	TransitionDisable bool
+
+	// WPA2-EAP: method (e.g. PEAP, TLS, TTLS or PWD), identities and phase 2
+	// method (e.g. MSCHAPV2)
+	EAPMethod         string
+	Identity          string
+	AnonymousIdentity string
+	Phase2Method      string
+}
+
+// Security types by their T field, compared without case. WPA2 is read as
+// WPA.
+var wifiSecurities = map[string]WiFiSecurity{
+	"NOPASS": WiFiOpen, "WEP": WiFiWEP, "WPA": WiFiWPA, "WPA2": WiFiWPA,
+	"SAE": WiFiSAE, "WPA3": WiFiSAE, "WPA2-EAP": WiFiWPA2EAP,
+}
+
+// ParseWiFiSecurity returns the security type named s, compared without
+// case, e.g. wpa, WPA2 or WPA3
+func ParseWiFiSecurity(s string) (WiFiSecurity, error) {
+	security, ok := wifiSecurities[strings.ToUpper(s)]
+	if !ok {
+		return "", fmt.Errorf("payload: unknown WIFI security type %q", s)
+	}
+	return security, nil
+}
+
+// ParseWiFi parses a Wi-Fi network configuration, e.g.
+// WIFI:T:WPA;S:My network;P:secret password;; and validates it. Without a T
+// field the network is open.
+func ParseWiFi(data string) (*WiFi, error) {
+	body, ok := cutPrefixFold(data, "WIFI:")
+	if !ok {
+		return nil, errors.New("payload: WIFI payload must start with WIFI:")
+	}
+	fields, err := splitFields("WIFI", body)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &WiFi{Security: WiFiOpen}
+	seen := map[string]bool{}
+	for _, f := range fields {
+		if seen[f.key] {
+			return nil, fmt.Errorf("payload: WIFI field %s is repeated", f.key)
+		}
+		seen[f.key] = true
+		switch f.key {
+		case "T":
+			if f.value != "" {
+				if w.Security, err = ParseWiFiSecurity(f.value); err != nil {
+					return nil, err
+				}
+			}
+		case "S":
+			w.SSID = f.value
+		case "P":
+			w.Password = f.value
+		case "H":
+			w.Hidden, err = strconv.ParseBool(f.value)
+			if err != nil {
+				return nil, fmt.Errorf("payload: WIFI hidden flag %q is not true or false", f.value)
+			}
+		case "R":
+			bits, err := strconv.ParseUint(f.value, 16, 8)
+			if err != nil {
+				return nil, fmt.Errorf("payload: WIFI transition disable %q is not hexadecimal", f.value)
+			}
+			w.TransitionDisable = bits&1 == 1
+		case "E":
+			w.EAPMethod = f.value
+		case "I":
+			w.Identity = f.value
+		case "A":
+			w.AnonymousIdentity = f.value
+		case "PH2":
+			w.Phase2Method = f.value
+		default:
+			return nil, fmt.Errorf("payload: unknown WIFI field %s", f.key)
+		}
+	}
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Validate checks the SSID, the password for the security type, and that
+// only WPA2-EAP networks have EAP settings
+func (w *WiFi) Validate() error {
+	security := cmp.Or(w.Security, WiFiOpen)
+	if w.SSID == "" {
+		return errors.New("payload: WIFI network has no SSID")
+	}
+	if len(w.SSID) > 32 {
+		return fmt.Errorf("payload: WIFI SSID is %d bytes long, 32 max", len(w.SSID))
+	}
+
+	eap := w.EAPMethod != "" || w.Identity != "" || w.AnonymousIdentity != "" || w.Phase2Method != ""
+	if eap && security != WiFiWPA2EAP {
+		return fmt.Errorf("payload: WIFI EAP settings for a %s network", security)
+	}
+	if w.TransitionDisable && security != WiFiWPA && security != WiFiSAE {
+		return fmt.Errorf("payload: WIFI transition disable for a %s network", security)
+	}
+
+	switch security {
+	case WiFiOpen:
+		if w.Password != "" {
+			return errors.New("payload: WIFI open network has a password")
+		}
+	case WiFiWEP:
+		// 40 or 104 bit keys, as ASCII or hexadecimal
+		n := len(w.Password)
+		if !((n == 5 || n == 13) && printableASCII(w.Password)) && !((n == 10 || n == 26) && hexadecimal(w.Password)) {
+			return errors.New("payload: WIFI WEP key must be 5 or 13 ASCII characters, or 10 or 26 hexadecimal digits")
+		}
+	case WiFiWPA:
+		return validatePassphrase(w.Password)
+	case WiFiSAE:
+		// SAE passwords have no length limits
+		if w.Password == "" {
+			return errors.New("payload: WIFI SAE network has no password")
+		}
+	case WiFiWPA2EAP:
+		if w.EAPMethod == "" {
+			return errors.New("payload: WIFI WPA2-EAP network has no EAP method")
+		}
+	default:
+		return fmt.Errorf("payload: unknown WIFI security type %q", security)
+	}
+	return nil
+}
+
+// String returns the WIFI payload of w, e.g.
+// WIFI:T:WPA;S:My network;P:secret password;; with the special characters
+// \ ; , : and " of the values escaped. Empty fields are left out, and so is
+// T for open networks. Call Validate first.
+func (w *WiFi) String() string {
+	var b strings.Builder
+	b.WriteString("WIFI:")
+	write := func(key, value string) {
+		if value == "" {
+			return
+		}
+		b.WriteString(key)
+		b.WriteByte(':')
+		for _, r := range value {
+			if strings.ContainsRune(`\;,:"`, r) {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		}
+		b.WriteByte(';')
+	}
+	if w.Security != WiFiOpen {
+		write("T", string(w.Security))
+	}
+	if w.TransitionDisable {
+		write("R", "1")
+	}
+	write("S", w.SSID)
+	write("P", w.Password)
+	if w.Hidden {
+		write("H", "true")
+	}
+	write("E", w.EAPMethod)
+	write("A", w.AnonymousIdentity)
+	write("I", w.Identity)
+	write("PH2", w.Phase2Method)
+	b.WriteByte(';')
+	return b.String()
+}
+
+// validatePassphrase checks a WPA passphrase: 8 to 63 printable ASCII
+// characters, or a 64 hexadecimal digit pre-shared key
+func validatePassphrase(password string) error {
+	if len(password) == 64 && hexadecimal(password) {
+		return nil
+	}
+	if len(password) < 8 || len(password) > 63 {
+		return fmt.Errorf("payload: WIFI WPA passphrase is %d characters long, 8 to 63 needed", len(password))
+	}
+	if !printableASCII(password) {
+		return errors.New("payload: WIFI WPA passphrase must be printable ASCII")
+	}
+	return nil
+}
+
+func printableASCII(s string) bool {
+	for i := range len(s) {
+		if s[i] < ' ' || s[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func hexadecimal(s string) bool {
+	for i := range len(s) {
+		c := s[i]
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') && !(c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
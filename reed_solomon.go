@@ -1,5 +1,10 @@
 package main
 
+import (
+	"fmt"
+	"slices"
+)
+
 // Galois Field GF(256) arithmetic for QR Codes
 // Primitive polynomial: x^8 + x^4 + x^3 + x^2 + 1 (0x11D)
 
@@ -180,3 +185,204 @@ func reedSolomonEncode(data []byte, numECCodewords int) []byte {
 	}
 	return ecBytes
 }
+
+// Reed-Solomon Decoding
+
+// UncorrectableError reports a block with more damage than its error
+// correction codewords can repair: 2 x errors + erasures must not exceed the
+// number of error correction codewords.
+type UncorrectableError struct {
+	ECCodewords int
+	Erasures    int
+	Reason      string
+}
+
+func (e *UncorrectableError) Error() string {
+	return fmt.Sprintf("reed-solomon: uncorrectable block (%d error correction codewords, %d erasures): %s",
+		e.ECCodewords, e.Erasures, e.Reason)
+}
+
+// evaluate returns p(x) using Horner's method
+func (p *polynomial) evaluate(x int) int {
+	y := 0
+	for _, c := range p.coeffs {
+		y = gfMul(y, x) ^ c
+	}
+	return y
+}
+
+func polyAdd(p1, p2 *polynomial) *polynomial {
+	if len(p1.coeffs) < len(p2.coeffs) {
+		p1, p2 = p2, p1
+	}
+	coeffs := make([]int, len(p1.coeffs))
+	copy(coeffs, p1.coeffs)
+	offset := len(p1.coeffs) - len(p2.coeffs)
+	for i, c := range p2.coeffs {
+		coeffs[offset+i] ^= c
+	}
+	return newPolynomial(coeffs)
+}
+
+func polyScale(p *polynomial, factor int) *polynomial {
+	coeffs := make([]int, len(p.coeffs))
+	for i, c := range p.coeffs {
+		coeffs[i] = gfMul(c, factor)
+	}
+	return newPolynomial(coeffs)
+}
+
+// polyShift multiplies p by x^n
+func polyShift(p *polynomial, n int) *polynomial {
+	return newPolynomial(append(slices.Clone(p.coeffs), make([]int, n)...))
+}
+
+// polyDerivative returns the formal derivative of p. In GF(2^8) the even
+// powers cancel out.
+func polyDerivative(p *polynomial) *polynomial {
+	n := p.degree()
+	if n == 0 {
+		return newPolynomial([]int{0})
+	}
+	coeffs := make([]int, n)
+	for i := range n {
+		if (n-i)%2 == 1 {
+			coeffs[i] = p.coeffs[i]
+		}
+	}
+	return newPolynomial(coeffs)
+}
+
+// syndromes returns S_j = c(2^j) for j = 0 to numECCodewords-1, the roots of
+// the generator polynomial. They are all zero for a valid block.
+func syndromes(codewords []byte, numECCodewords int) ([]int, bool) {
+	c := make([]int, len(codewords))
+	for i, b := range codewords {
+		c[i] = int(b)
+	}
+	p := &polynomial{coeffs: c}
+
+	s := make([]int, numECCodewords)
+	valid := true
+	for j := range s {
+		s[j] = p.evaluate(gfPow(2, j))
+		if s[j] != 0 {
+			valid = false
+		}
+	}
+	return s, valid
+}
+
+// reedSolomonDecode corrects a block of data followed by numECCodewords error
+// correction codewords, as built by reedSolomonEncode. erasures holds the
+// indexes of codewords known to be unreliable, each of which costs half as
+// much correction capacity as an error at an unknown position.
+//
+// returns the corrected block and the number of codewords it changed, outside
+// of the erasures and among them, or an *UncorrectableError
+func reedSolomonDecode(codewords []byte, numECCodewords int, erasures []int) ([]byte, int, int, error) {
+	n := len(codewords)
+	uncorrectable := func(reason string) error {
+		return &UncorrectableError{ECCodewords: numECCodewords, Erasures: len(erasures), Reason: reason}
+	}
+	if len(erasures) > numECCodewords {
+		return nil, 0, 0, uncorrectable("too many erasures")
+	}
+	for _, pos := range erasures {
+		if pos < 0 || pos >= n {
+			return nil, 0, 0, fmt.Errorf("reed-solomon: erasure %d outside of a %d codeword block", pos, n)
+		}
+	}
+
+	s, valid := syndromes(codewords, numECCodewords)
+	if valid {
+		return slices.Clone(codewords), 0, 0, nil
+	}
+
+	// Codeword i is the coefficient of x^(n-1-i), its locator is 2^(n-1-i)
+	locator := func(pos int) int { return gfPow(2, n-1-pos) }
+
+	// Step 1 - Erasure locator Γ(x) = Π (1 + X_k x)
+	gamma := newPolynomial([]int{1})
+	for _, pos := range erasures {
+		gamma = polyMul(gamma, newPolynomial([]int{locator(pos), 1}))
+	}
+
+	// Step 2 - Berlekamp-Massey, started from the erasure locator, finds the
+	// errata locator Λ(x)
+	e := len(erasures)
+	lambda, b := gamma, gamma
+	l := e
+	for k := e; k < numECCodewords; k++ {
+		// Discrepancy Δ = Σ Λ_i S_(k-i)
+		delta := 0
+		for i := 0; i <= lambda.degree() && i <= k; i++ {
+			delta ^= gfMul(lambda.coeffs[lambda.degree()-i], s[k-i])
+		}
+		if delta == 0 {
+			b = polyShift(b, 1)
+			continue
+		}
+		next := polyAdd(lambda, polyScale(polyShift(b, 1), delta))
+		if 2*l <= k+e {
+			l = k + 1 + e - l
+			b = polyScale(lambda, gfDiv(1, delta))
+		} else {
+			b = polyShift(b, 1)
+		}
+		lambda = next
+	}
+	if lambda.degree() != l || 2*(l-e)+e > numECCodewords {
+		return nil, 0, 0, uncorrectable("too many errors")
+	}
+
+	// Step 3 - Chien search: position i is wrong if Λ(X_i^-1) = 0
+	var positions []int
+	for i := range n {
+		if lambda.evaluate(gfDiv(1, locator(i))) == 0 {
+			positions = append(positions, i)
+		}
+	}
+	if len(positions) != l {
+		return nil, 0, 0, uncorrectable("errors outside of the block")
+	}
+
+	// Step 4 - Forney: the magnitude at X_i is X_i Ω(X_i^-1) / Λ'(X_i^-1),
+	// with the error evaluator Ω(x) = S(x) Λ(x) mod x^numECCodewords
+	sCoeffs := make([]int, numECCodewords)
+	for j := range s {
+		sCoeffs[numECCodewords-1-j] = s[j]
+	}
+	omega := polyMul(newPolynomial(sCoeffs), lambda)
+	if len(omega.coeffs) > numECCodewords {
+		omega = newPolynomial(omega.coeffs[len(omega.coeffs)-numECCodewords:])
+	}
+	derivative := polyDerivative(lambda)
+
+	corrected := slices.Clone(codewords)
+	for _, pos := range positions {
+		xInv := gfDiv(1, locator(pos))
+		denominator := derivative.evaluate(xInv)
+		if denominator == 0 {
+			return nil, 0, 0, uncorrectable("repeated error locator root")
+		}
+		magnitude := gfMul(locator(pos), gfDiv(omega.evaluate(xInv), denominator))
+		corrected[pos] ^= byte(magnitude)
+	}
+
+	if _, valid := syndromes(corrected, numECCodewords); !valid {
+		return nil, 0, 0, uncorrectable("correction failed")
+	}
+	numErrors, numErasures := 0, 0
+	for _, pos := range positions {
+		switch {
+		case corrected[pos] == codewords[pos]:
+			// an erasure that held the right value
+		case slices.Contains(erasures, pos):
+			numErasures++
+		default:
+			numErrors++
+		}
+	}
+	return corrected, numErrors, numErasures, nil
+}
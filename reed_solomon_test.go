@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+	"slices"
 	"testing"
 )
 
@@ -97,3 +99,56 @@ func TestRSEncode(t *testing.T) {
 		t.Errorf("RS check failed: remainder is not zero: %v", remPoly.coeffs)
 	}
 }
+
+func TestRSDecode(t *testing.T) {
+	data := []byte("hello reed-solomon")
+	numEC := 10
+	block := append(slices.Clone(data), reedSolomonEncode(data, numEC)...)
+
+	tests := []struct {
+		name         string
+		damaged      []int // positions flipped
+		erasures     []int
+		wantErrors   int
+		wantErasures int
+	}{
+		{"No damage", nil, nil, 0, 0},
+		{"Errors only", []int{0, 7, 12, 20, 27}, nil, 5, 0},
+		{"Erasures only", []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 0, 10},
+		{"Errors and erasures", []int{0, 5, 9, 15, 20, 25}, []int{5, 9, 15, 20}, 2, 4},
+		// An erasure that happens to hold the right value is not counted
+		{"Intact erasure", []int{3}, []int{3, 4}, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			damaged := slices.Clone(block)
+			for _, pos := range tt.damaged {
+				damaged[pos] ^= 0x5A
+			}
+			corrected, numErrors, numErasures, err := reedSolomonDecode(damaged, numEC, tt.erasures)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(corrected, block) {
+				t.Errorf("corrected = %v, want %v", corrected, block)
+			}
+			if numErrors != tt.wantErrors || numErasures != tt.wantErasures {
+				t.Errorf("corrected %d errors and %d erasures, want %d and %d",
+					numErrors, numErasures, tt.wantErrors, tt.wantErasures)
+			}
+		})
+	}
+
+	// One error too many
+	damaged := slices.Clone(block)
+	for _, pos := range []int{0, 4, 8, 12, 16, 20} {
+		damaged[pos] ^= 0x5A
+	}
+	var uncorrectable *UncorrectableError
+	if _, _, _, err := reedSolomonDecode(damaged, numEC, nil); !errors.As(err, &uncorrectable) {
+		t.Errorf("err = %v, want an *UncorrectableError", err)
+	}
+	if _, _, _, err := reedSolomonDecode(block, numEC, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}); !errors.As(err, &uncorrectable) {
+		t.Errorf("err = %v, want an *UncorrectableError for 11 erasures", err)
+	}
+}
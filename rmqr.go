@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+)
+
+const (
+	// rMQR format information masks, one for each copy
+	rmqr_format_information_finder_mask     = 0b011111101010110010
+	rmqr_format_information_sub_finder_mask = 0b100000101001111011
+	// rMQR always uses the QR Code data mask 100
+	rmqr_mask_pattern = 0b100
+)
+
+// rMQR symbols are width x height rectangles with a finder pattern on the
+// left, a finder sub-pattern in the lower right corner, corner finder
+// patterns in the other two corners and timing patterns along every edge.
+// Alignment patterns sit on the top and bottom edges, joined by vertical
+// timing patterns.
+func (qr *qr) generate_rmqr() {
+	// Functions patterns. Timing patterns go first, the other patterns
+	// overlap them on the edges.
+	qr.rmqr_timing_patterns()
+	qr.rmqr_finder_pattern()
+	qr.rmqr_finder_sub_pattern()
+	qr.rmqr_corner_finder_patterns()
+	qr.rmqr_alignment_patterns()
+
+	// Encoding region
+	qr.reserve_rmqr_format_information_area()
+	qr.data_and_error_correction()
+
+	// Masking, there is a single mask pattern
+	if qr.debug {
+		fmt.Println("DEBUG: Masking disabled")
+	} else {
+		qr.matrix = qr.apply_mask(rmqr_mask_pattern, qr.matrix)
+	}
+	qr.mask = rmqr_mask_pattern
+	qr.place_rmqr_format_information()
+}
+
+// sets a function pattern module
+func (qr *qr) set_function_module(row, col int, bit Bit) {
+	qr.matrix[row][col] = module{bit: bit}
+	qr.is_function_pattern[row][col] = true
+}
+
+// places the timing patterns along the four edges and the vertical timing
+// patterns between the alignment patterns. Modules on even rows and columns are dark.
+func (qr *qr) rmqr_timing_patterns() {
+	timing := func(k int) Bit {
+		if k%2 == 0 {
+			return One
+		}
+		return Zero
+	}
+
+	for j := range qr.width {
+		qr.set_function_module(0, j, timing(j))
+		qr.set_function_module(qr.height-1, j, timing(j))
+	}
+	for i := range qr.height {
+		qr.set_function_module(i, 0, timing(i))
+		qr.set_function_module(i, qr.width-1, timing(i))
+	}
+	for _, col := range rmqr_alignment_columns[qr.width] {
+		for i := 3; i < qr.height-3; i++ {
+			qr.set_function_module(i, col, timing(i))
+		}
+	}
+}
+
+// places the 7 x 7 finder pattern on the left side and its separator
+func (qr *qr) rmqr_finder_pattern() {
+	for i := range 7 {
+		for j := range 7 {
+			// 7 x 7 dark, 5 x 5 light and 3 x 3 dark concentric squares
+			if min(i, j, 6-i, 6-j) == 1 {
+				qr.set_function_module(i, j, Zero)
+			} else {
+				qr.set_function_module(i, j, One)
+			}
+		}
+	}
+
+	// Separator on the right side, and below unless the finder pattern
+	// spans the whole height (R7). Edge modules keep the timing pattern.
+	for i := 1; i < min(8, qr.height-1); i++ {
+		qr.set_function_module(i, 7, Zero)
+	}
+	if qr.height > 7 {
+		for j := 1; j < 8; j++ {
+			qr.set_function_module(7, j, Zero)
+		}
+	}
+}
+
+// places the 5 x 5 finder sub-pattern in the lower right corner
+func (qr *qr) rmqr_finder_sub_pattern() {
+	for i := range 5 {
+		for j := range 5 {
+			// 5 x 5 dark, 3 x 3 light and single dark module
+			bit := One
+			if min(i, j, 4-i, 4-j) == 1 {
+				bit = Zero
+			}
+			qr.set_function_module(qr.height-5+i, qr.width-5+j, bit)
+		}
+	}
+}
+
+// places the corner finder patterns in the upper right and lower left corners
+func (qr *qr) rmqr_corner_finder_patterns() {
+	// Upper right
+	qr.set_function_module(0, qr.width-2, One)
+	qr.set_function_module(0, qr.width-1, One)
+	qr.set_function_module(1, qr.width-2, Zero)
+	qr.set_function_module(1, qr.width-1, One)
+
+	// Lower left, below the finder pattern separator
+	for j := range 3 {
+		qr.set_function_module(qr.height-1, j, One)
+	}
+	if qr.height > 9 {
+		qr.set_function_module(qr.height-2, 0, One)
+		qr.set_function_module(qr.height-2, 1, Zero)
+	}
+}
+
+// places the 3 x 3 alignment patterns (dark with a light center) on the top
+// and bottom edges
+func (qr *qr) rmqr_alignment_patterns() {
+	for _, col := range rmqr_alignment_columns[qr.width] {
+		for _, row := range []int{1, qr.height - 2} {
+			for i := row - 1; i <= row+1; i++ {
+				for j := col - 1; j <= col+1; j++ {
+					qr.set_function_module(i, j, One)
+				}
+			}
+			qr.set_function_module(row, col, Zero)
+		}
+	}
+}
+
+// marks both format information areas as reserved: next to the finder
+// pattern and next to the finder sub-pattern
+func (qr *qr) reserve_rmqr_format_information_area() {
+	for n := range 18 {
+		i, j := rmqr_format_position_finder(n)
+		qr.is_function_pattern[i][j] = true
+		i, j = qr.rmqr_format_position_sub_finder(n)
+		qr.is_function_pattern[i][j] = true
+	}
+}
+
+// places both copies of the format information
+func (qr *qr) place_rmqr_format_information() {
+	finder, subFinder, err := GenerateRMQRFormatInformation(qr.version.Number, qr.error_corr_level)
+	if err != nil {
+		panic(err)
+	}
+	for n := range 18 {
+		i, j := rmqr_format_position_finder(n)
+		qr.matrix[i][j] = format_bit_module(finder, n)
+		i, j = qr.rmqr_format_position_sub_finder(n)
+		qr.matrix[i][j] = format_bit_module(subFinder, n)
+	}
+}
+
+// returns the position of format information bit n (0 being the least
+// significant) next to the finder pattern: a 5 x 3 block from (1, 8) filled
+// column by column, then (1-3, 11)
+func rmqr_format_position_finder(n int) (row, col int) {
+	if n < 15 {
+		return 1 + n%5, 8 + n/5
+	}
+	return 1 + n - 15, 11
+}
+
+// returns the position of format information bit n next to the finder
+// sub-pattern: a 5 x 3 block from (height-6, width-8) filled column by
+// column, then (height-6, width-5 to width-3)
+func (qr *qr) rmqr_format_position_sub_finder(n int) (row, col int) {
+	if n < 15 {
+		return qr.height - 6 + n%5, qr.width - 8 + n/5
+	}
+	return qr.height - 6, qr.width - 5 + n - 15
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/harogaston/qr-decoder/images"
+	"github.com/harogaston/qr-decoder/version"
+	"github.com/harogaston/qr-decoder/writer"
+)
+
+// DamageModel is a kind of damage applied to a symbol, with a severity from
+// 0 (none) to 1
+type DamageModel string
+
+const (
+	// Each module is flipped with probability severity
+	DamageFlips DamageModel = "flips"
+	// Straight scratches 1 module wide remove the ink of the modules they
+	// cross, until they cover a share severity of the symbol
+	DamageScratches DamageModel = "scratches"
+	// A dark square at the center, where logos go, covering a share severity
+	// of the symbol
+	DamageBlotch DamageModel = "blotch"
+	// The design rendered 4 pixels per module, blurred over up to 2 modules
+	// and with Gaussian noise of up to half the grey scale
+	DamageBlurNoise DamageModel = "blur"
+)
+
+// DamageModels lists every damage model
+var DamageModels = []DamageModel{DamageFlips, DamageScratches, DamageBlotch, DamageBlurNoise}
+
+// Severities are tried in these steps
+const robustnessStep = 0.01
+
+// Pixels per module of the renders of DamageBlurNoise
+const damageModuleSize = 4
+
+// RobustnessResult is the decode margin of a symbol under a damage model
+type RobustnessResult struct {
+	Model   DamageModel
+	ECLevel errcorr
+	Mask    int
+	// Highest severity that every trial survived
+	Threshold float64
+}
+
+// Robustness measures the DecodeMargin of each damage model for the design of
+// r (logo, reflectance reversal and mirroring) drawn with shape, at every
+// error correction level that fits the version of r (or the smallest version
+// that fits at each level) and with every data mask.
+func Robustness(r QRRequest, shape writer.Shape, models []DamageModel, trials int, seed uint64) ([]RobustnessResult, error) {
+	if trials < 1 {
+		return nil, fmt.Errorf("robustness: %d trials, at least 1 is needed", trials)
+	}
+	var results []RobustnessResult
+	for _, level := range errCorrLevels {
+		req := r
+		req.err_corr_level = string(level)
+		if _, _, err := analyze(req); err != nil {
+			continue
+		}
+		symbol := NewQRCode(req)
+		if symbol.version.Format != version.FORMAT_QR_MODEL_2 {
+			return nil, errors.New("robustness: only QR Code symbols can be decoded")
+		}
+		for mask := range 8 {
+			masked := symbol.withMask(mask)
+			masked.reversed = symbol.reversed
+			if symbol.mirrored {
+				masked.mirrored = true
+				masked.mirror()
+			}
+			for _, model := range models {
+				threshold, err := DecodeMargin(masked, shape, model, trials, seed)
+				if err != nil {
+					return nil, err
+				}
+				results = append(results, RobustnessResult{Model: model, ECLevel: level, Mask: mask, Threshold: threshold})
+			}
+		}
+	}
+	if len(results) == 0 {
+		return nil, errors.New("robustness: the data fits no error correction level")
+	}
+	return results, nil
+}
+
+// DecodeMargin returns the highest severity of the damage model, in steps of
+// robustnessStep, that trials damaged copies of the design of qr drawn with
+// shape survive: they all decode to the data of qr. The grid models damage
+// the modules left once the logo has cleared its own, and DamageBlurNoise
+// damages the symbol as the writer draws it (see writer.Rasterize). Trial i
+// draws its damage from the random source seeded with seed and i, so that
+// the damage of a trial only grows with severity.
+func DecodeMargin(qr *qr, shape writer.Shape, model DamageModel, trials int, seed uint64) (float64, error) {
+	if qr.version.Format != version.FORMAT_QR_MODEL_2 {
+		return 0, errors.New("robustness: only QR Code symbols can be decoded")
+	}
+	if !slices.Contains(DamageModels, model) {
+		return 0, fmt.Errorf("robustness: unknown damage model %q", model)
+	}
+	if trials < 1 {
+		return 0, fmt.Errorf("robustness: %d trials, at least 1 is needed", trials)
+	}
+	design := qr.svgRequest(shape)
+	design.Scale = damageModuleSize
+
+	var render *image.Gray
+	var matrix [][]module
+	if model == DamageBlurNoise {
+		var err error
+		if render, err = writer.Rasterize(design); err != nil {
+			return 0, fmt.Errorf("robustness: %w", err)
+		}
+	} else {
+		matrix = make([][]module, qr.size)
+		for i, row := range qr.matrix {
+			matrix[i] = slices.Clone(row)
+		}
+		for _, p := range writer.LogoModules(design) {
+			matrix[p.Y][p.X].bit = Zero
+		}
+		if qr.mirrored {
+			for _, row := range matrix {
+				slices.Reverse(row)
+			}
+		}
+	}
+	if !survives(matrix, render, string(qr.data), model, 0, rand.New(rand.NewPCG(seed, 0))) {
+		return 0, errors.New("robustness: the undamaged design does not decode")
+	}
+
+	threshold := 0.0
+	for step := 1; float64(step)*robustnessStep <= 1; step++ {
+		severity := float64(step) * robustnessStep
+		for trial := range trials {
+			if !survives(matrix, render, string(qr.data), model, severity, rand.New(rand.NewPCG(seed, uint64(trial)))) {
+				return threshold, nil
+			}
+		}
+		threshold = severity
+	}
+	return threshold, nil
+}
+
+// survives damages a copy of matrix, or of render for DamageBlurNoise, and
+// reports whether it still decodes to data
+func survives(matrix [][]module, render *image.Gray, data string, model DamageModel, severity float64, rng *rand.Rand) bool {
+	var decoded *qr
+	var err error
+	if model == DamageBlurNoise {
+		// The design is measured rather than the binarizer: as a reader
+		// would, every binarization method is tried
+		img := blurNoise(render, severity, rng)
+		for _, method := range []images.Binarization{images.BinarizationHybrid, images.BinarizationOtsu, images.BinarizationSauvola} {
+			if decoded, err = DecodeImage(img, images.Binarizer{Binarization: method}); err == nil {
+				break
+			}
+		}
+	} else {
+		decoded, err = DecodeMatrix(damageMatrix(matrix, model, severity, rng))
+	}
+	return err == nil && string(decoded.data) == data
+}
+
+// damageMatrix returns a copy of matrix with a damage model of the grid
+func damageMatrix(matrix [][]module, model DamageModel, severity float64, rng *rand.Rand) [][]module {
+	size := len(matrix)
+	damaged := make([][]module, size)
+	for i, row := range matrix {
+		damaged[i] = slices.Clone(row)
+	}
+
+	switch model {
+	case DamageFlips:
+		for i := range size {
+			for j := range size {
+				if rng.Float64() < severity {
+					if damaged[i][j].bit == One {
+						damaged[i][j].bit = Zero
+					} else {
+						damaged[i][j].bit = One
+					}
+				}
+			}
+		}
+	case DamageScratches:
+		scratched := make([][]bool, size)
+		for i := range scratched {
+			scratched[i] = make([]bool, size)
+		}
+		covered := 0
+		for float64(covered) < severity*float64(size*size) {
+			// Through a random point at a random angle, modules within half a
+			// module of the line
+			px, py := rng.Float64()*float64(size), rng.Float64()*float64(size)
+			sin, cos := math.Sincos(rng.Float64() * math.Pi)
+			for i := range size {
+				for j := range size {
+					if !scratched[i][j] && math.Abs((float64(j)+0.5-px)*sin-(float64(i)+0.5-py)*cos) <= 0.5 {
+						scratched[i][j] = true
+						damaged[i][j].bit = Zero
+						covered++
+					}
+				}
+			}
+		}
+	case DamageBlotch:
+		side := int(math.Round(math.Sqrt(severity) * float64(size)))
+		start := (size - side) / 2
+		for i := start; i < start+side; i++ {
+			for j := start; j < start+side; j++ {
+				damaged[i][j].bit = One
+			}
+		}
+	}
+	return damaged
+}
+
+// blurNoise box blurs img over a radius of up to 2 modules, then adds
+// Gaussian noise with a standard deviation of up to 128 grey levels
+func blurNoise(img *image.Gray, severity float64, rng *rand.Rand) *image.Gray {
+	blurred := boxBlur(img, int(math.Round(severity*2*damageModuleSize)))
+	addNoise(blurred, severity*128, rng)
+	return blurred
+}
+
+// boxBlur returns img averaged over squares of 2 x radius + 1 pixels, edges
+// extended
+func boxBlur(img *image.Gray, radius int) *image.Gray {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	level := func(x, y int) float64 {
+		return float64(img.GrayAt(bounds.Min.X+min(max(x, 0), w-1), bounds.Min.Y+min(max(y, 0), h-1)).Y)
+	}
+
+	// Horizontally, then vertically
+	rows := make([]float64, w*h)
+	for y := range h {
+		for x := range w {
+			sum := 0.0
+			for dx := -radius; dx <= radius; dx++ {
+				sum += level(x+dx, y)
+			}
+			rows[y*w+x] = sum / float64(2*radius+1)
+		}
+	}
+	blurred := image.NewGray(bounds)
+	for y := range h {
+		for x := range w {
+			sum := 0.0
+			for dy := -radius; dy <= radius; dy++ {
+				sum += rows[min(max(y+dy, 0), h-1)*w+x]
+			}
+			blurred.SetGray(bounds.Min.X+x, bounds.Min.Y+y, color.Gray{Y: uint8(math.Round(sum / float64(2*radius+1)))})
+		}
+	}
+	return blurred
+}
+
+// addNoise adds Gaussian noise of standard deviation sigma grey levels to
+// every pixel of img
+func addNoise(img *image.Gray, sigma float64, rng *rand.Rand) {
+	for i, v := range img.Pix {
+		img.Pix[i] = uint8(min(max(math.Round(float64(v)+rng.NormFloat64()*sigma), 0), 255))
+	}
+}
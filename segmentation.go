@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/harogaston/qr-decoder/modes"
+	"github.com/harogaston/qr-decoder/version"
+)
+
+// Modes taken into account when splitting input into segments
+var segmentModes = []modes.QRMode{
+	modes.NumericMode,
+	modes.AlphanumericMode,
+	modes.ByteMode,
+	modes.KanjiMode,
+	modes.HanziMode,
+}
+
+// GetSegments splits input into the sequence of segments that takes the
+// fewest bits for the version class of v (ISO/IEC 18004 Annex J). Byte mode
+// segments are transcoded to charset. In the FNC1 modes group separators are
+// allowed in alphanumeric segments, which are escaped accordingly.
+//
+// Costs are tracked in sixths of a bit so that numeric (10/3 bits per
+// character) and alphanumeric (11/2 bits per character) runs stay integer.
+func GetSegments(input string, v version.QRVersion, charset modes.Charset, fnc1 bool) ([]modes.Segment, error) {
+	if input == "" {
+		return []modes.Segment{{Mode: modes.GetMode(input), Data: input}}, nil
+	}
+
+	// Raw Shift JIS or GB 2312 input is not valid UTF-8 and cannot be split by runes
+	if !utf8.ValidString(input) {
+		if _, ok := modes.ToShiftJIS(input); ok {
+			return splitLongSegments([]modes.Segment{{Mode: modes.KanjiMode, Data: input}}, v), nil
+		}
+		if _, ok := modes.ToGB2312(input); ok && GetCharCountLength(v, modes.HanziMode) > 0 {
+			return splitLongSegments([]modes.Segment{{Mode: modes.HanziMode, Data: input}}, v), nil
+		}
+	}
+
+	// 1. Character boundaries
+	var offsets []int
+	var runes []rune
+	for i := 0; i < len(input); {
+		r, size := utf8.DecodeRuneInString(input[i:])
+		offsets = append(offsets, i)
+		runes = append(runes, r)
+		i += size
+	}
+	offsets = append(offsets, len(input))
+	n := len(runes)
+
+	// 2. Cost of starting a new segment in each mode
+	headCost := make([]int, len(segmentModes))
+	for m, mode := range segmentModes {
+		if GetCharCountLength(v, mode) == 0 {
+			headCost[m] = -1 // mode not available for this version
+			continue
+		}
+		headCost[m] = (modes.GetModeIndicatorBits(v, mode).Len() + GetCharCountLength(v, mode)) * 6
+		if mode == modes.HanziMode {
+			headCost[m] += modes.EncodeHanziSubset().Len() * 6
+		}
+	}
+
+	// 3. cost[i][m] is the minimum cost of characters 0..i with character i
+	// encoded in mode m. from[i][m] is the mode of character i-1 on that path.
+	cost := make([][]int, n)
+	from := make([][]int, n)
+	for i := range n {
+		cost[i] = make([]int, len(segmentModes))
+		from[i] = make([]int, len(segmentModes))
+		for m, mode := range segmentModes {
+			cost[i][m] = math.MaxInt
+			charCost := segmentCharCost(mode, runes[i], input[offsets[i]:offsets[i+1]], charset, fnc1)
+			if charCost < 0 || headCost[m] < 0 {
+				continue
+			}
+			if i == 0 {
+				cost[i][m] = headCost[m] + charCost
+				from[i][m] = m
+				continue
+			}
+			for p := range segmentModes {
+				if cost[i-1][p] == math.MaxInt {
+					continue
+				}
+				c := cost[i-1][p] + charCost
+				if p != m {
+					// Segments end on a whole number of bits
+					c = (cost[i-1][p]+5)/6*6 + headCost[m] + charCost
+				}
+				if c < cost[i][m] {
+					cost[i][m] = c
+					from[i][m] = p
+				}
+			}
+		}
+	}
+
+	// 4. Walk back from the cheapest final mode
+	for i := range n {
+		if slices.Min(cost[i]) == math.MaxInt {
+			return nil, fmt.Errorf("cannot encode %q in any mode with charset %s", input[offsets[i]:offsets[i+1]], charset.Name)
+		}
+	}
+	best := 0
+	for m := range segmentModes {
+		if cost[n-1][m] < cost[n-1][best] {
+			best = m
+		}
+	}
+	charModes := make([]int, n)
+	for i := n - 1; i >= 0; i-- {
+		charModes[i] = best
+		best = from[i][best]
+	}
+
+	// 5. Group consecutive characters sharing a mode
+	var segments []modes.Segment
+	start := 0
+	for i := 1; i <= n; i++ {
+		if i == n || charModes[i] != charModes[start] {
+			segment := modes.Segment{
+				Mode: segmentModes[charModes[start]],
+				Data: input[offsets[start]:offsets[i]],
+			}
+			if segment.Mode == modes.ByteMode {
+				data, err := charset.Encode(segment.Data)
+				if err != nil {
+					return nil, err
+				}
+				segment.Data = string(data)
+			}
+			if segment.Mode == modes.AlphanumericMode && fnc1 {
+				segment.Data = modes.EscapeFNC1Alphanumeric(segment.Data)
+			}
+			segments = append(segments, segment)
+			start = i
+		}
+	}
+	return splitLongSegments(segments, v), nil
+}
+
+// withECIHeader prepends an ECI segment designating charset. The header is
+// left out for the default charset, and for a detected charset when no byte
+// mode segment needs it.
+func withECIHeader(segments []modes.Segment, charset modes.Charset, explicit bool) []modes.Segment {
+	if charset.IsDefault() {
+		return segments
+	}
+	if !explicit && !slices.ContainsFunc(segments, func(s modes.Segment) bool {
+		return s.Mode == modes.ByteMode
+	}) {
+		return segments
+	}
+	return append([]modes.Segment{{Mode: modes.ECI, ECI: charset.ECI}}, segments...)
+}
+
+// segmentCharCost returns the cost in sixths of a bit of encoding r (the
+// text char) in the given mode, or -1 if the mode cannot encode it.
+func segmentCharCost(mode modes.QRMode, r rune, char string, charset modes.Charset, fnc1 bool) int {
+	switch mode {
+	case modes.NumericMode:
+		if modes.IsNumeric(r) {
+			return 20
+		}
+	case modes.AlphanumericMode:
+		if fnc1 && r == '%' {
+			return 2 * 33 // escaped as "%%"
+		}
+		if modes.IsAlphanumeric(r) || (fnc1 && r == modes.GroupSeparator) {
+			return 33
+		}
+	case modes.ByteMode:
+		if data, err := charset.Encode(char); err == nil {
+			return len(data) * 8 * 6
+		}
+	case modes.KanjiMode:
+		if r != utf8.RuneError && modes.IsKanji(r) {
+			return 13 * 6
+		}
+	case modes.HanziMode:
+		if r != utf8.RuneError && modes.IsHanzi(r) {
+			return 13 * 6
+		}
+	}
+	return -1
+}
+
+// splitLongSegments splits segments whose character count does not fit in
+// the character count indicator of v.
+func splitLongSegments(segments []modes.Segment, v version.QRVersion) []modes.Segment {
+	var res []modes.Segment
+	for _, s := range segments {
+		maxCount := 1<<GetCharCountLength(v, s.Mode) - 1
+		for maxCount > 0 && modes.CharCount(s.Mode, s.Data) > maxCount {
+			// Cut on a character boundary
+			cut := 0
+			for cut < len(s.Data) {
+				next := cut + segmentCharLen(s.Mode, s.Data[cut:])
+				if modes.CharCount(s.Mode, s.Data[:next]) > maxCount {
+					break
+				}
+				cut = next
+			}
+			res = append(res, modes.Segment{Mode: s.Mode, Data: s.Data[:cut]})
+			s.Data = s.Data[cut:]
+		}
+		res = append(res, s)
+	}
+	return res
+}
+
+// segmentCharLen returns the length in bytes of the first character of data.
+func segmentCharLen(mode modes.QRMode, data string) int {
+	if (mode == modes.KanjiMode || mode == modes.HanziMode) && !utf8.ValidString(data) {
+		return 2 // raw Shift JIS or GB 2312
+	}
+	_, size := utf8.DecodeRuneInString(data)
+	return size
+}
+
+// segmentsBitLength returns the number of bits taken by the segments in
+// version v, or -1 if a segment does not fit its character count indicator.
+func segmentsBitLength(segments []modes.Segment, v version.QRVersion) int {
+	total := 0
+	for _, s := range segments {
+		// Headers (ECI, FNC1, structured append) have no character count
+		ccBits := GetCharCountLength(v, s.Mode)
+		if slices.Contains(segmentModes, s.Mode) && modes.CharCount(s.Mode, s.Data) >= 1<<ccBits {
+			return -1
+		}
+		total += encodeSegment(s, v).Len()
+	}
+	return total
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/harogaston/qr-decoder/modes"
+	"github.com/harogaston/qr-decoder/writer"
+)
+
+// Error correction levels from lowest to highest recovery capacity
+var errCorrLevels = []errcorr{ERR_CORR_L, ERR_CORR_M, ERR_CORR_Q, ERR_CORR_H}
+
+// NewStructuredAppend splits the input data of r across a structured append
+// sequence of up to 16 symbols. With numSymbols 0 the fewest symbols that fit
+// are used. Each symbol gets the smallest version that fits its part of the
+// data, and the highest error correction level (starting at the requested
+// one) that still fits in that version.
+func NewStructuredAppend(r QRRequest, numSymbols int) ([]*qr, error) {
+	if r.segments != nil {
+		return nil, errors.New("structured append splits input_data, explicit segments are not supported")
+	}
+
+	minSymbols, maxSymbols := 1, modes.MaxStructuredAppendSymbols
+	if numSymbols != 0 {
+		if numSymbols < 1 || numSymbols > modes.MaxStructuredAppendSymbols {
+			return nil, fmt.Errorf("structured append supports 1 to %d symbols, got %d", modes.MaxStructuredAppendSymbols, numSymbols)
+		}
+		minSymbols, maxSymbols = numSymbols, numSymbols
+	}
+
+	// Every symbol uses the charset of the whole message
+	charset, err := r.getCharset()
+	if err != nil {
+		return nil, err
+	}
+	if !charset.IsDefault() {
+		r.charset = charset.Name
+	}
+
+	for n := minSymbols; n <= maxSymbols; n++ {
+		requests, err := structuredAppendRequests(r, splitPayload(r.input_data, n))
+		if err != nil {
+			if n == maxSymbols {
+				return nil, err
+			}
+			continue
+		}
+
+		symbols := make([]*qr, n)
+		for i, req := range requests {
+			symbols[i] = NewQRCode(req)
+		}
+		return symbols, nil
+	}
+	return nil, errors.New("structured append: no symbols requested")
+}
+
+// structuredAppendRequests builds the request of each symbol of the sequence,
+// selecting its version and error correction level.
+func structuredAppendRequests(r QRRequest, parts []string) ([]QRRequest, error) {
+	requests := make([]QRRequest, len(parts))
+	// Parity is computed over the whole message, as the segments encode it.
+	// The header has the same length whatever the parity, so it is filled in
+	// once every symbol is segmented.
+	var message []byte
+	for i, part := range parts {
+		req := r
+		req.input_data = part
+		req.structured_append = &modes.Segment{
+			Mode:     modes.StructuredAppend,
+			Position: i,
+			Total:    len(parts),
+		}
+
+		v, segments, err := analyze(req)
+		if err != nil {
+			return nil, fmt.Errorf("symbol %d of %d: %w", i+1, len(parts), err)
+		}
+		req.version = v.Number
+		message = append(message, segmentsMessage(segments)...)
+
+		// Spend the spare capacity of the version on error correction
+		requested := slices.Index(errCorrLevels, errcorr(req.err_corr_level))
+		for _, level := range errCorrLevels[requested+1:] {
+			boosted := req
+			boosted.err_corr_level = string(level)
+			if _, _, err := analyze(boosted); err != nil {
+				break
+			}
+			req = boosted
+		}
+		requests[i] = req
+	}
+	parity := modes.StructuredAppendParity(message)
+	for _, req := range requests {
+		req.structured_append.Parity = parity
+	}
+	return requests, nil
+}
+
+// segmentsMessage returns the message bytes encoded by segments: the
+// characters of numeric and alphanumeric mode, the charset bytes of byte
+// mode, Shift JIS in Kanji mode and GB 2312 in Hanzi mode.
+func segmentsMessage(segments []modes.Segment) []byte {
+	fnc1 := slices.ContainsFunc(segments, func(s modes.Segment) bool {
+		return s.Mode == modes.FNC1FirstPosition || s.Mode == modes.FNC1SecondPosition
+	})
+	var message []byte
+	for _, s := range segments {
+		switch s.Mode {
+		case modes.NumericMode, modes.ByteMode:
+			message = append(message, s.Data...)
+		case modes.AlphanumericMode:
+			if fnc1 {
+				message = append(message, modes.UnescapeFNC1Alphanumeric(s.Data)...)
+			} else {
+				message = append(message, s.Data...)
+			}
+		case modes.KanjiMode:
+			sjis, _ := modes.ToShiftJIS(s.Data)
+			message = append(message, sjis...)
+		case modes.HanziMode:
+			gb, _ := modes.ToGB2312(s.Data)
+			message = append(message, gb...)
+		}
+	}
+	return message
+}
+
+// splitPayload splits input into n parts of about the same length in bytes,
+// cutting on character boundaries.
+func splitPayload(input string, n int) []string {
+	parts := make([]string, 0, n)
+	start := 0
+	for i := 1; i <= n; i++ {
+		target := len(input) * i / n
+		end := start
+		for end < target {
+			end = min(end+segmentCharLen(modes.KanjiMode, input[end:]), len(input))
+		}
+		parts = append(parts, input[start:end])
+		start = end
+	}
+	return parts
+}
+
+// DrawStructuredAppend writes every symbol of a structured append sequence,
+// each in its own file (qr-1.svg, qr-2.svg, ...) or all together in one sheet.
+func DrawStructuredAppend(symbols []*qr, shape writer.Shape, sheet bool) {
+	reqs := make([]writer.SVGRequest, len(symbols))
+	for i, qr := range symbols {
+		reqs[i] = qr.svgRequest(shape)
+		reqs[i].Path = fmt.Sprintf("qr-%d.svg", i+1)
+	}
+
+	if sheet {
+		writer.WriteSheet(reqs, "")
+		return
+	}
+	for _, req := range reqs {
+		writer.WriteSVG(req)
+	}
+}
+
+// StructuredAppendMessage is a message reassembled from the symbols of a
+// structured append sequence
+type StructuredAppendMessage struct {
+	Parity byte
+	// Symbols by position, nil for those missing
+	Symbols []*qr
+	// Data of the whole message, only once no symbol is missing
+	Data []byte
+}
+
+// Missing returns the positions (0 based) of the symbols not found
+func (m StructuredAppendMessage) Missing() []int {
+	var missing []int
+	for i, symbol := range m.Symbols {
+		if symbol == nil {
+			missing = append(missing, i)
+		}
+	}
+	return missing
+}
+
+// Complete reports whether every symbol of the sequence was found
+func (m StructuredAppendMessage) Complete() bool {
+	return len(m.Missing()) == 0
+}
+
+// JoinStructuredAppend groups decoded symbols that carry a structured append
+// header by parity and number of symbols, orders them by position and
+// concatenates the data of complete sequences. The messages are in the order
+// their first symbol appears in symbols, the symbols of a position already
+// seen or past the end of their sequence are ignored. Symbols without header
+// are returned apart.
+func JoinStructuredAppend(symbols []*qr) (messages []StructuredAppendMessage, others []*qr) {
+	for _, symbol := range symbols {
+		header, ok := symbol.structuredAppendHeader()
+		if !ok {
+			others = append(others, symbol)
+			continue
+		}
+		if header.Position < 0 || header.Position >= header.Total {
+			continue
+		}
+		i := slices.IndexFunc(messages, func(m StructuredAppendMessage) bool {
+			return m.Parity == header.Parity && len(m.Symbols) == header.Total
+		})
+		if i < 0 {
+			messages = append(messages, StructuredAppendMessage{Parity: header.Parity, Symbols: make([]*qr, header.Total)})
+			i = len(messages) - 1
+		}
+		if messages[i].Symbols[header.Position] == nil {
+			messages[i].Symbols[header.Position] = symbol
+		}
+	}
+
+	for i, m := range messages {
+		if !m.Complete() {
+			continue
+		}
+		for _, symbol := range m.Symbols {
+			messages[i].Data = append(messages[i].Data, symbol.data...)
+		}
+	}
+	return messages, others
+}
+
+// structuredAppendHeader returns the structured append segment of a decoded
+// symbol, if any
+func (qr *qr) structuredAppendHeader() (modes.Segment, bool) {
+	for _, s := range qr.segments {
+		if s.Mode == modes.StructuredAppend {
+			return s, true
+		}
+	}
+	return modes.Segment{}, false
+}
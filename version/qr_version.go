@@ -8,7 +8,8 @@ type QRFormat string
 
 const FORMAT_QR_MODEL_2 = QRFormat("model2") // included in QR (2024)
 const FORMAT_QR = QRFormat("qr")             // 2024 specification NOT IMPLEMENTED yet
-const FORMAT_MICRO_QR = QRFormat("micro")    // NOT IMPLEMENTED yet
+const FORMAT_MICRO_QR = QRFormat("micro")    // M1 to M4
+const FORMAT_RMQR = QRFormat("rmqr")         // rectangular Micro QR (ISO/IEC 23941), R7x43 to R17x139
 
 type QRVersion struct {
 	Format QRFormat
@@ -17,8 +18,29 @@ type QRVersion struct {
 
 func (v QRVersion) String() string {
 	var format string
-	if v.Format == FORMAT_MICRO_QR {
+	switch v.Format {
+	case FORMAT_MICRO_QR:
 		format = "M"
+	case FORMAT_RMQR:
+		width, height := v.Size()
+		return fmt.Sprintf("R%dx%d", height, width)
 	}
 	return fmt.Sprintf("%s%d", format, v.Number)
 }
+
+// Size returns the width and height of the symbol in modules
+func (v QRVersion) Size() (width, height int) {
+	switch v.Format {
+	case FORMAT_MICRO_QR:
+		size := 9 + v.Number*2
+		return size, size
+	case FORMAT_RMQR:
+		if v.Number < 1 || v.Number > len(rmqrSizes) {
+			return 0, 0
+		}
+		s := rmqrSizes[v.Number-1]
+		return s.width, s.height
+	}
+	size := 21 + (v.Number-1)*4
+	return size, size
+}
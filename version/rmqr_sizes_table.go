@@ -0,0 +1,43 @@
+package version
+
+import (
+	"fmt"
+)
+
+// rMQR sizes, version numbers 1 to 32 follow this order
+//
+// | Height | Widths                     |
+// | :----- | :------------------------- |
+// | 7      | 43, 59, 77, 99, 139        |
+// | 9      | 43, 59, 77, 99, 139        |
+// | 11     | 27, 43, 59, 77, 99, 139    |
+// | 13     | 27, 43, 59, 77, 99, 139    |
+// | 15     | 43, 59, 77, 99, 139        |
+// | 17     | 43, 59, 77, 99, 139        |
+var rmqrSizes = []struct{ height, width int }{
+	{7, 43}, {7, 59}, {7, 77}, {7, 99}, {7, 139},
+	{9, 43}, {9, 59}, {9, 77}, {9, 99}, {9, 139},
+	{11, 27}, {11, 43}, {11, 59}, {11, 77}, {11, 99}, {11, 139},
+	{13, 27}, {13, 43}, {13, 59}, {13, 77}, {13, 99}, {13, 139},
+	{15, 43}, {15, 59}, {15, 77}, {15, 99}, {15, 139},
+	{17, 43}, {17, 59}, {17, 77}, {17, 99}, {17, 139},
+}
+
+// RMQRVersionCount is the number of rMQR sizes
+const RMQRVersionCount = 32
+
+// ParseRMQR parses an rMQR size such as "R7x43" (or "r7x43").
+func ParseRMQR(s string) (QRVersion, error) {
+	var height, width int
+	if _, err := fmt.Sscanf(s, "R%dx%d", &height, &width); err != nil {
+		if _, err := fmt.Sscanf(s, "r%dx%d", &height, &width); err != nil {
+			return QRVersion{}, fmt.Errorf("invalid rMQR size %q, expected e.g. R7x43", s)
+		}
+	}
+	for i, size := range rmqrSizes {
+		if size.height == height && size.width == width {
+			return QRVersion{Format: FORMAT_RMQR, Number: i + 1}, nil
+		}
+	}
+	return QRVersion{}, fmt.Errorf("no rMQR size R%dx%d", height, width)
+}
@@ -0,0 +1,17 @@
+package main
+
+import "github.com/harogaston/qr-decoder/payload"
+
+// NewWiFiRequest builds a request for a Wi-Fi network configuration, e.g. a
+// join card for a meeting room. The network is validated first: SSID of 1
+// to 32 bytes, password rules of its security type and EAP settings only
+// for WPA2-EAP.
+func NewWiFiRequest(w payload.WiFi, err_corr_level string) (QRRequest, error) {
+	if err := w.Validate(); err != nil {
+		return QRRequest{}, err
+	}
+	return QRRequest{
+		input_data:     w.String(),
+		err_corr_level: err_corr_level,
+	}, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"cmp"
+	"strings"
+	"testing"
+
+	"github.com/harogaston/qr-decoder/payload"
+)
+
+func TestWiFiPayload(t *testing.T) {
+	tests := []struct {
+		name string
+		wifi payload.WiFi
+		want string
+	}{
+		{"Escaped", payload.WiFi{Security: payload.WiFiWPA, SSID: `Room 4; "East"`, Password: `p\ss:word,1`, Hidden: true},
+			`WIFI:T:WPA;S:Room 4\; \"East\";P:p\\ss\:word\,1;H:true;;`},
+		{"Open", payload.WiFi{SSID: "Lobby"}, "WIFI:S:Lobby;;"},
+		{"WPA3", payload.WiFi{Security: payload.WiFiSAE, SSID: "Lab", Password: "x", TransitionDisable: true},
+			"WIFI:T:SAE;R:1;S:Lab;P:x;;"},
+		{"WPA2-EAP", payload.WiFi{Security: payload.WiFiWPA2EAP, SSID: "Corp", Password: "secret", EAPMethod: "PEAP",
+			Identity: "alice", Phase2Method: "MSCHAPV2"}, "WIFI:T:WPA2-EAP;S:Corp;P:secret;E:PEAP;I:alice;PH2:MSCHAPV2;;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := NewWiFiRequest(tt.wifi, ERR_CORR_M)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if req.input_data != tt.want {
+				t.Errorf("payload = %q, want %q", req.input_data, tt.want)
+			}
+
+			// Through a symbol and back
+			decoded, err := DecodeMatrix(NewQRCode(req).matrix)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w, err := payload.ParseWiFi(string(decoded.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := tt.wifi
+			want.Security = cmp.Or(want.Security, payload.WiFiOpen)
+			if *w != want {
+				t.Errorf("ParseWiFi() = %+v, want %+v", *w, want)
+			}
+		})
+	}
+
+	invalid := []payload.WiFi{
+		{SSID: strings.Repeat("x", 33)},
+		{Security: payload.WiFiWPA, SSID: "Net", Password: strings.Repeat("x", 64)},
+		{Security: payload.WiFiWPA, SSID: "Net", Password: "pässword"},
+		{Security: payload.WiFiWEP, SSID: "Net", Password: "123456"},
+		{Security: payload.WiFiSAE, SSID: "Net"},
+		{Security: payload.WiFiWPA, SSID: "Net", Password: "password", EAPMethod: "PEAP"},
+		{SSID: "Net", TransitionDisable: true},
+	}
+	for _, w := range invalid {
+		if _, err := NewWiFiRequest(w, ERR_CORR_M); err == nil {
+			t.Errorf("NewWiFiRequest(%+v) expected an error", w)
+		}
+	}
+}
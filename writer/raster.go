@@ -0,0 +1,158 @@
+package writer
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+)
+
+// Width of the light stroke around the dark modules of shapes other than
+// squares, in modules
+const moduleStrokeWidth = 0.125
+
+// Rasterize draws req as WriteSVG does, in grey levels with Scale pixels per
+// module: the module shapes, the finder patterns, the colours by their
+// luminance and the logo, read from its file. Each pixel takes the colour at
+// its center. Shapes without a definition in the SVG draw nothing there, and
+// nothing here either.
+func Rasterize(req SVGRequest) (*image.Gray, error) {
+	if req.Scale < 1 {
+		return nil, fmt.Errorf("writer: scale %d is not a number of pixels", req.Scale)
+	}
+	dark, light := luminance(req.Color, color.Black), luminance(req.Background, color.White)
+
+	width, dim := len(req.Cells[0]), len(req.Cells)
+	qz := req.quietZone()
+	img := image.NewGray(image.Rect(0, 0, (width+2*qz)*req.Scale, (dim+2*qz)*req.Scale))
+	for i := range img.Pix {
+		img.Pix[i] = light
+	}
+
+	var logo image.Image
+	logoPos, logoSize, cleared := logoLayout(dim, req.Shape)
+	clearedModules := map[image.Point]bool{}
+	if req.Logo != "" && logoSize >= 5 {
+		file, err := os.Open(req.Logo)
+		if err != nil {
+			return nil, fmt.Errorf("writer: logo: %w", err)
+		}
+		defer file.Close()
+		if logo, _, err = image.Decode(file); err != nil {
+			return nil, fmt.Errorf("writer: logo %s: %w", req.Logo, err)
+		}
+		for _, p := range cleared {
+			clearedModules[p] = true
+		}
+	}
+
+	for py := range img.Bounds().Dy() {
+		for px := range img.Bounds().Dx() {
+			// Position in modules from the upper left corner of the symbol
+			x := (float64(px)+0.5)/float64(req.Scale) - float64(qz)
+			y := (float64(py)+0.5)/float64(req.Scale) - float64(qz)
+			if x < 0 || y < 0 || x >= float64(width) || y >= float64(dim) {
+				continue
+			}
+			module := image.Point{X: int(x), Y: int(y)}
+			u, v := x-float64(module.X), y-float64(module.Y)
+
+			// Modules, then finder patterns and the logo over them
+			level := light
+			if req.Cells[module.Y][module.X] == color.Black && moduleContains(req.Shape, u, v) {
+				level = dark
+			}
+			for _, p := range req.finders() {
+				if fx, fy := x-float64(p.X), y-float64(p.Y); fx >= 0 && fy >= 0 && fx < 7 && fy < 7 {
+					level = light
+					for _, ring := range []struct {
+						side  float64
+						level uint8
+					}{{7, dark}, {5, light}, {3, dark}} {
+						offset := (7 - ring.side) / 2
+						if shapeContains(req.Shape, (fx-offset)/ring.side, (fy-offset)/ring.side) {
+							level = ring.level
+						}
+					}
+				}
+			}
+			if logo != nil {
+				if clearedModules[module] {
+					level = light
+				}
+				// Border 0.25 modules wide, 0.4 modules out of the logo
+				r := math.Hypot(x-float64(dim)/2, y-float64(dim)/2)
+				if math.Abs(r-(float64(logoSize)+0.8)/2) <= 0.125 {
+					level = dark
+				}
+				center := float64(logoPos) + float64(logoSize)/2
+				if math.Hypot(x-center, y-center) <= float64(logoSize)/2 {
+					if l, ok := logoLevel(logo, (x-float64(logoPos))/float64(logoSize), (y-float64(logoPos))/float64(logoSize), light); ok {
+						level = l
+					}
+				}
+			}
+			img.Pix[py*img.Stride+px] = level
+		}
+	}
+	return img, nil
+}
+
+// moduleContains reports whether (u, v), from 0 to 1 across a dark module,
+// is dark. Shapes other than squares have a light stroke around them.
+func moduleContains(shape Shape, u, v float64) bool {
+	if shape == ShapeSquare {
+		return shapeContains(shape, u, v)
+	}
+	// The inner half of the stroke covers the edge of the shape
+	inset := 1 - moduleStrokeWidth
+	return shapeContains(shape, 0.5+(u-0.5)/inset, 0.5+(v-0.5)/inset)
+}
+
+// shapeContains reports whether (u, v) is inside shape drawn over the unit
+// square
+func shapeContains(shape Shape, u, v float64) bool {
+	if u < 0 || v < 0 || u > 1 || v > 1 {
+		return false
+	}
+	du, dv := 2*u-1, 2*v-1
+	switch shape {
+	case ShapeSquare:
+		return true
+	case ShapeCircle:
+		return du*du+dv*dv <= 1
+	case ShapeSquircle:
+		return du*du*du*du+dv*dv*dv*dv <= 1
+	default:
+		return false
+	}
+}
+
+// logoLevel returns the grey level of logo at (u, v), from 0 to 1 across the
+// square the logo is fit into keeping its aspect ratio, composited over
+// background. ok is false outside the logo.
+func logoLevel(logo image.Image, u, v float64, background uint8) (level uint8, ok bool) {
+	bounds := logo.Bounds()
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+	scale := max(w, h)
+	x := u*scale - (scale-w)/2
+	y := v*scale - (scale-h)/2
+	if x < 0 || y < 0 || x >= w || y >= h {
+		return 0, false
+	}
+	r, g, b, a := logo.At(bounds.Min.X+int(x), bounds.Min.Y+int(y)).RGBA()
+	// The channels are premultiplied by alpha
+	y16 := (19595*r+38470*g+7471*b+1<<15)>>16 + uint32(background)*0x101*(0xFFFF-a)/0xFFFF
+	return uint8(y16 >> 8), true
+}
+
+// luminance returns the grey level of c, or of fallback if c is nil
+func luminance(c, fallback color.Color) uint8 {
+	if c == nil {
+		c = fallback
+	}
+	return color.GrayModel.Convert(c).(color.Gray).Y
+}
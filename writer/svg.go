@@ -2,6 +2,7 @@ package writer
 
 import (
 	"fmt"
+	"image"
 	"image/color"
 	"math/rand/v2"
 
@@ -14,32 +15,104 @@ import (
 const output_file_path string = "qr.svg"
 const logoRelativeSize = 1. / 5.
 
+// Default quiet zone width in modules, Micro QR only needs 2
+const quietZone = 4
+
 type SVGRequest struct {
 	Scale int
 	Cells [][]color.Color
 	Shape Shape
 	Logo  string
-	Color color.Color
+	Color color.Color // dark modules, defaults to black
+	// Light modules and quiet zone, defaults to white. Swapping Color and
+	// Background gives a reflectance reversed symbol.
+	Background color.Color
+	Path       string // output file, defaults to qr.svg
+	// Quiet zone width in modules, defaults to 4
+	QuietZone int
+	// Upper left corner of each 7 x 7 finder pattern, defaults to the
+	// three corners of QR Code. Micro QR and rMQR have a single one.
+	Finders []image.Point
+}
+
+func (req SVGRequest) quietZone() int {
+	if req.QuietZone == 0 {
+		return quietZone
+	}
+	return req.QuietZone
+}
+
+func (req SVGRequest) finders() []image.Point {
+	if req.Finders == nil {
+		width, height := len(req.Cells[0]), len(req.Cells)
+		return []image.Point{{X: 0, Y: 0}, {X: width - 7, Y: 0}, {X: 0, Y: height - 7}}
+	}
+	return req.Finders
 }
 
 func WriteSVG(req SVGRequest) {
-	if req.Color == nil {
-		req.Color = color.Black
+	path := req.Path
+	if path == "" {
+		path = output_file_path
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		fmt.Println("Error creating SVG file:", err)
+	}
+	defer file.Close()
+
+	canvas := render(req, "")
+	if _, err := canvas.WriteToIndent(file, "", "  "); err != nil {
+		panic(err)
 	}
+}
 
-	file, err := os.Create(output_file_path)
+// WriteSheet writes several symbols side by side into a single SVG file,
+// e.g. all the symbols of a structured append sequence.
+func WriteSheet(reqs []SVGRequest, path string) {
+	if path == "" {
+		path = output_file_path
+	}
+	file, err := os.Create(path)
 	if err != nil {
 		fmt.Println("Error creating SVG file:", err)
 	}
 	defer file.Close()
 
-	dim := len(req.Cells)
-	// TODO: Support Minimum Quiet Zone
-	quietZone := 4
+	sheet := svg.New()
+	offset, height := 0, 0
+	for i, req := range reqs {
+		// Symbol plus quiet zone on both sides
+		symbol := svg.G(render(req, fmt.Sprintf("s%d-", i)))
+		symbol.Attrs["transform"] = svg.String(fmt.Sprintf("translate(%d, 0)", offset))
+		sheet.AppendChildren(symbol)
+		offset += (len(req.Cells[0]) + 2*req.quietZone()) * req.Scale
+		height = max(height, (len(req.Cells)+2*req.quietZone())*req.Scale)
+	}
+	sheet.WidthHeight(float64(offset), float64(height), svg.Number)
+
+	if _, err := sheet.WriteToIndent(file, "", "  "); err != nil {
+		panic(err)
+	}
+}
+
+// render draws a single symbol. idPrefix keeps element IDs unique when
+// several symbols share a document.
+func render(req SVGRequest, idPrefix string) *svg.SVGElement {
+	if req.Color == nil {
+		req.Color = color.Black
+	}
+	if req.Background == nil {
+		req.Background = color.White
+	}
+
+	// Rectangular symbols (rMQR) are wider than high. The view box has the
+	// symbol at the origin, surrounded by the quiet zone.
+	width, dim := len(req.Cells[0]), len(req.Cells)
+	qz := float64(req.quietZone())
 	canvas := svg.New().
-		WidthHeight(float64(dim), float64(dim), svg.Number).
-		Transform(svg.String(fmt.Sprintf("scale(%d) translate(%d, %d)", req.Scale, quietZone, quietZone)))
-	canvas.Attrs["transform-origin"] = svg.String("0 0")
+		WidthHeight((float64(width)+2*qz)*float64(req.Scale), (float64(dim)+2*qz)*float64(req.Scale), svg.Number).
+		ViewBox(-qz, -qz, float64(width)+2*qz, float64(dim)+2*qz)
 
 	// Definitions
 	circle := svg.Circle().R(svg.Number(0.5)).ID(svg.String(ShapeCircle))
@@ -54,13 +127,20 @@ func WriteSVG(req SVGRequest) {
 		),
 	)
 
+	// Quiet zone and light modules
+	canvas.AppendChildren(
+		svg.Rect().XYWidthHeight(-qz, -qz, float64(width)+2*qz, float64(dim)+2*qz, svg.Number).Style(
+			svg.String(NoStrokeStyle(req.Color, req.Background, color.White)),
+		),
+	)
+
 	// All Modules
 	for y, row := range req.Cells {
 		for x, c := range row {
 			if c == color.Black {
 				canvas.AppendChildren(
 					svg.Use().XY(float64(x), float64(y), svg.Number).Href(svg.String(fmt.Sprintf("#%s", req.Shape))).Style(
-						svg.String(GetStyle(req.Shape, req.Color, c)),
+						svg.String(GetStyle(req.Shape, req.Color, req.Background, c)),
 					),
 				)
 			}
@@ -71,59 +151,39 @@ func WriteSVG(req SVGRequest) {
 	connect(req, canvas, dim)
 
 	// Superimpose finder patterns
-	finderBackground := svg.Use().Href(svg.String("#square")).Style("fill:white")
+	finderBackground := svg.Use().Href(svg.String("#square")).Style(svg.String(NoStrokeStyle(req.Color, req.Background, color.White)))
 	finderBackground.Attrs["transform"] = svg.String(fmt.Sprintf("scale(%d) translate(%f, %f)", 7, 0/7., 0/7.))
-	finderOuterRing := svg.Use().Href(svg.String("#" + string(req.Shape))).Style(svg.String(NoStrokeStyle(req.Color, color.Black)))
+	finderOuterRing := svg.Use().Href(svg.String("#" + string(req.Shape))).Style(svg.String(NoStrokeStyle(req.Color, req.Background, color.Black)))
 	finderOuterRing.Attrs["transform"] = svg.String(fmt.Sprintf("scale(%d) translate(%f, %f)", 7, 0/7., 0/7.))
-	finderMiddleRing := svg.Use().Href(svg.String("#" + string(req.Shape))).Style(svg.String(NoStrokeStyle(req.Color, color.White)))
+	finderMiddleRing := svg.Use().Href(svg.String("#" + string(req.Shape))).Style(svg.String(NoStrokeStyle(req.Color, req.Background, color.White)))
 	finderMiddleRing.Attrs["transform"] = svg.String(fmt.Sprintf("scale(%d) translate(%f, %f)", 5, 1./5., 1./5.))
-	finderCenterRing := svg.Use().Href(svg.String("#" + string(req.Shape))).Style(svg.String(NoStrokeStyle(req.Color, color.Black)))
+	finderCenterRing := svg.Use().Href(svg.String("#" + string(req.Shape))).Style(svg.String(NoStrokeStyle(req.Color, req.Background, color.Black)))
 	finderCenterRing.Attrs["transform"] = svg.String(fmt.Sprintf("scale(%d) translate(%f, %f)", 3, 2./3., 2./3.))
-	canvas.AppendChildren(
-		svg.G(
-			finderBackground,
-			finderOuterRing,
-			finderMiddleRing,
-			finderCenterRing,
-		).ID("finderpattern"),
-		svg.Use().Href(svg.String("#finderpattern")).XY(float64(dim-7), 0, svg.Number),
-		svg.Use().Href(svg.String("#finderpattern")).XY(0, float64(dim-7), svg.Number),
-	)
+	finder := svg.G(
+		finderBackground,
+		finderOuterRing,
+		finderMiddleRing,
+		finderCenterRing,
+	).ID(svg.String(idPrefix + "finderpattern"))
+	canvas.AppendChildren(svg.Defs(finder))
+	for _, p := range req.finders() {
+		canvas.AppendChildren(
+			svg.Use().Href(svg.String("#"+idPrefix+"finderpattern")).XY(float64(p.X), float64(p.Y), svg.Number),
+		)
+	}
 
 	// Draw logo ensuring a minimum size of 5 modules
-	logoSize := int(float64(dim) * logoRelativeSize)
-	logoSize += (logoSize ^ dim) & 1
+	logoPos, logoSize, cleared := logoLayout(dim, req.Shape)
 	fmt.Println("Logo size:", logoSize)
 
 	if req.Logo != "" && logoSize >= 5 {
-		logoPos := dim/2 - logoSize/2
-
 		// Cleanup overlapping QR modules
-		padding := 0.
-		switch req.Shape {
-		case ShapeCircle:
-			padding = 2
-		case ShapeSquircle:
-			padding = 3
-		case ShapeSquare:
-			padding = 2
-		}
-		startCell := logoPos
-		endCell := startCell + logoSize
-
-		center := float64(logoPos) + float64(logoSize)/2.
-		radius := float64(logoSize/2) + padding
-		for y := startCell - 1; y < endCell+1; y++ {
-			for x := startCell - 1; x < endCell+1; x++ {
-				dx := float64(x) + .5 - center
-				dy := float64(y) + .5 - center
-				distance := dx*dx + dy*dy
-				if distance < radius*radius {
-					canvas.AppendChildren(
-						svg.Use().XY(float64(x), float64(y), svg.Number).Href("#square").Style("fill:white"),
-					)
-				}
-			}
+		for _, p := range cleared {
+			canvas.AppendChildren(
+				svg.Use().XY(float64(p.X), float64(p.Y), svg.Number).Href("#square").Style(
+					svg.String(NoStrokeStyle(req.Color, req.Background, color.White)),
+				),
+			)
 		}
 
 		// Place logo with clipping path
@@ -134,21 +194,63 @@ func WriteSVG(req SVGRequest) {
 		logoBorderPos := float64(dim)/2. - float64(logoBorderScale)/2.
 		fmt.Println("Logo border position:", logoBorderPos)
 		fmt.Println("Logo border scale:", logoBorderScale)
-		logoBorder := svg.Use().Href("#circle").Style(svg.String(fmt.Sprintf("fill:none;stroke:black;stroke-width:%f", 0.25/float64(logoBorderScale))))
+		logoBorder := svg.Use().Href("#circle").Style(svg.String(fmt.Sprintf("fill:none;stroke:%s;stroke-width:%f", ColorToFill(req.Color), 0.25/float64(logoBorderScale))))
 		logoBorder.Attrs["transform"] = svg.String(fmt.Sprintf("scale(%f) translate(%f %f)", logoBorderScale, float64(logoBorderPos)/float64(logoBorderScale), float64(logoBorderPos)/float64(logoBorderScale)))
 		canvas.AppendChildren(
-			svg.ClipPath().ID("logoClip").AppendChildren(
+			svg.ClipPath().ID(svg.String(idPrefix+"logoClip")).AppendChildren(
 				logoClipPath,
 			),
 			logoBorder,
 			svg.Image().Href(svg.String(req.Logo)).XYWidthHeight(
 				float64(logoPos), float64(logoPos), float64(logoSize), float64(logoSize), svg.Number,
-			).ClipPath("url(#logoClip)"),
+			).ClipPath(svg.String("url(#"+idPrefix+"logoClip)")),
 		)
 	}
-	if _, err := canvas.WriteToIndent(file, "", "  "); err != nil {
-		panic(err)
+	return canvas
+}
+
+// logoLayout returns the upper left module and the size in modules of the
+// logo of a symbol dim modules high, and the modules cleared around it
+func logoLayout(dim int, shape Shape) (pos, size int, cleared []image.Point) {
+	size = int(float64(dim) * logoRelativeSize)
+	size += (size ^ dim) & 1
+	pos = dim/2 - size/2
+
+	padding := 0.
+	switch shape {
+	case ShapeCircle:
+		padding = 2
+	case ShapeSquircle:
+		padding = 3
+	case ShapeSquare:
+		padding = 2
+	}
+	startCell := pos
+	endCell := startCell + size
+
+	center := float64(pos) + float64(size)/2.
+	radius := float64(size/2) + padding
+	for y := startCell - 1; y < endCell+1; y++ {
+		for x := startCell - 1; x < endCell+1; x++ {
+			dx := float64(x) + .5 - center
+			dy := float64(y) + .5 - center
+			distance := dx*dx + dy*dy
+			if distance < radius*radius {
+				cleared = append(cleared, image.Point{X: x, Y: y})
+			}
+		}
+	}
+	return pos, size, cleared
+}
+
+// LogoModules returns the modules covered by the logo of req, which are
+// drawn light, or nil without a logo
+func LogoModules(req SVGRequest) []image.Point {
+	_, size, cleared := logoLayout(len(req.Cells), req.Shape)
+	if req.Logo == "" || size < 5 {
+		return nil
 	}
+	return cleared
 }
 
 // connect encapsulates the logic for drawing connected shapes (rectangles) based on module color.
@@ -168,13 +270,13 @@ func connect(req SVGRequest, canvas *svg.SVGElement, dim int) {
 		if dir == 0 { // Horizontal
 			canvas.AppendChildren(
 				svg.Rect().XYWidthHeight(float64(x-4)+0.5, float64(y-4), 1, 1, svg.Number).Style(
-					svg.String(NoStrokeStyle(req.Color, c)),
+					svg.String(NoStrokeStyle(req.Color, req.Background, c)),
 				),
 			)
 		} else { // Vertical
 			canvas.AppendChildren(
 				svg.Rect().XYWidthHeight(float64(x-4), float64(y-4)+0.5, 1, 1, svg.Number).Style(
-					svg.String(NoStrokeStyle(req.Color, c)),
+					svg.String(NoStrokeStyle(req.Color, req.Background, c)),
 				),
 			)
 		}
@@ -224,27 +326,29 @@ func connect(req SVGRequest, canvas *svg.SVGElement, dim int) {
 	}
 }
 
-func GetStyle(shape Shape, target, source color.Color) string {
+// GetStyle returns the style of a module drawn with shape. source is the
+// module color (black for dark modules), dark and light the colors to draw with.
+func GetStyle(shape Shape, dark, light, source color.Color) string {
 	switch shape {
 	case ShapeSquare:
-		return NoStrokeStyle(target, source)
+		return NoStrokeStyle(dark, light, source)
 	default:
-		return StrokeStyle(target, source)
+		return StrokeStyle(dark, light, source)
 	}
 }
 
-func NoStrokeStyle(target, source color.Color) string {
+func NoStrokeStyle(dark, light, source color.Color) string {
 	if source == color.Black {
-		return fmt.Sprintf("fill:%s;stroke:none", ColorToFill(target))
+		return fmt.Sprintf("fill:%s;stroke:none", ColorToFill(dark))
 	}
-	return "fill:white;stroke:none"
+	return fmt.Sprintf("fill:%s;stroke:none", ColorToFill(light))
 }
 
-func StrokeStyle(target, source color.Color) string {
+func StrokeStyle(dark, light, source color.Color) string {
 	if source == color.Black {
-		return fmt.Sprintf("fill:%s;stroke:white;stroke-width:0.125", ColorToFill(target))
+		return fmt.Sprintf("fill:%s;stroke:%s;stroke-width:0.125", ColorToFill(dark), ColorToFill(light))
 	}
-	return "fill:white;stroke:white;stroke-width:0.125"
+	return fmt.Sprintf("fill:%[1]s;stroke:%[1]s;stroke-width:0.125", ColorToFill(light))
 }
 
 func ColorToFill(c color.Color) string {